@@ -0,0 +1,90 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// The logging settings read and written by the admin handler.
+type adminSettings struct {
+	Level         string   `json:"level"`
+	Keys          []string `json:"keys"`
+	IncludeCaller bool     `json:"includeCaller"`
+	KnownKeys     []string `json:"knownKeys,omitempty"` // Read only
+}
+
+// A PUT to the admin handler; absent members are left unchanged.
+type adminUpdate struct {
+	Level         *string   `json:"level"`
+	Keys          *[]string `json:"keys"`
+	IncludeCaller *bool     `json:"includeCaller"`
+}
+
+// Returns an http.Handler for inspecting and changing the default logger's
+// settings at runtime; see Logger.AdminHandler.
+func AdminHandler() http.Handler {
+	return std.AdminHandler()
+}
+
+// Returns an http.Handler for inspecting and changing the logger's settings
+// at runtime. GET returns a JSON object such as
+//
+//	{"level":"normal","keys":["dcp"],"includeCaller":true,"knownKeys":["dcp","xdcr"]}
+//
+// where knownKeys lists the keys which have been logged to, as by KnownKeys.
+// PUT accepts an object with any of the other members, applies them (the
+// keys replace the currently enabled set), and returns the new settings.
+func (l *Logger) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			var update adminUpdate
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := l.applyAdminUpdate(update); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(adminSettings{
+			Level:         l.GetLevel().String(),
+			Keys:          l.EnabledKeys(),
+			IncludeCaller: l.IsIncludeCaller(),
+			KnownKeys:     l.KnownKeys(),
+		})
+	})
+}
+
+func (l *Logger) applyAdminUpdate(update adminUpdate) error {
+	if update.Level != nil {
+		level, err := ParseLevel(*update.Level)
+		if err != nil {
+			return err
+		}
+		l.setLevel(level)
+	}
+	if update.Keys != nil {
+		l.keys.replace(*update.Keys)
+	}
+	if update.IncludeCaller != nil {
+		l.setIncludeCaller(*update.IncludeCaller)
+	}
+	l.configChanged()
+	return nil
+}
@@ -0,0 +1,48 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	l := New(WithKeys("dcp"))
+	h := l.AdminHandler()
+
+	tests := []struct {
+		method, body string
+		status       int
+		response     string
+	}{
+		{"GET", "", 200, `{"level":"normal","keys":["dcp"],"includeCaller":true}`},
+		{"PUT", `{"level":"debug"}`, 200, `{"level":"debug","keys":["dcp"],"includeCaller":true}`},
+		{"PUT", `{"keys":["rest","auth"],"includeCaller":false}`, 200,
+			`{"level":"debug","keys":["auth","rest"],"includeCaller":false}`},
+		{"PUT", `{"level":"loud"}`, 400, `clog: unknown log level "loud"`},
+		{"PUT", `{`, 400, `unexpected EOF`},
+		{"POST", `{}`, 405, `method not allowed`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, "/", strings.NewReader(test.body)))
+		if w.Code != test.status {
+			t.Errorf("%s %s: expected status %d, got %d", test.method, test.body, test.status, w.Code)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != test.response {
+			t.Errorf("%s %s: expected %s, got %s", test.method, test.body, test.response, got)
+		}
+	}
+	if l.GetLevel() != LevelDebug || !l.KeyEnabled("auth") || l.KeyEnabled("dcp") {
+		t.Errorf("Settings were not applied")
+	}
+}
@@ -0,0 +1,217 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// AsyncOptions configure asynchronous output, enabled by EnableAsync.
+type AsyncOptions struct {
+	QueueSize      int           // Entries queued before messages are dropped (0 = synchronous)
+	ReportInterval time.Duration // How often dropped messages are summarized (default 10s)
+}
+
+// A DropCount is the number of messages at a level and key which have been
+// dropped because the async queue was full.
+type DropCount struct {
+	Level LogLevel
+	Key   string
+	Count int64
+}
+
+type dropKey struct {
+	level LogLevel
+	key   string
+}
+
+// A queue of entries written to the outputs by a background goroutine.
+type asyncQueue struct {
+	opts    AsyncOptions
+	entries chan asyncItem
+	done    chan struct{} // Closed when the goroutine exits
+
+	stopMu  sync.RWMutex // Held for reading while queueing entries
+	stopped bool         // Set before the stop item is sent, after which no entries are
+
+	mu         sync.Mutex // Protects the fields below
+	drops      map[dropKey]int64
+	total      map[dropKey]int64
+	lastReport time.Time
+}
+
+// An entry to write, or a request to signal when those before are written.
+type asyncItem struct {
+	e       *Entry
+	flushed chan struct{}
+	stop    bool
+}
+
+// Makes the default logger's output asynchronous; see Logger.EnableAsync.
+func EnableAsync(opts AsyncOptions) {
+	std.EnableAsync(opts)
+}
+
+// Returns the default logger's dropped message counts; see Logger.Dropped.
+func Dropped() []DropCount {
+	return std.Dropped()
+}
+
+// Writes messages to the outputs from a background goroutine, so that slow
+// outputs don't hold up the logging goroutines. When more than
+// opts.QueueSize messages are waiting, further messages are dropped; the
+// numbers dropped are logged as a warning every opts.ReportInterval, and are
+// returned by Dropped. Flush waits for the queued messages to be written.
+// Panic messages are written synchronously, after the queue, as they don't
+// return. A QueueSize of 0 makes the output synchronous again, after
+// writing out the queue.
+func (l *Logger) EnableAsync(opts AsyncOptions) {
+	var q *asyncQueue
+	if opts.QueueSize > 0 {
+		if opts.ReportInterval <= 0 {
+			opts.ReportInterval = 10 * time.Second
+		}
+		q = &asyncQueue{
+			opts:       opts,
+			entries:    make(chan asyncItem, opts.QueueSize),
+			done:       make(chan struct{}),
+			drops:      map[dropKey]int64{},
+			total:      map[dropKey]int64{},
+			lastReport: time.Now(),
+		}
+		go l.runAsync(q)
+	}
+	if old := (*asyncQueue)(atomic.SwapPointer(&l.async, unsafe.Pointer(q))); old != nil {
+		old.stopMu.Lock()
+		old.stopped = true
+		old.stopMu.Unlock()
+		old.entries <- asyncItem{stop: true}
+		<-old.done
+	}
+}
+
+// Returns the numbers of messages dropped since EnableAsync was called,
+// because the queue was full.
+func (l *Logger) Dropped() []DropCount {
+	q := l.loadAsync()
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return dropCounts(q.total)
+}
+
+func (l *Logger) loadAsync() *asyncQueue {
+	return (*asyncQueue)(atomic.LoadPointer(&l.async))
+}
+
+// Queues an entry, or counts it as dropped if the queue is full. Returns
+// false if the queue has been stopped, in which case the entry should be
+// written directly.
+func (q *asyncQueue) enqueue(e *Entry) bool {
+	q.stopMu.RLock()
+	defer q.stopMu.RUnlock()
+	if q.stopped {
+		return false
+	}
+	select {
+	case q.entries <- asyncItem{e: e}:
+	default:
+		k := dropKey{e.Level, e.Key}
+		q.mu.Lock()
+		q.drops[k]++
+		q.total[k]++
+		q.mu.Unlock()
+	}
+	return true
+}
+
+// Waits until the entries queued so far have been written, or if the queue
+// has been stopped, until the goroutine has written them all and exited.
+func (q *asyncQueue) flush() {
+	flushed := make(chan struct{})
+	select {
+	case q.entries <- asyncItem{flushed: flushed}:
+	case <-q.done:
+		return
+	}
+	select {
+	case <-flushed:
+	case <-q.done:
+	}
+}
+
+func (l *Logger) runAsync(q *asyncQueue) {
+	defer close(q.done)
+	t := time.NewTicker(q.opts.ReportInterval)
+	defer t.Stop()
+	for {
+		select {
+		case item := <-q.entries:
+			if item.e != nil {
+				l.writeNow(item.e)
+			}
+			if item.flushed != nil {
+				l.reportDrops(q)
+				close(item.flushed)
+			}
+			if item.stop {
+				// No entries are queued after the stop item.
+				l.reportDrops(q)
+				return
+			}
+		case <-t.C:
+			l.reportDrops(q)
+		}
+	}
+}
+
+// Logs a warning for each level and key which had messages dropped since
+// the last report.
+func (l *Logger) reportDrops(q *asyncQueue) {
+	q.mu.Lock()
+	drops := q.drops
+	since := time.Since(q.lastReport).Round(time.Second)
+	q.drops, q.lastReport = map[dropKey]int64{}, time.Now()
+	q.mu.Unlock()
+	for _, d := range dropCounts(drops) {
+		msg := fmt.Sprintf("dropped %d %s messages", d.Count, d.Level)
+		if d.Key != "" {
+			msg += " for key " + d.Key
+		}
+		l.writeNow(&Entry{
+			Time:    time.Now(),
+			Level:   LevelWarning,
+			Message: fmt.Sprintf("%s in last %v", msg, since),
+			color:   fgRed,
+			prefix:  levelPrefix(LevelWarning),
+		})
+	}
+}
+
+// Returns the counts sorted by key, then level.
+func dropCounts(m map[dropKey]int64) []DropCount {
+	counts := make([]DropCount, 0, len(m))
+	for k, n := range m {
+		counts = append(counts, DropCount{k.level, k.key, n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Key != counts[j].Key {
+			return counts[i].Key < counts[j].Key
+		}
+		return counts[i].Level < counts[j].Level
+	})
+	return counts
+}
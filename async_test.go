@@ -0,0 +1,129 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A writer which blocks its first write until released.
+type stallingWriter struct {
+	mu       sync.Mutex
+	b        bytes.Buffer
+	entered  chan struct{}
+	released chan struct{}
+	once     sync.Once
+}
+
+func (w *stallingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.entered)
+		<-w.released
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func TestAsyncDrops(t *testing.T) {
+	requireDebug(t)
+	w := &stallingWriter{entered: make(chan struct{}), released: make(chan struct{})}
+	l := New(WithOutput(w), WithFlags(0), WithIncludeCaller(false), WithLevel(LevelDebug))
+	DisableColor()
+	l.EnableAsync(AsyncOptions{QueueSize: 1, ReportInterval: time.Hour})
+	defer l.EnableAsync(AsyncOptions{})
+
+	l.Log("first")
+	<-w.entered // The first entry is being written, and the queue is empty
+	l.Log("second")
+	dcp := l.Named("dcp")
+	for i := 0; i < 3; i++ {
+		dcp.Debugf("dropped")
+	}
+	l.Log("dropped")
+	close(w.released)
+	l.Flush()
+
+	expect := []DropCount{{LevelNormal, "", 1}, {LevelDebug, "dcp", 3}}
+	if got := l.Dropped(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected drop counts %v, got %v", expect, got)
+	}
+	w.mu.Lock()
+	out := w.b.String()
+	w.mu.Unlock()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || lines[0] != "first" || lines[1] != "second" ||
+		!strings.HasPrefix(lines[2], "WARN: dropped 1 normal messages in last ") ||
+		!strings.HasPrefix(lines[3], "WARN: dropped 3 debug messages for key dcp in last ") {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
+
+func TestAsyncDisable(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.EnableAsync(AsyncOptions{QueueSize: 100})
+	for i := 0; i < 10; i++ {
+		l.Log("queued")
+	}
+	l.EnableAsync(AsyncOptions{})
+	if n := strings.Count(b.String(), "queued\n"); n != 10 {
+		t.Errorf("Expected the queue to be written out, got %d messages", n)
+	}
+	if l.Dropped() != nil {
+		t.Errorf("Expected no drop counts once synchronous")
+	}
+}
+
+func TestAsyncPanic(t *testing.T) {
+	b := &lockedBuffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.EnableAsync(AsyncOptions{QueueSize: 100})
+	defer l.EnableAsync(AsyncOptions{})
+
+	l.Log("queued")
+	func() {
+		defer func() { recover() }()
+		l.Panic("boom")
+	}()
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 || lines[0] != "queued" || !strings.HasSuffix(lines[1], "boom") {
+		t.Errorf("Expected the panic message after the queue, got %q", lines)
+	}
+}
+
+func TestAsyncStopped(t *testing.T) {
+	b := &lockedBuffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.EnableAsync(AsyncOptions{QueueSize: 1})
+	q := l.loadAsync()
+	l.EnableAsync(AsyncOptions{})
+
+	// As for a writer which loaded the queue just before it was stopped.
+	if q.enqueue(&Entry{Message: "late"}) {
+		t.Error("Expected a stopped queue to refuse entries")
+	}
+	done := make(chan struct{})
+	go func() {
+		q.flush()
+		q.flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Flushing a stopped queue blocked")
+	}
+}
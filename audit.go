@@ -0,0 +1,228 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// An AuditEvent records a security-relevant action. Actor, Action, Resource
+// and Result are required.
+type AuditEvent struct {
+	Time     time.Time // Default now
+	Actor    string    // Who, e.g. a user name
+	Action   string    // What, e.g. "bucket.create"
+	Resource string    // On what, e.g. a bucket name
+	Result   string    // e.g. "success" or "denied"
+	Fields   Fields    // Any further details
+}
+
+// The previous hash of the first record in an audit log.
+var auditGenesis = strings.Repeat("0", 2*sha256.Size)
+
+// The audit output of a logger.
+type auditLog struct {
+	mu   sync.Mutex // Serializes records, to keep the chain in order
+	w    io.Writer
+	file *os.File // Set by SetAuditFile
+	prev string   // Hash of the last record written
+}
+
+// Sets the default logger's audit output; see Logger.SetAuditOutput.
+func SetAuditOutput(w io.Writer) {
+	std.SetAuditOutput(w)
+}
+
+// Sets the default logger's audit output to a file; see
+// Logger.SetAuditFile.
+func SetAuditFile(path string) error {
+	return std.SetAuditFile(path)
+}
+
+// Records an audit event with the default logger; see Logger.Audit.
+func Audit(event AuditEvent) error {
+	return std.Audit(event)
+}
+
+// Sets the output of Audit, starting a new hash chain. The audit output is
+// separate from the logger's other outputs, and isn't affected by its level,
+// keys or hooks. A file previously set by SetAuditFile is closed. Pass nil to
+// stop auditing.
+func (l *Logger) SetAuditOutput(w io.Writer) {
+	var a *auditLog
+	if w != nil {
+		a = &auditLog{w: w, prev: auditGenesis}
+	}
+	l.setAudit(a)
+}
+
+func (l *Logger) setAudit(a *auditLog) {
+	if old := (*auditLog)(atomic.SwapPointer(&l.audit, unsafe.Pointer(a))); old != nil && old.file != nil {
+		old.mu.Lock()
+		old.file.Close()
+		old.mu.Unlock()
+	}
+}
+
+// Sets the output of Audit to the file at path, appending to it. If the file
+// already holds audit records, the chain continues from the last of them.
+func (l *Logger) SetAuditFile(path string) error {
+	prev, err := lastAuditHash(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	l.setAudit(&auditLog{w: f, file: f, prev: prev})
+	return nil
+}
+
+// Writes an audit event as a line of JSON to the audit output, whatever the
+// logger's level. Each record includes the SHA-256 hash of the record before
+// it, as "prev", and its own hash, as "hash", so that VerifyAuditLog can
+// detect records which have been altered, removed or reordered. Returns an
+// error if a required member of the event is missing, there is no audit
+// output, or the record could not be written.
+func (l *Logger) Audit(event AuditEvent) error {
+	a := (*auditLog)(atomic.LoadPointer(&l.audit))
+	if a == nil {
+		return errors.New("clog: no audit output")
+	}
+	for _, m := range [][2]string{{"actor", event.Actor}, {"action", event.Action},
+		{"resource", event.Resource}, {"result", event.Result}} {
+		if m[1] == "" {
+			return fmt.Errorf("clog: audit event has no %s", m[0])
+		}
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := &bytes.Buffer{}
+	b.WriteByte('{')
+	appendJSONString(b, "time", event.Time.UTC().Format(time.RFC3339Nano))
+	appendJSONString(b, "actor", event.Actor)
+	appendJSONString(b, "action", event.Action)
+	appendJSONString(b, "resource", event.Resource)
+	appendJSONString(b, "result", event.Result)
+	if len(event.Fields) > 0 {
+		appendJSONKey(b, "fields")
+		b.WriteByte('{')
+		for _, f := range appendKeyvals(nil, []interface{}{event.Fields}) {
+			appendJSON(b, f.Key, f.Value)
+		}
+		b.WriteByte('}')
+	}
+	appendJSONString(b, "prev", a.prev)
+	b.WriteByte('}')
+	hash := auditHash(b.Bytes())
+	b.Truncate(b.Len() - 1)
+	appendJSONString(b, "hash", hash)
+	b.WriteString("}\n")
+	if _, err := a.w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("clog: writing audit record: %w", err)
+	}
+	a.prev = hash
+	return nil
+}
+
+// Returns the hex SHA-256 hash of a record without its hash member.
+func auditHash(record []byte) string {
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:])
+}
+
+// Splits an audit record into the record as it was hashed, and its hash.
+func splitAuditRecord(line string) (record, hash string, err error) {
+	i := strings.LastIndex(line, `,"hash":"`)
+	if i < 0 || !strings.HasSuffix(line, `"}`) {
+		return "", "", errors.New("no hash")
+	}
+	return line[:i] + "}", line[i+len(`,"hash":"`) : len(line)-2], nil
+}
+
+// Returns the hash of the last record in the audit log at path, or the
+// genesis hash if there is no such file.
+func lastAuditHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return auditGenesis, nil
+	} else if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	prev := auditGenesis
+	err = scanAuditLog(f, func(n int, line string) error {
+		_, hash, err := splitAuditRecord(line)
+		if err != nil {
+			return fmt.Errorf("clog: audit log %s line %d: %w", path, n, err)
+		}
+		prev = hash
+		return nil
+	})
+	return prev, err
+}
+
+// Checks the hash chain of the audit records read from r, returning an error
+// identifying the first record which doesn't match its hash or the record
+// before it.
+func VerifyAuditLog(r io.Reader) error {
+	prev := auditGenesis
+	return scanAuditLog(r, func(n int, line string) error {
+		record, hash, err := splitAuditRecord(line)
+		if err != nil {
+			return fmt.Errorf("clog: audit record %d: %w", n, err)
+		}
+		var fields struct {
+			Prev string `json:"prev"`
+		}
+		if err := json.Unmarshal([]byte(record), &fields); err != nil {
+			return fmt.Errorf("clog: audit record %d: %w", n, err)
+		}
+		if fields.Prev != prev {
+			return fmt.Errorf("clog: audit record %d doesn't follow the record before it", n)
+		}
+		if auditHash([]byte(record)) != hash {
+			return fmt.Errorf("clog: audit record %d doesn't match its hash", n)
+		}
+		prev = hash
+		return nil
+	})
+}
+
+// Calls f with each non-empty line read from r, numbered from 1.
+func scanAuditLog(r io.Reader, f func(n int, line string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if line := scanner.Text(); line != "" {
+			if err := f(n, line); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
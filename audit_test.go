@@ -0,0 +1,88 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	logged := &bytes.Buffer{}
+	audited := &bytes.Buffer{}
+	l := New(WithOutput(logged), WithLevel(LevelPanic))
+	if err := l.Audit(AuditEvent{Actor: "bob"}); err == nil {
+		t.Errorf("Expected an error without an audit output")
+	}
+	l.SetAuditOutput(audited)
+
+	if err := l.Audit(AuditEvent{Actor: "bob", Action: "bucket.create"}); err == nil ||
+		err.Error() != "clog: audit event has no resource" {
+		t.Errorf("Expected a missing resource error, got %v", err)
+	}
+	when := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := l.Audit(AuditEvent{Time: when, Actor: "bob", Action: "bucket.create",
+		Resource: "travel", Result: "success", Fields: Fields{"ramMB": 256}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Audit(AuditEvent{Actor: "eve", Action: "bucket.drop",
+		Resource: "travel", Result: "denied"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if logged.Len() != 0 {
+		t.Errorf("Expected nothing in the general output, got %q", logged.String())
+	}
+	lines := strings.Split(strings.TrimSpace(audited.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"time":"2024-05-01T10:00:00Z","actor":"bob",`+
+		`"action":"bucket.create","resource":"travel","result":"success","fields":{"ramMB":256},`+
+		`"prev":"`+auditGenesis+`","hash":"`) {
+		t.Fatalf("Unexpected audit log %q", audited.String())
+	}
+	if err := VerifyAuditLog(strings.NewReader(audited.String())); err != nil {
+		t.Errorf("Expected the log to verify, got %v", err)
+	}
+
+	tampered := strings.Replace(audited.String(), `"denied"`, `"success"`, 1)
+	if err := VerifyAuditLog(strings.NewReader(tampered)); err == nil ||
+		err.Error() != "clog: audit record 2 doesn't match its hash" {
+		t.Errorf("Expected a hash mismatch, got %v", err)
+	}
+	if err := VerifyAuditLog(strings.NewReader(lines[1] + "\n")); err == nil ||
+		err.Error() != "clog: audit record 1 doesn't follow the record before it" {
+		t.Errorf("Expected a broken chain, got %v", err)
+	}
+}
+
+func TestAuditFileContinuesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		l := New()
+		if err := l.SetAuditFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Audit(AuditEvent{Actor: "bob", Action: "login", Resource: "ui", Result: "success"}); err != nil {
+			t.Fatal(err)
+		}
+		l.SetAuditOutput(nil)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := VerifyAuditLog(f); err != nil {
+		t.Errorf("Expected the log to verify, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchOptions control how an output which sends entries over the network
+// batches and retries them.
+type BatchOptions struct {
+	BatchSize     int           // Records per batch (default 512)
+	FlushInterval time.Duration // Maximum time a record is held (default 5s)
+	MaxRetries    int           // Further attempts to send a batch (default 3; -1 for none)
+	RetryBackoff  time.Duration // Wait before the first retry, doubling after each (default 100ms)
+	MaxPending    int           // Records held while sending is slow, beyond which new ones are dropped (default 16 batches)
+}
+
+// A rendered entry waiting to be sent.
+type batchRecord struct {
+	time  time.Time
+	key   []byte // Used for partitioning, by outputs which support it
+	value []byte
+}
+
+// Wraps a send error which retrying won't fix, such as the endpoint
+// rejecting the request as malformed.
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Collects records, sending them in batches from a background goroutine.
+type batcher struct {
+	drops uint64 // Records dropped because pending was full or the batcher closed, accessed atomically
+
+	opts    BatchOptions
+	circuit *circuit
+	send    func([]batchRecord) error
+	dropped func([]batchRecord, error) // Called with batches which couldn't be sent, if set
+
+	sendMu sync.Mutex // Serializes sends, so that batches go out in order
+
+	mu      sync.Mutex
+	pending []batchRecord
+	closed  bool
+	err     error // Last send error
+
+	flush     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newBatcher(opts BatchOptions, circuit *circuit, send func([]batchRecord) error,
+	dropped func([]batchRecord, error)) *batcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 16 * opts.BatchSize
+	}
+	b := &batcher{
+		opts:    opts,
+		circuit: circuit,
+		send:    send,
+		dropped: dropped,
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// Queues a record, or drops it if too many are pending or the batcher is
+// closed.
+func (b *batcher) add(r batchRecord) {
+	b.mu.Lock()
+	if b.closed || len(b.pending) >= b.opts.MaxPending {
+		b.mu.Unlock()
+		atomic.AddUint64(&b.drops, 1)
+		return
+	}
+	b.pending = append(b.pending, r)
+	full := len(b.pending) >= b.opts.BatchSize
+	b.mu.Unlock()
+	if full {
+		select {
+		case b.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Sends the pending records, retrying with backoff unless the error is
+// permanent. If every attempt fails, or the output is down, the records are passed to dropped and the error
+// returned.
+func (b *batcher) Flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	err := ErrSinkDown
+	if b.circuit.allow() {
+		backoff := b.opts.RetryBackoff
+		err = b.send(batch)
+		for retry := 0; err != nil && !isPermanent(err) && retry < b.opts.MaxRetries; retry++ {
+			time.Sleep(backoff)
+			backoff *= 2
+			err = b.send(batch)
+		}
+		b.circuit.record(err)
+	}
+	if err != nil && b.dropped != nil {
+		b.dropped(batch, err)
+	}
+	b.mu.Lock()
+	b.err = err
+	b.mu.Unlock()
+	return err
+}
+
+func (b *batcher) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Returns the number of records dropped because too many were pending, or
+// they were added after Close.
+func (b *batcher) Dropped() uint64 {
+	return atomic.LoadUint64(&b.drops)
+}
+
+// Stops the background goroutine and sends the pending records. Records
+// added afterwards are dropped.
+func (b *batcher) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.closeOnce.Do(func() { close(b.done) })
+	b.wg.Wait()
+	return b.Flush()
+}
+
+func (b *batcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.flush:
+		case <-b.done:
+			return
+		}
+		b.Flush()
+	}
+}
@@ -0,0 +1,43 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"sync"
+	"unsafe"
+)
+
+// Buffers larger than this aren't returned to the pool, so that one huge
+// message doesn't pin its memory.
+const maxPooledBuffer = 64 * 1024
+
+// Buffers in which outputs format entries.
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() <= maxPooledBuffer {
+		bufferPool.Put(b)
+	}
+}
+
+// Returns the contents of b as a string, without copying. It is only valid
+// until b is next modified or returned to the pool.
+func bufferString(b *bytes.Buffer) string {
+	if b.Len() == 0 {
+		return ""
+	}
+	return unsafe.String(&b.Bytes()[0], b.Len())
+}
@@ -0,0 +1,124 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// BufferOptions control when a BufferedWriter flushes.
+type BufferOptions struct {
+	Size          int           `json:"size,omitempty" yaml:"size,omitempty"`                   // Flush when this many bytes are buffered (default 64KB)
+	FlushInterval time.Duration `json:"flushInterval,omitempty" yaml:"flushInterval,omitempty"` // Flush at least this often (default 1s)
+}
+
+// A BufferedWriter batches writes to an underlying writer, such as a
+// RotatingFile, to save system calls when logging at high volume. It is
+// flushed when the buffer fills, every FlushInterval, and by Flush, Sync and
+// Close; Logger.Flush flushes it too, as does Fatal. It is safe for
+// concurrent use.
+type BufferedWriter struct {
+	w    io.Writer
+	mu   sync.Mutex // Protects buf
+	buf  *bufio.Writer
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Returns a BufferedWriter writing to w.
+func NewBufferedWriter(w io.Writer, opts BufferOptions) *BufferedWriter {
+	if opts.Size <= 0 {
+		opts.Size = 64 * 1024
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	b := &BufferedWriter{
+		w:    w,
+		buf:  bufio.NewWriterSize(w, opts.Size),
+		done: make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.flushPeriodically(opts.FlushInterval)
+	return b
+}
+
+func (b *BufferedWriter) flushPeriodically(interval time.Duration) {
+	defer b.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			b.Flush()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// Write buffers p, writing out the buffer first if p doesn't fit in it.
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(p) > b.buf.Available() && b.buf.Buffered() > 0 {
+		if err := b.buf.Flush(); err != nil { // Rather than splitting p
+			return 0, err
+		}
+	}
+	return b.buf.Write(p)
+}
+
+// Flush writes out any buffered data.
+func (b *BufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Flush()
+}
+
+// Sync writes out any buffered data, then commits the underlying writer's
+// contents to stable storage, if it is a file.
+func (b *BufferedWriter) Sync() error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	if s, ok := b.w.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Reopen writes out any buffered data, then reopens the underlying writer if
+// it is a RotatingFile; see Logger.Reopen.
+func (b *BufferedWriter) Reopen() error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	if r, ok := b.w.(reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Close stops the periodic flushing and writes out any buffered data, then
+// closes the underlying writer if it is an io.Closer.
+func (b *BufferedWriter) Close() error {
+	close(b.done)
+	b.wg.Wait()
+	err := b.Flush()
+	if c, ok := b.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferedWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffered.log")
+	f, err := OpenRotatingFile(path, RotationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBufferedWriter(f, BufferOptions{Size: 64, FlushInterval: time.Hour})
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+
+	contents := func() string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+	l.Log("one")
+	if got := contents(); got != "" {
+		t.Errorf("Expected nothing written before a flush, got %q", got)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(); got != "one\n" {
+		t.Errorf("Expected one line after Sync, got %q", got)
+	}
+
+	l.Log("%s", strings.Repeat("x", 100)) // Larger than the buffer
+	if got := contents(); !strings.HasSuffix(got, "x\n") {
+		t.Errorf("Expected a long line to be written through, got %q", got)
+	}
+
+	l.Log("two")
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(); !strings.HasSuffix(got, "two\n") {
+		t.Errorf("Expected Close to flush, got %q", got)
+	}
+	if _, err := f.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("Expected Close to close the file, got %v", err)
+	}
+}
+
+func TestBufferedWriterInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffered.log")
+	f, err := OpenRotatingFile(path, RotationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBufferedWriter(f, BufferOptions{FlushInterval: 10 * time.Millisecond})
+	defer b.Close()
+	b.Write([]byte("tick\n"))
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if data, _ := os.ReadFile(path); string(data) == "tick\n" {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("Buffer wasn't flushed after the interval")
+}
@@ -0,0 +1,89 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"unsafe"
+)
+
+var readBuildInfo = debug.ReadBuildInfo
+
+// Logs the build information of the program from the default logger; see
+// Logger.LogBuildInfo.
+func LogBuildInfo() {
+	std.LogBuildInfo()
+}
+
+// Logs the main module's path and version, the VCS revision (with
+// "+modified" appended if the working tree was dirty), commit time and Go
+// version the program was built from, as fields of a "build info" message,
+// for example at startup:
+//
+//	build info module=github.com/couchbase/indexer version=v7.6.0 revision=3f2a9c1 vcsTime=2024-05-01T10:00:00Z go=go1.22.3
+//
+// The revision is also added to the global fields (see SetGlobalFields), so
+// that every entry identifies the build.
+func (l *Logger) LogBuildInfo() {
+	keyvals := []interface{}{"go", runtime.Version()}
+	if info, ok := readBuildInfo(); ok {
+		keyvals = []interface{}{"module", info.Main.Path, "version", info.Main.Version}
+		var revision, vcsTime string
+		modified := false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.time":
+				vcsTime = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if revision != "" {
+			if modified {
+				revision += "+modified"
+			}
+			keyvals = append(keyvals, "revision", revision)
+			l.addGlobalFields(Field{Key: "revision", Value: revision})
+		}
+		if vcsTime != "" {
+			keyvals = append(keyvals, "vcsTime", vcsTime)
+		}
+		keyvals = append(keyvals, "go", info.GoVersion)
+	}
+	if l.wants(LevelNormal) {
+		l.With(keyvals...).doPrintf("build info")
+	}
+}
+
+// Adds to the global fields, replacing any with the same keys.
+func (l *Logger) addGlobalFields(fields ...Field) {
+	for {
+		ogp := atomic.LoadPointer(&l.globalFields)
+		var global []Field
+		if ogp != nil {
+		outer:
+			for _, f := range *(*[]Field)(ogp) {
+				for _, n := range fields {
+					if n.Key == f.Key {
+						continue outer
+					}
+				}
+				global = append(global, f)
+			}
+		}
+		global = append(global, fields...)
+		if atomic.CompareAndSwapPointer(&l.globalFields, ogp, unsafe.Pointer(&global)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"runtime/debug"
+	"testing"
+)
+
+func TestLogBuildInfo(t *testing.T) {
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.22.3",
+			Main:      debug.Module{Path: "github.com/couchbase/indexer", Version: "v7.6.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "3f2a9c1"},
+				{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.SetGlobalFields(Fields{"service": "indexer"})
+	l.LogBuildInfo()
+	l.Log("next")
+
+	expect := "build info module=github.com/couchbase/indexer version=v7.6.0 revision=3f2a9c1+modified " +
+		"vcsTime=2024-05-01T10:00:00Z go=go1.22.3\nnext\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+	if fields, n := l.entryFields(); n != 2 || fields[1] != (Field{Key: "revision", Value: "3f2a9c1+modified"}) {
+		t.Errorf("Expected the revision to be a global field, got %v", fields)
+	}
+}
@@ -0,0 +1,49 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// An EntryCallback renders an entry for text outputs, in place of its level
+// prefix, key and message, or returns "" to drop the entry.
+type EntryCallback func(e Entry) string
+
+// Sets the default logger's entry callback; see Logger.SetEntryCallback.
+func SetEntryCallback(k EntryCallback) {
+	std.SetEntryCallback(k)
+}
+
+// Thread-safe API for setting a callback rendering each entry, which unlike
+// SetLoggerCallback is passed the whole Entry: its time, level, key, caller
+// and fields. Text outputs write the callback's rendering followed by the
+// fields and caller, and still write their timestamp; use DisableTime if the
+// callback writes its own. The callback is called last, for the entries
+// which are to be written, after the hooks, and takes precedence over one set
+// by SetLoggerCallback. nil removes it.
+func (l *Logger) SetEntryCallback(k EntryCallback) {
+	if k == nil {
+		atomic.StorePointer(&l.entryCallback, nil)
+		return
+	}
+	atomic.StorePointer(&l.entryCallback, unsafe.Pointer(&k))
+}
+
+// Passes the entry to the entry callback, if there is one. Returns false if
+// it was dropped.
+func (l *Logger) runEntryCallback(e *Entry) bool {
+	kp := atomic.LoadPointer(&l.entryCallback)
+	if kp == nil {
+		return true
+	}
+	e.text = (*(*EntryCallback)(kp))(*e)
+	return e.text != ""
+}
@@ -0,0 +1,50 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryCallback(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("DCP"))
+	l.SetCallerOptions(CallerOptions{Format: CallerFile})
+	l.SetEntryCallback(func(e Entry) string {
+		if strings.Contains(e.Message, "secret") {
+			return ""
+		}
+		return fmt.Sprintf("%s [%s] %s/%s", e.Time.UTC().Format("2006"),
+			strings.ToUpper(e.Level.String()), e.Key, e.Message)
+	})
+	l.SetLoggerCallback(func(level, format string, args ...interface{}) string {
+		return "overridden"
+	})
+	l.DebugTo("DCP", "filtered by level")
+	l.With("vb", 7).WarnTo("DCP", "stream ended")
+	l.Log("secret")
+
+	year := time.Now().UTC().Format("2006")
+	exp := year + " [WARNING] DCP/stream ended vb=7 -- callback_test.go:34\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+
+	b.Reset()
+	l.SetEntryCallback(nil)
+	l.SetLoggerCallback(nil)
+	l.Log("plain")
+	if b.String() != "plain\n" {
+		t.Errorf("Unexpected output after removing the callback %q", b.String())
+	}
+}
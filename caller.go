@@ -0,0 +1,115 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// CallerFormat selects how text and JSON outputs render an entry's caller.
+type CallerFormat int
+
+const (
+	CallerFunction     = CallerFormat(iota) // "clog.Foo() at foo.go:12"
+	CallerFile                              // "foo.go:12"
+	CallerPackageFile                       // "github.com/couchbase/clog/foo.go:12"
+	CallerFullPath                          // "/home/me/src/clog/foo.go:12"
+	CallerFunctionOnly                      // "clog.Foo()"
+)
+
+// CallerOptions control how and when callers are included, if caller
+// information is enabled.
+type CallerOptions struct {
+	Format CallerFormat
+
+	// Write the caller at the start of text lines, after the timestamp, as
+	// the log package's Lshortfile flag does, rather than at the end.
+	First bool
+
+	// Only look up callers of messages at this level or above, such as
+	// LevelWarning, to save the cost of runtime.Caller on hot paths.
+	Level LogLevel
+}
+
+// Sets the default logger's caller options; see Logger.SetCallerOptions.
+func SetCallerOptions(opts CallerOptions) {
+	std.SetCallerOptions(opts)
+}
+
+// Thread-safe API for setting how callers are rendered, and for which
+// levels they are looked up. The options take effect only while caller
+// information is included; see SetIncludeCaller.
+func (l *Logger) SetCallerOptions(opts CallerOptions) {
+	atomic.StorePointer(&l.callerOpts, unsafe.Pointer(&opts))
+}
+
+// Returns the logger's caller options.
+func (l *Logger) GetCallerOptions() CallerOptions {
+	if p := atomic.LoadPointer(&l.callerOpts); p != nil {
+		return *(*CallerOptions)(p)
+	}
+	return CallerOptions{}
+}
+
+// Returns whether the caller of a message at the given level is included.
+func (l *Logger) wantsCaller(level LogLevel) bool {
+	if !l.IsIncludeCaller() {
+		return false
+	}
+	p := atomic.LoadPointer(&l.callerOpts)
+	return p == nil || level >= (*CallerOptions)(p).Level
+}
+
+// Writes the caller in the given format, without allocating.
+func (c *Caller) writeFormat(b *bytes.Buffer, format CallerFormat) {
+	if c.Function == "" {
+		b.WriteString("???")
+		return
+	}
+	switch format {
+	case CallerFunction, CallerFunctionOnly:
+		b.WriteString(lastComponent(c.Function))
+		b.WriteString("()")
+		if format == CallerFunctionOnly {
+			return
+		}
+		b.WriteString(" at ")
+		b.WriteString(lastComponent(c.File))
+	case CallerPackageFile:
+		if pkg := packagePath(c.Function); pkg != "" {
+			b.WriteString(pkg)
+			b.WriteByte('/')
+		}
+		b.WriteString(lastComponent(c.File))
+	case CallerFullPath:
+		b.WriteString(c.File)
+	default:
+		b.WriteString(lastComponent(c.File))
+	}
+	b.WriteByte(':')
+	b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(c.Line), 10))
+}
+
+// Returns the import path of the package of a function, given its name as
+// reported by the runtime, such as "github.com/couchbase/clog.(*Logger).Log".
+// The runtime escapes dots in the last element of the path as "%2e".
+func packagePath(function string) string {
+	dir := ""
+	if i := strings.LastIndexByte(function, '/'); i >= 0 {
+		dir, function = function[:i+1], function[i+1:]
+	}
+	if i := strings.IndexByte(function, '.'); i >= 0 {
+		return dir + strings.ReplaceAll(function[:i], "%2e", ".")
+	}
+	return ""
+}
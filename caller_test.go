@@ -0,0 +1,72 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCallerFormat(t *testing.T) {
+	DisableColor()
+	_, file, _, _ := runtime.Caller(0)
+	for _, test := range []struct {
+		opts   CallerOptions
+		expect string
+	}{
+		{CallerOptions{}, "WARN: slow -- clog.TestCallerFormat() at caller_test.go:39\n"},
+		{CallerOptions{Format: CallerFile}, "WARN: slow -- caller_test.go:39\n"},
+		{CallerOptions{Format: CallerPackageFile}, "WARN: slow -- github.com/couchbase/clog/caller_test.go:39\n"},
+		{CallerOptions{Format: CallerFullPath}, "WARN: slow -- " + file + ":39\n"},
+		{CallerOptions{Format: CallerFunctionOnly}, "WARN: slow -- clog.TestCallerFormat()\n"},
+		{CallerOptions{Format: CallerFile, First: true}, "caller_test.go:39: WARN: slow\n"},
+		{CallerOptions{Level: LevelWarning}, "WARN: slow -- clog.TestCallerFormat() at caller_test.go:39\n"},
+		{CallerOptions{Level: LevelError}, "WARN: slow\n"},
+	} {
+		b := &bytes.Buffer{}
+		l := New(WithOutput(b), WithFlags(0))
+		l.SetCallerOptions(test.opts)
+		l.Warnf("slow")
+		if b.String() != test.expect {
+			t.Errorf("With %+v, got %q, expected %q", test.opts, b.String(), test.expect)
+		}
+	}
+	if filepath.Base(file) != "caller_test.go" {
+		t.Errorf("Unexpected file %q", file)
+	}
+}
+
+func TestCallerFormatJSON(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard))
+	l.AddOutput(b, SinkOptions{Format: FormatJSON})
+	l.SetCallerOptions(CallerOptions{Format: CallerPackageFile})
+	l.Warnf("slow")
+
+	if !strings.Contains(b.String(), `"caller":"github.com/couchbase/clog/caller_test.go:`) {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	for function, expect := range map[string]string{
+		"github.com/couchbase/clog.(*Logger).Log": "github.com/couchbase/clog",
+		"github.com/couchbase/clog.Log.func1":     "github.com/couchbase/clog",
+		"main.main":                               "main",
+		"gopkg.in/yaml%2ev3.Marshal":              "gopkg.in/yaml.v3",
+	} {
+		if pkg := packagePath(function); pkg != expect {
+			t.Errorf("packagePath(%q) = %q, expected %q", function, pkg, expect)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SinkHealth is the state of a network output.
+type SinkHealth int
+
+const (
+	SinkUp       = SinkHealth(iota) // The last send succeeded
+	SinkDegraded                    // Recent sends failed
+	SinkDown                        // Sends are suspended, apart from periodic probes
+)
+
+var sinkHealthNames = []string{
+	SinkUp:       "up",
+	SinkDegraded: "degraded",
+	SinkDown:     "down",
+}
+
+// Returns the health's name, such as "degraded".
+func (h SinkHealth) String() string {
+	if h >= 0 && int(h) < len(sinkHealthNames) {
+		return sinkHealthNames[h]
+	}
+	return fmt.Sprintf("SinkHealth(%d)", int(h))
+}
+
+// Implements encoding.TextMarshaler.
+func (h SinkHealth) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// CircuitOptions control when a network output is considered down. While it
+// is down, entries aren't sent to it, but dropped (or spooled, if the output
+// supports it), so that logging isn't slowed by an unreachable endpoint.
+type CircuitOptions struct {
+	Failures      int           // Consecutive failures after which the output is down (default 5)
+	ProbeInterval time.Duration // Time between attempts to send while down (default 30s)
+}
+
+// SinkState describes the health of a network output, as returned by
+// SinkStatus.
+type SinkState struct {
+	Name                string // e.g. "http https://es:9200/logs/_bulk"
+	Health              SinkHealth
+	ConsecutiveFailures int
+	LastError           error     // The error of the last failed send
+	LastSuccess         time.Time // Zero if no send has succeeded
+}
+
+// Returned by the Flush methods of batching outputs while they are down.
+var ErrSinkDown = errors.New("clog: output is down")
+
+// Tracks the health of a network output, breaking the circuit after
+// consecutive failures.
+type circuit struct {
+	name string
+	opts CircuitOptions
+
+	mu          sync.Mutex
+	failures    int
+	lastErr     error
+	lastSuccess time.Time
+	nextProbe   time.Time // While down, when to next allow a send
+}
+
+// Implemented by network outputs.
+type healthReporter interface {
+	health() SinkState
+}
+
+func newCircuit(name string, opts CircuitOptions) *circuit {
+	if opts.Failures <= 0 {
+		opts.Failures = 5
+	}
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = 30 * time.Second
+	}
+	return &circuit{name: name, opts: opts}
+}
+
+// Returns whether to attempt a send: always unless the output is down, and
+// then once per probe interval.
+func (c *circuit) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures < c.opts.Failures {
+		return true
+	}
+	now := time.Now()
+	if now.Before(c.nextProbe) {
+		return false
+	}
+	c.nextProbe = now.Add(c.opts.ProbeInterval)
+	return true
+}
+
+// Records the outcome of a send.
+func (c *circuit) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.failures, c.lastErr, c.lastSuccess = 0, nil, time.Now()
+		return
+	}
+	c.failures++
+	c.lastErr = err
+	if c.failures == c.opts.Failures {
+		c.nextProbe = time.Now().Add(c.opts.ProbeInterval)
+	}
+}
+
+func (c *circuit) health() SinkState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := SinkState{
+		Name:                c.name,
+		ConsecutiveFailures: c.failures,
+		LastError:           c.lastErr,
+		LastSuccess:         c.lastSuccess,
+	}
+	switch {
+	case c.failures >= c.opts.Failures:
+		s.Health = SinkDown
+	case c.failures > 0:
+		s.Health = SinkDegraded
+	}
+	return s
+}
+
+// Returns the health of the default logger's network outputs; see
+// Logger.SinkStatus.
+func SinkStatus() []SinkState {
+	return std.SinkStatus()
+}
+
+// Returns the health of the logger's network outputs (GELF, OTLP, Kafka,
+// HTTP and socket outputs), for the service to report in its own health
+// checks.
+func (l *Logger) SinkStatus() []SinkState {
+	var states []SinkState
+	for _, s := range l.loadSinks() {
+		if h, ok := s.ew.(healthReporter); ok {
+			states = append(states, h.health())
+		}
+	}
+	return states
+}
@@ -0,0 +1,75 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSinkStatus(t *testing.T) {
+	var requests, up int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&up) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	l := New(WithOutput(io.Discard))
+	h, err := l.AddHTTPOutput(HTTPOptions{
+		Endpoint: srv.URL,
+		Batch:    BatchOptions{FlushInterval: time.Hour, MaxRetries: -1},
+		Circuit:  CircuitOptions{Failures: 2, ProbeInterval: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	status := func() SinkState {
+		states := l.SinkStatus()
+		if len(states) != 1 || states[0].Name != "http "+srv.URL {
+			t.Fatalf("Unexpected status %+v", states)
+		}
+		return states[0]
+	}
+	if s := status(); s.Health != SinkUp || s.LastError != nil {
+		t.Errorf("Expected the output to start up, got %+v", s)
+	}
+
+	l.Log("one")
+	h.Flush()
+	if s := status(); s.Health != SinkDegraded || s.ConsecutiveFailures != 1 || s.LastError == nil {
+		t.Errorf("Expected the output to be degraded, got %+v", s)
+	}
+	l.Log("two")
+	h.Flush()
+	l.Log("three")
+	if err := h.Flush(); err != ErrSinkDown || atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("Expected the circuit to be broken after 2 requests, got %v after %d", err, requests)
+	}
+	if s := status(); s.Health != SinkDown {
+		t.Errorf("Expected the output to be down, got %+v", s)
+	}
+
+	atomic.StoreInt32(&up, 1)
+	time.Sleep(30 * time.Millisecond)
+	l.Log("four")
+	if err := h.Flush(); err != nil {
+		t.Errorf("Expected the probe to succeed, got %v", err)
+	}
+	if s := status(); s.Health != SinkUp || s.LastSuccess.IsZero() {
+		t.Errorf("Expected the output to be up again, got %+v", s)
+	}
+}
@@ -9,68 +9,62 @@
 package clog
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
-	"unsafe"
 )
 
 // Log level type.
 type LogLevel int32
 
+// Levels in increasing order of severity. LevelNotice and LevelCritical were
+// inserted in order, which renumbered LevelWarning, LevelError and
+// LevelPanic: code which stores or compares levels as numbers, rather than
+// with these constants or their names, must be updated.
 const (
 	LevelTrace = LogLevel(iota)
 	LevelDebug
 	LevelNormal
+	LevelNotice // Significant but normal conditions, such as syslog's notice
 	LevelWarning
 	LevelError
+	LevelCritical // Failures needing urgent attention, such as syslog's crit
 	LevelPanic
 )
 
-// Logging package level (Setting Level directly isn't thread-safe).
-var Level = LevelNormal
-
-// Should caller be included in log messages (stored as 0 or 1 to enable thread-safe access)
-var includeCaller = int32(1)
-
-// Set of To() key strings that are enabled.
-var keys unsafe.Pointer = unsafe.Pointer(&map[string]bool{})
+// Level of TEMP messages, which are logged regardless of the log level.
+const levelTemp = LogLevel(math.MaxInt32)
 
-var logger *log.Logger = log.New(os.Stderr, "", log.LstdFlags)
-var logCallBack func(level, format string, args ...interface{}) string
+// Logging package level, as it was before any call to SetLevel.
+//
+// Deprecated: Use SetLevel and GetLevel. Level is no longer read or written
+// by this package, so assigning to it has no effect.
+var Level = LevelNormal
 
 // Thread-safe API for setting log level.
 func SetLevel(to LogLevel) {
-	for {
-		if atomic.CompareAndSwapInt32((*int32)(&Level),
-			int32(GetLevel()), int32(to)) {
-			break
-		}
-	}
+	std.SetLevel(to)
 }
 
 // Thread-safe API for fetching log level.
 func GetLevel() LogLevel {
-	return LogLevel(atomic.LoadInt32((*int32)(&Level)))
+	return std.GetLevel()
 }
 
 // Thread-safe API for configuring whether caller information is included in log output. (default true)
 func SetIncludeCaller(enabled bool) {
-	for {
-		if atomic.CompareAndSwapInt32((*int32)(&includeCaller),
-			btoi(IsIncludeCaller()), btoi(enabled)) {
-			break
-		}
-	}
+	std.SetIncludeCaller(enabled)
 }
 
 // Thread-safe API for indicating whether caller information is included in log output.
 func IsIncludeCaller() bool {
-	return atomic.LoadInt32((*int32)(&includeCaller)) == 1
+	return std.IsIncludeCaller()
 }
 
 // Category that the data falls under.
@@ -93,251 +87,279 @@ func init() {
 	}
 }
 
+// Tags data as belonging to a category, so that it can be redacted from the
+// logs. How the data is rendered depends on the redaction level.
 func Tag(category ContentCategory, data interface{}) interface{} {
 	if category < numTypes {
-		return fmt.Sprintf("<%s>%s</%s>", tags[category], data, tags[category])
+		if GetRedactionLevel() == RedactNone {
+			return data
+		}
+		return tagString(category, fmt.Sprintf("%s", data))
 	}
 
 	return data
 }
 
+// Renders data of a category according to the redaction level.
+func tagString(category ContentCategory, data string) string {
+	if category >= numTypes {
+		return data
+	}
+	switch GetRedactionLevel() {
+	case RedactNone:
+		return data
+	case RedactFull:
+		if category == UserData {
+			data = redactionHash(data)
+		}
+	}
+	return "<" + tags[category] + ">" + data + "</" + tags[category] + ">"
+}
+
 // Flags returns the output flags for clog.
 func Flags() int {
-	return logger.Flags()
+	return std.Flags()
 }
 
 // SetFlags sets the output flags for clog.
 func SetFlags(flags int) {
-	logger.SetFlags(flags)
+	std.SetFlags(flags)
 }
 
-// Disables ANSI color in log output.
+// Whether text output is colored: 0 or 1, accessed atomically.
+var colorOn = int32(1)
+
+// Thread-safe API for disabling ANSI color in log output.
 func DisableColor() {
-	reset, dim, fgRed, fgYellow = "", "", "", ""
+	atomic.StoreInt32(&colorOn, 0)
+}
+
+// Thread-safe API for re-enabling ANSI color in log output, after
+// DisableColor.
+func EnableColor() {
+	atomic.StoreInt32(&colorOn, 1)
+}
+
+// Reports whether ANSI color is enabled.
+func colorEnabled() bool {
+	return atomic.LoadInt32(&colorOn) == 1
 }
 
 // Disable timestamps in logs.
 func DisableTime() {
-	logger.SetFlags(logger.Flags() &^ (log.Ldate | log.Ltime | log.Lmicroseconds))
+	std.DisableTime()
 }
 
 // SetOutput sets the output destination for clog
 func SetOutput(w io.Writer) {
-	logger = log.New(w, "", logger.Flags())
+	std.SetOutput(w)
 }
 
 // Parses a comma-separated list of log keys, probably coming from an argv flag.
 // The key "bw" is interpreted as a call to NoColor, not a key.
 func ParseLogFlag(flag string) {
-	ParseLogFlags(strings.Split(flag, ","))
+	std.ParseLogFlag(flag)
 }
 
 // Set a prefix function for the log message. Prefix function is called for
 // each log message and it returns a prefix which is logged before each message
+//
+// Deprecated: Use SetEntryCallback, which is passed the whole Entry, or
+// AddHook, which can be stacked.
 func SetLoggerCallback(k func(level, format string, args ...interface{}) string) {
-	// Clear the date and time flag
-	DisableTime()
-	logCallBack = k
+	std.SetLoggerCallback(k)
 }
 
 // Parses an array of log keys, probably coming from a argv flags.
 // The key "bw" is interpreted as a call to NoColor, not a key.
+// "all" or "*" enables every key, and a key prefixed with "-" or "!" is
+// disabled, so "all,-dcp" enables everything except "dcp" and its children.
 func ParseLogFlags(flags []string) {
-	for _, key := range flags {
-		switch key {
-		case "bw":
-			DisableColor()
-		case "notime":
-			DisableTime()
-		default:
-			EnableKey(key)
-			for strings.HasSuffix(key, "+") {
-				key = key[:len(key)-1]
-				EnableKey(key) // "foo+" also enables "foo"
-			}
-		}
-	}
-	Log("Enabling logging: %s", flags)
+	std.ParseLogFlags(flags)
 }
 
 // Enable logging messages sent to this key
 func EnableKey(key string) {
-	for {
-		opp := atomic.LoadPointer(&keys)
-		oldk := (*map[string]bool)(opp)
-		newk := map[string]bool{key: true}
-		for k := range *oldk {
-			newk[k] = true
-		}
-		if atomic.CompareAndSwapPointer(&keys, opp, unsafe.Pointer(&newk)) {
-			return
-		}
-	}
+	std.EnableKey(key)
 }
 
 // Disable logging messages sent to this key
 func DisableKey(key string) {
-	for {
-		opp := atomic.LoadPointer(&keys)
-		oldk := (*map[string]bool)(opp)
-		newk := map[string]bool{}
-		for k := range *oldk {
-			if k != key {
-				newk[k] = true
-			}
-		}
-		if atomic.CompareAndSwapPointer(&keys, opp, unsafe.Pointer(&newk)) {
-			return
-		}
-	}
+	std.DisableKey(key)
+}
+
+// Check to see if messages at a level may be logged; see Logger.LevelEnabled.
+func LevelEnabled(level LogLevel) bool {
+	return std.LevelEnabled(level)
 }
 
 // Check to see if logging is enabled for a key
 func KeyEnabled(key string) bool {
-	m := *(*map[string]bool)(atomic.LoadPointer(&keys))
-	return m[key]
+	return std.KeyEnabled(key)
 }
 
-type callInfo struct {
-	funcname, filename string
-	line               int
+// The function, and position in its source, which logged an Entry.
+type Caller struct {
+	Function, File string
+	Line           int
 }
 
-func (c callInfo) String() string {
-	if c.funcname == "" {
+func (c Caller) String() string {
+	if c.Function == "" {
 		return "???"
 	}
-	return fmt.Sprintf("%s() at %s:%d", lastComponent(c.funcname),
-		lastComponent(c.filename), c.line)
+	return fmt.Sprintf("%s() at %s:%d", lastComponent(c.Function),
+		lastComponent(c.File), c.Line)
+}
+
+// Writes the caller as String does, without allocating.
+func (c *Caller) write(b *bytes.Buffer) {
+	c.writeFormat(b, CallerFunction)
 }
 
+// Resolved *Caller, keyed by program counter.
+var callerCache sync.Map
+
 // Returns a string identifying a function on the call stack.
 // Use depth=1 for the caller of the function that calls GetCallersName, etc.
-func getCallersName(depth int) callInfo {
-	pc, file, line, ok := runtime.Caller(depth + 1)
-	if !ok {
-		return callInfo{}
+// Resolving a program counter is costly, so results are cached.
+func getCallersName(depth int) Caller {
+	return *callerAt(depth + 1)
+}
+
+// Like getCallersName, but returns the cached Caller itself, which must not
+// be modified.
+func callerAt(depth int) *Caller {
+	var pcs [1]uintptr
+	if runtime.Callers(depth+2, pcs[:]) == 0 {
+		return &Caller{}
 	}
+	return cachedCallerForPC(pcs[0])
+}
 
-	fnname := ""
-	if fn := runtime.FuncForPC(pc); fn != nil {
-		fnname = fn.Name()
+func cachedCallerForPC(pc uintptr) *Caller {
+	if c, ok := callerCache.Load(pc); ok {
+		return c.(*Caller)
 	}
+	c := callerForPC(pc)
+	callerCache.Store(pc, &c)
+	return &c
+}
 
-	return callInfo{fnname, file, line}
+// Returns the Caller for a program counter, as returned by runtime.Callers.
+func callerForPC(pc uintptr) Caller {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.Function == "" {
+		return Caller{}
+	}
+	return Caller{frame.Function, frame.File, frame.Line}
 }
 
 // Logs a message to the console, but only if the corresponding key is true in keys.
 func To(key string, format string, args ...interface{}) {
-	if GetLevel() <= LevelNormal && KeyEnabled(key) {
-		if logCallBack != nil {
-			str := logCallBack("INFO", format, args...)
-			if str != "" {
-				logger.Print(str)
-			}
-		} else {
-			logger.Printf(fgYellow+key+": "+reset+format, args...)
-		}
+	if std.wantsKey(LevelNormal, key) {
+		std.doTo(key, format, args...)
+	}
+}
+
+// Logs a message to the console at the given level, but only if the key is
+// enabled. It doesn't panic or exit at LevelPanic.
+func Tof(level LogLevel, key string, format string, args ...interface{}) {
+	if std.wantsKey(level, key) {
+		std.doTof(level, key, format, args...)
+	}
+}
+
+// Logs a formatted warning to the console, but only if the key is enabled.
+func WarnTo(key string, format string, args ...interface{}) {
+	if std.wantsKey(LevelWarning, key) {
+		std.doTof(LevelWarning, key, format, args...)
+	}
+}
+
+// Logs a formatted error message to the console, but only if the key is enabled.
+func ErrorTo(key string, format string, args ...interface{}) {
+	if std.wantsKey(LevelError, key) {
+		std.doTof(LevelError, key, format, args...)
 	}
 }
 
 // Logs a message to the console.
 func Log(format string, args ...interface{}) {
-	if GetLevel() <= LevelNormal {
-		if logCallBack != nil {
-			str := logCallBack("INFO", format, args...)
-			if str != "" {
-				logger.Print(str)
-			}
-		} else {
-			logger.Printf(format, args...)
-		}
+	if std.wants(LevelNormal) {
+		std.doPrintf(format, args...)
 	}
 }
 
 // Prints a formatted message to the console.
 func Printf(format string, args ...interface{}) {
-	if GetLevel() <= LevelNormal {
-		if logCallBack != nil {
-			str := logCallBack("INFO", format, args...)
-			if str != "" {
-				logger.Print(str)
-			}
-		} else {
-			logger.Printf(format, args...)
-		}
+	if std.wants(LevelNormal) {
+		std.doPrintf(format, args...)
 	}
 }
 
 // Prints a message to the console.
 func Print(args ...interface{}) {
-	if GetLevel() <= LevelNormal {
-		if logCallBack != nil {
-			str := logCallBack("INFO", "", args...)
-			if str != "" {
-				logger.Print(str)
-			}
-		} else {
-			logger.Print(args...)
-		}
+	if std.wants(LevelNormal) {
+		std.doPrint(args...)
 	}
 }
 
 // If the error is not nil, logs error to the console. Returns the input error
 // for easy chaining.
 func Error(err error) error {
-	if GetLevel() <= LevelError && err != nil {
-		doLogf(fgRed, "ERRO", "%v", err)
+	if std.wants(LevelError) && err != nil {
+		std.doError(err)
 	}
 	return err
 }
 
 // Logs a formatted error message to the console
 func Errorf(format string, args ...interface{}) {
-	if GetLevel() <= LevelError {
-		doLogf(fgRed, "ERRO", format, args...)
+	if std.wants(LevelError) {
+		std.doLogf(LevelError, fgRed, "ERRO", format, args...)
 	}
 }
 
-// Logs a formatted warning to the console
-func Warnf(format string, args ...interface{}) {
-	if GetLevel() <= LevelWarning {
-		doLogf(fgRed, "WARN", format, args...)
+// Logs a formatted critical error message to the console
+func Criticalf(format string, args ...interface{}) {
+	if std.wants(LevelCritical) {
+		std.doLogf(LevelCritical, fgRed, "CRIT", format, args...)
 	}
 }
 
-// Logs a warning to the console
-func Warn(args ...interface{}) {
-	if GetLevel() <= LevelWarning {
-		doLog(fgRed, "WARN", args...)
+// Logs a critical error message to the console
+func Critical(args ...interface{}) {
+	if std.wants(LevelCritical) {
+		std.doLog(LevelCritical, fgRed, "CRIT", args...)
 	}
 }
 
-// Logs a formatted debug message to the console
-func Debugf(format string, args ...interface{}) {
-	if GetLevel() <= LevelDebug {
-		doLogf(fgRed, "DEBU", format, args...)
+// Logs a formatted warning to the console
+func Warnf(format string, args ...interface{}) {
+	if std.wants(LevelWarning) {
+		std.doLogf(LevelWarning, fgRed, "WARN", format, args...)
 	}
 }
 
-// Logs a debug message to the console
-func Debug(args ...interface{}) {
-	if GetLevel() <= LevelDebug {
-		doLog(fgRed, "DEBU", args...)
+// Logs a warning to the console
+func Warn(args ...interface{}) {
+	if std.wants(LevelWarning) {
+		std.doLog(LevelWarning, fgRed, "WARN", args...)
 	}
 }
 
-// Logs a formatted trace message to the console
-func Tracef(format string, args ...interface{}) {
-	if GetLevel() <= LevelTrace {
-		doLogf(fgRed, "TRAC", format, args...)
+// Logs a formatted notice to the console
+func Noticef(format string, args ...interface{}) {
+	if std.wants(LevelNotice) {
+		std.doLogf(LevelNotice, fgRed, "NOTI", format, args...)
 	}
 }
 
-// Logs a trace message to the console
-func Trace(args ...interface{}) {
-	if GetLevel() <= LevelTrace {
-		doLog(fgRed, "TRAC", args...)
+// Logs a notice to the console
+func Notice(args ...interface{}) {
+	if std.wants(LevelNotice) {
+		std.doLog(LevelNotice, fgRed, "NOTI", args...)
 	}
 }
 
@@ -345,25 +367,25 @@ func Trace(args ...interface{}) {
 // temporary logging calls added during development and not to be checked in, hence its
 // distinctive name (which is visible and easy to search for before committing.)
 func TEMPf(format string, args ...interface{}) {
-	doLogf(fgYellow, "TEMP", format, args...)
+	std.doLogf(levelTemp, fgYellow, "TEMP", format, args...)
 }
 
 // Logs a highlighted message prefixed with "TEMP". This function is intended for
 // temporary logging calls added during development and not to be checked in, hence its
 // distinctive name (which is visible and easy to search for before committing.)
 func TEMP(args ...interface{}) {
-	doLog(fgYellow, "TEMP", args...)
+	std.doLog(levelTemp, fgYellow, "TEMP", args...)
 }
 
 // Logs a formatted warning to the console, then panics.
 func Panicf(format string, args ...interface{}) {
-	doLogf(fgRed, "CRIT", format, args...)
+	std.doLogf(LevelPanic, fgRed, "CRIT", format, args...)
 	panic(fmt.Sprintf(format, args...))
 }
 
 // Logs a warning to the console, then panics.
 func Panic(args ...interface{}) {
-	doLog(fgRed, "CRIT", args...)
+	std.doLog(LevelPanic, fgRed, "CRIT", args...)
 	panic(fmt.Sprint(args...))
 }
 
@@ -372,56 +394,14 @@ var exit = os.Exit
 
 // Logs a formatted warning to the console, then exits the process.
 func Fatalf(format string, args ...interface{}) {
-	doLogf(fgRed, "FATA", format, args...)
-	exit(1)
+	std.doLogf(LevelPanic, fgRed, "FATA", format, args...)
+	std.exit(GetExitCode())
 }
 
 // Logs a warning to the console, then exits the process.
 func Fatal(args ...interface{}) {
-	doLog(fgRed, "FATA", args...)
-	exit(1)
-}
-
-func doLog(color string, prefix string, args ...interface{}) {
-	message := fmt.Sprint(args...)
-	if logCallBack != nil {
-		str := logCallBack(prefix, "", args...)
-		if str != "" {
-			if IsIncludeCaller() {
-				logger.Print(str, " -- ", getCallersName(2))
-			} else {
-				logger.Print(str)
-			}
-		}
-	} else {
-		if IsIncludeCaller() {
-			logger.Print(color, prefix, ": ", message, reset,
-				dim, " -- ", getCallersName(2), reset)
-		} else {
-			logger.Print(color, prefix, ": ", message, reset, dim)
-		}
-	}
-}
-
-func doLogf(color string, prefix string, format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	if logCallBack != nil {
-		str := logCallBack(prefix, format, args...)
-		if str != "" {
-			if IsIncludeCaller() {
-				logger.Print(str, " -- ", getCallersName(2))
-			} else {
-				logger.Print(str)
-			}
-		}
-	} else {
-		if IsIncludeCaller() {
-			logger.Print(color, prefix, ": ", message, reset,
-				dim, " -- ", getCallersName(2), reset)
-		} else {
-			logger.Print(color, prefix, ": ", message, reset, dim)
-		}
-	}
+	std.doLog(LevelPanic, fgRed, "FATA", args...)
+	std.exit(GetExitCode())
 }
 
 func lastComponent(path string) string {
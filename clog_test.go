@@ -16,10 +16,26 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
 )
 
+// Skips a test which logs debug or trace messages, when they are compiled
+// out by the clog_nodebug build tag.
+func requireDebug(t testing.TB) {
+	if !DebugEnabled {
+		t.Skip("debug logging is compiled out")
+	}
+}
+
+// Skips a test which counts allocations, when the race detector is on.
+func requireNoRace(t testing.TB) {
+	if raceEnabled {
+		t.Skip("allocation counts are inflated by the race detector")
+	}
+}
+
 func TestLastComponent(t *testing.T) {
 	tests := map[string]string{
 		"plain":     "plain",
@@ -73,15 +89,15 @@ func TestParseLogFlagsEmpty(t *testing.T) {
 
 func TestGetCallersName(t *testing.T) {
 	cn := getCallersName(0)
-	if lastComponent(cn.filename) != "clog_test.go" {
+	if lastComponent(cn.File) != "clog_test.go" {
 		t.Errorf("Expected fn=clog_test.go, got %q",
-			lastComponent(cn.filename))
+			lastComponent(cn.File))
 	}
-	if lastComponent(cn.funcname) != "clog.TestGetCallersName" {
+	if lastComponent(cn.Function) != "clog.TestGetCallersName" {
 		t.Errorf("Expected func=clog.TestGetCallersName, got %q",
-			lastComponent(cn.funcname))
+			lastComponent(cn.Function))
 	}
-	cn.String() // for side effect
+	_ = cn.String() // for side effect
 	if cn = getCallersName(19); cn.String() != "???" {
 		t.Errorf("Expected unknown call, got %q", cn.String())
 	}
@@ -103,7 +119,32 @@ func TestKeyFlag(t *testing.T) {
 	}
 }
 
+func TestKeyHierarchy(t *testing.T) {
+	l := New(WithKeys("rest", "dcp.*", "views.index.build"))
+
+	tests := map[string]bool{
+		"rest":               true,
+		"rest.auth":          true,
+		"rest.auth.session":  true,
+		"restful":            false,
+		"dcp":                false,
+		"dcp.stream":         true,
+		"dcp.stream.open":    true,
+		"views":              false,
+		"views.index":        false,
+		"views.index.build":  true,
+		"views.index.builds": false,
+		".rest":              false,
+	}
+	for key, exp := range tests {
+		if l.KeyEnabled(key) != exp {
+			t.Errorf("Expected %q enabled=%v", key, exp)
+		}
+	}
+}
+
 func TestOutput(t *testing.T) {
+	requireDebug(t)
 	// reset the log when we're done
 	defer SetOutput(os.Stderr)
 
@@ -125,7 +166,7 @@ func TestOutput(t *testing.T) {
 				EnableKey("private")
 				To("private", "testing %s", "123")
 			},
-			fgYellow + "private: " + reset + "testing 123", -1, false,
+			"private: testing 123", -1, false,
 		},
 		{
 			func() {
@@ -151,85 +192,93 @@ func TestOutput(t *testing.T) {
 			func() {
 				Error(fmt.Errorf("test error"))
 			},
-			fgRed + "ERRO: " + "test error" + reset, -1, false,
+			"ERRO: test error", -1, false,
 		},
 		{
 			func() {
 				Errorf("TestOutput, err: %v", fmt.Errorf("test error"))
 			},
-			fgRed + "ERRO: " + "TestOutput, err: test error" + reset, -1, false,
+			"ERRO: TestOutput, err: test error", -1, false,
 		},
 		{
 			func() {
 				Warnf("testing %s", "123")
 			},
-			fgRed + "WARN: " + "testing 123" + reset, -1, false,
+			"WARN: testing 123", -1, false,
 		},
 		{
 			func() {
 				Warn("testing", "123")
 			},
-			fgRed + "WARN: " + "testing123" + reset, -1, false,
+			"WARN: testing123", -1, false,
 		},
 		{
 			func() {
 				TEMPf("testing %s", "123")
 			},
-			fgYellow + "TEMP: " + "testing 123" + reset, -1, false,
+			"TEMP: testing 123", -1, false,
 		},
 		{
 			func() {
 				TEMP("testing", "123")
 			},
-			fgYellow + "TEMP: " + "testing123" + reset, -1, false,
+			"TEMP: testing123", -1, false,
 		},
 		{
 			func() {
 				Fatal("testing", "123")
 			},
-			fgYellow + "FATA: " + "testing123" + reset, 1, false,
+			"FATA: testing123", 1, false,
 		},
 		{
 			func() {
 				Fatalf("testing12%d", 3)
 			},
-			fgYellow + "FATA: " + "testing123" + reset, 1, false,
+			"FATA: testing123", 1, false,
 		},
 		{
 			func() {
 				Panic("testing", "123")
 			},
-			fgYellow + "CRIT: " + "testing123" + reset, -1, true,
+			"CRIT: testing123", -1, true,
 		},
 		{
 			func() {
 				Panicf("testing12%d", 3)
 			},
-			fgYellow + "CRIT: " + "testing123" + reset, -1, true,
+			"CRIT: testing123", -1, true,
 		},
 		{
 			func() {
+				SetLevel(LevelTrace)
+				defer SetLevel(LevelNormal)
 				Debug("testing", "123")
 			},
-			fgYellow + "DEBU: " + "testing123" + reset, -1, true,
+			"DEBU: testing123", -1, false,
 		},
 		{
 			func() {
+				SetLevel(LevelTrace)
+				defer SetLevel(LevelNormal)
 				Debugf("testing12%d", 3)
 			},
-			fgYellow + "DEBU: " + "testing123" + reset, -1, true,
+			"DEBU: testing123", -1, false,
 		},
 		{
 			func() {
+				SetLevel(LevelTrace)
+				defer SetLevel(LevelNormal)
 				Trace("testing", "123")
 			},
-			fgYellow + "TRAC: " + "testing123" + reset, -1, true,
+			"TRAC: testing123", -1, false,
 		},
 		{
 			func() {
+				SetLevel(LevelTrace)
+				defer SetLevel(LevelNormal)
 				Tracef("testing12%d", 3)
 			},
-			fgYellow + "TRAC: " + "testing123" + reset, -1, true,
+			"TRAC: testing123", -1, false,
 		},
 	}
 
@@ -259,7 +308,7 @@ func TestOutput(t *testing.T) {
 			usedBytes := buffer.Bytes()[:buffer.Len()-1]
 			output := string(usedBytes)
 			// strip off the caller info as we can't easily compare that
-			callerLocation := strings.LastIndex(output, dim+" -- ")
+			callerLocation := strings.LastIndex(output, " -- ")
 			if callerLocation >= 0 {
 				output = output[:callerLocation]
 			}
@@ -301,6 +350,31 @@ func TestRedactions(t *testing.T) {
 	}
 }
 
+// The implementation of getCallersName before caching, for comparison.
+func uncachedCallersName(depth int) Caller {
+	pc, file, line, ok := runtime.Caller(depth + 1)
+	if !ok {
+		return Caller{}
+	}
+	fnname := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		fnname = fn.Name()
+	}
+	return Caller{fnname, file, line}
+}
+
+func BenchmarkGetCallersName(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		getCallersName(1)
+	}
+}
+
+func BenchmarkGetCallersNameUncached(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		uncachedCallersName(1)
+	}
+}
+
 func BenchmarkFlagLookupMiss(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		KeyEnabled("x")
@@ -315,6 +389,14 @@ func BenchmarkFlagLookupHit(b *testing.B) {
 	}
 }
 
+func BenchmarkFlagLookupHierarchyMiss(b *testing.B) {
+	EnableKey("x")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KeyEnabled("rest.auth.session")
+	}
+}
+
 func BenchmarkFlagSet(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		EnableKey("x")
@@ -353,3 +435,72 @@ func BenchmarkToWithFmt(b *testing.B) {
 		To("btoe", "%s", "a string")
 	}
 }
+
+// A filtered call is expected to take under 5ns and make no allocations.
+func BenchmarkDebugfFiltered(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debugf("filtered %s", "message")
+	}
+}
+
+func BenchmarkLogEmitted(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Log("emitted message")
+	}
+}
+
+func BenchmarkWarnfEmitted(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Warnf("emitted %s", "message")
+	}
+}
+
+func BenchmarkWarnfEmittedJSON(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard))
+	l.AddOutput(ioutil.Discard, SinkOptions{Format: FormatJSON})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Warnf("emitted %s", "message")
+	}
+}
+
+func BenchmarkWarnfFiltered(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard), WithLevel(LevelError))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Warnf("filtered %s", "message")
+	}
+}
+
+func BenchmarkDebugfFilteredNamed(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard)).Named("xdcr").Named("pipeline")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debugf("filtered %s", "message")
+	}
+}
+
+func BenchmarkDebugToFiltered(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.DebugTo("xdcr", "filtered %s", "message")
+	}
+}
+
+// Non-constant arguments are boxed before the call unless it is guarded.
+func BenchmarkDebugfFilteredGuarded(b *testing.B) {
+	l := New(WithOutput(ioutil.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if l.LevelEnabled(LevelDebug) {
+			l.Debugf("filtered %d", i)
+		}
+	}
+}
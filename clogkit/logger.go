@@ -0,0 +1,84 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package clogkit provides a github.com/go-kit/log Logger which writes
+// through clog, so that services built on go-kit middleware share clog's
+// levels, keys and outputs.
+package clogkit
+
+import (
+	"fmt"
+
+	"github.com/couchbase/clog"
+	"github.com/go-kit/log"
+)
+
+// Options configure a Logger.
+type Options struct {
+	// Key used for entries without a "component" keyval. If set, such
+	// entries are only logged while this clog key is enabled.
+	Key string
+}
+
+// Logger is a go-kit log.Logger writing to a clog.Logger. The "level" keyval,
+// as added by the go-kit level package, sets the entry's level, and the
+// "component" keyval its key; "msg" is the message. Other keyvals become
+// fields. Entries without a level are logged at LevelNormal.
+type Logger struct {
+	logger *clog.Logger
+	opts   Options
+}
+
+var _ log.Logger = (*Logger)(nil)
+
+// NewLogger creates a Logger writing to logger, or to the default clog
+// logger if logger is nil. opts may be nil.
+func NewLogger(logger *clog.Logger, opts *Options) *Logger {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	l := &Logger{logger: logger}
+	if opts != nil {
+		l.opts = *opts
+	}
+	return l
+}
+
+// Log logs keyvals. It always returns nil; entries which aren't enabled are
+// dropped.
+func (l *Logger) Log(keyvals ...interface{}) error {
+	level, key, msg := clog.LevelNormal, l.opts.Key, ""
+	fields := make([]interface{}, 0, len(keyvals)+1)
+	for i := 0; i < len(keyvals); i += 2 {
+		k := fmt.Sprint(keyvals[i])
+		var v interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		switch k {
+		case "level":
+			if lv, err := clog.ParseLevel(fmt.Sprint(v)); err == nil {
+				level = lv
+				continue
+			}
+		case "component":
+			key = fmt.Sprint(v)
+			continue
+		case "msg":
+			msg = fmt.Sprint(v)
+			continue
+		}
+		fields = append(fields, k, v)
+	}
+	logger := l.logger
+	if len(fields) > 0 {
+		logger = logger.With(fields...)
+	}
+	logger.Output(level, key, 0, msg)
+	return nil
+}
@@ -0,0 +1,58 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogkit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+func TestLogger(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0), clog.WithIncludeCaller(false))
+	clog.DisableColor()
+	logger := log.With(NewLogger(l, nil), "bucket", "default")
+
+	level.Debug(logger).Log("msg", "hidden")
+	level.Info(logger).Log("msg", "replicating", "vbs", 1024)
+	level.Warn(logger).Log("msg", "conflict", "err", errors.New("cas mismatch"))
+	logger.Log("msg", "plain", "odd")
+	log.With(logger, "component", "xdcr").Log("msg", "keyed")
+	l.EnableKey("xdcr")
+	log.With(logger, "component", "xdcr").Log("msg", "shown")
+
+	want := []string{
+		"replicating bucket=default vbs=1024",
+		`WARN: conflict bucket=default err="cas mismatch"`,
+		"plain bucket=default odd=(MISSING)",
+		"xdcr: shown bucket=default",
+	}
+	if got := strings.Split(strings.TrimSpace(b.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestLoggerKey(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0), clog.WithIncludeCaller(false))
+	logger := NewLogger(l, &Options{Key: "kit"})
+
+	logger.Log("msg", "hidden")
+	l.EnableKey("kit")
+	level.Error(logger).Log("msg", "shown")
+	if got := strings.TrimSpace(b.String()); got != "ERRO: kit: shown" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
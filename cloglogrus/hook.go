@@ -0,0 +1,105 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package cloglogrus provides a github.com/sirupsen/logrus Hook which
+// forwards entries to clog, so that code still using logrus shares clog's
+// levels, keys, output formats and rotation while it is converted.
+package cloglogrus
+
+import (
+	"io"
+	"sort"
+
+	"github.com/couchbase/clog"
+	"github.com/sirupsen/logrus"
+)
+
+// Options configure a Hook.
+type Options struct {
+	// If set, entries are only logged while this clog key is enabled.
+	Key string
+}
+
+// Hook is a logrus.Hook writing to a clog.Logger. Entry data become fields,
+// in key order. Panic and fatal entries are logged at LevelPanic, and the
+// logger is flushed before logrus panics or exits.
+type Hook struct {
+	logger *clog.Logger
+	opts   Options
+}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// NewHook creates a Hook writing to logger, or to the default clog logger if
+// logger is nil. opts may be nil.
+func NewHook(logger *clog.Logger, opts *Options) *Hook {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	h := &Hook{logger: logger}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Redirect adds a Hook to from, and discards its own output, so that all of
+// its entries are written by clog. from's level still applies, so it is
+// usually set to logrus.TraceLevel to leave filtering to clog.
+func Redirect(from *logrus.Logger, logger *clog.Logger, opts *Options) {
+	from.AddHook(NewHook(logger, opts))
+	from.SetOutput(io.Discard)
+}
+
+// Level converts a logrus level to the nearest clog level.
+func Level(level logrus.Level) clog.LogLevel {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return clog.LevelPanic
+	case logrus.ErrorLevel:
+		return clog.LevelError
+	case logrus.WarnLevel:
+		return clog.LevelWarning
+	case logrus.InfoLevel:
+		return clog.LevelNormal
+	case logrus.DebugLevel:
+		return clog.LevelDebug
+	}
+	return clog.LevelTrace
+}
+
+// Returns all levels; entries are filtered by the clog logger.
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	logger := h.logger
+	if len(entry.Data) > 0 {
+		keys := make([]string, 0, len(entry.Data))
+		for k := range entry.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		keyvals := make([]interface{}, 0, 2*len(keys))
+		for _, k := range keys {
+			keyvals = append(keyvals, k, entry.Data[k])
+		}
+		logger = logger.With(keyvals...)
+	}
+	var pc uintptr
+	if entry.Caller != nil {
+		pc = entry.Caller.PC + 1 // Frame.PC is the call, Output expects the return address
+	}
+	level := Level(entry.Level)
+	logger.Output(level, h.opts.Key, pc, entry.Message)
+	if level == clog.LevelPanic {
+		logger.Flush()
+	}
+	return nil
+}
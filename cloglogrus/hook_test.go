@@ -0,0 +1,61 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package cloglogrus
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHook(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	clog.DisableColor()
+	from := logrus.New()
+	from.SetLevel(logrus.TraceLevel)
+	from.SetReportCaller(true)
+	Redirect(from, l, nil)
+	entry := from.WithFields(logrus.Fields{"vbs": 1024, "bucket": "default"})
+
+	entry.Debug("hidden")
+	entry.Info("replicating")
+	entry.WithError(errors.New("cas mismatch")).Warn("conflict")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b.String())
+	}
+	if lines[0] != "replicating bucket=default vbs=1024" {
+		t.Errorf("Unexpected info line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `WARN: conflict bucket=default error="cas mismatch" vbs=1024 -- `) ||
+		!strings.HasSuffix(lines[1], "TestHook() at hook_test.go:33") {
+		t.Errorf("Unexpected warning line %q", lines[1])
+	}
+}
+
+func TestHookKey(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	from := logrus.New()
+	from.SetOutput(&bytes.Buffer{})
+	from.AddHook(NewHook(l, &Options{Key: "logrus"}))
+
+	from.Info("hidden")
+	l.EnableKey("logrus")
+	from.Info("shown")
+	if got := strings.TrimSpace(b.String()); got != "logrus: shown" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
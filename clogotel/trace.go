@@ -0,0 +1,47 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package clogotel joins clog's messages with OpenTelemetry traces, by adding
+// the W3C trace context of the span carried by a context to the messages
+// logged with it.
+package clogotel
+
+import (
+	"context"
+
+	"github.com/couchbase/clog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Makes logger, or the default clog logger if logger is nil, add the
+// "trace_id" and "span_id" fields to the messages logged with the Ctx
+// functions or WithContext, when the context carries a valid span.
+func Enable(logger *clog.Logger) {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	logger.AddContextFields(TraceFields)
+}
+
+// Returns the "trace_id" and "span_id" fields of the span carried by ctx, in
+// their W3C hexadecimal forms, or nil if there isn't a valid span. The
+// "trace_sampled" field is added if the span is sampled.
+func TraceFields(ctx context.Context) []clog.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	fields := []clog.Field{
+		{Key: "trace_id", Value: sc.TraceID().String()},
+		{Key: "span_id", Value: sc.SpanID().String()},
+	}
+	if sc.IsSampled() {
+		fields = append(fields, clog.Field{Key: "trace_sampled", Value: true})
+	}
+	return fields
+}
@@ -0,0 +1,40 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogotel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/couchbase/clog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestEnable(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	Enable(l)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	l.LogCtx(ctx, "traced")
+	l.LogCtx(context.Background(), "untraced")
+
+	expect := "traced trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_sampled=true\n" +
+		"untraced\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
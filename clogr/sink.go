@@ -0,0 +1,123 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package clogr provides a github.com/go-logr/logr LogSink which writes
+// through clog, so that logr-based dependencies such as controller-runtime
+// share clog's levels, keys and outputs.
+package clogr
+
+import (
+	"runtime"
+
+	"github.com/couchbase/clog"
+	"github.com/go-logr/logr"
+)
+
+// Options configure a LogSink.
+type Options struct {
+	// If set, messages are only logged while this clog key is enabled.
+	Key string
+}
+
+// LogSink is a logr.LogSink writing to a clog.Logger. Names added with
+// WithName become Named child loggers, so they are joined with dots.
+type LogSink struct {
+	logger *clog.Logger
+	opts   Options
+	depth  int // Frames between the caller and Info or Error
+}
+
+var _ logr.CallDepthLogSink = (*LogSink)(nil)
+
+// New returns a logr.Logger writing to logger, or to the default clog logger
+// if logger is nil. opts may be nil.
+func New(logger *clog.Logger, opts *Options) logr.Logger {
+	return logr.New(NewLogSink(logger, opts))
+}
+
+// NewLogSink creates a LogSink writing to logger, or to the default clog
+// logger if logger is nil. opts may be nil.
+func NewLogSink(logger *clog.Logger, opts *Options) *LogSink {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	s := &LogSink{logger: logger}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+// Level converts a logr verbosity to a clog level: V(0) is LevelNormal, V(1)
+// LevelDebug, and higher verbosities LevelTrace.
+func Level(v int) clog.LogLevel {
+	switch {
+	case v <= 0:
+		return clog.LevelNormal
+	case v == 1:
+		return clog.LevelDebug
+	}
+	return clog.LevelTrace
+}
+
+func (s *LogSink) Init(info logr.RuntimeInfo) {
+	s.depth = info.CallDepth
+}
+
+func (s *LogSink) Enabled(level int) bool {
+	return (s.opts.Key == "" || s.logger.KeyEnabled(s.opts.Key)) &&
+		s.logger.GetLevel() <= Level(level)
+}
+
+func (s *LogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.output(Level(level), s.callerPC(), msg, keysAndValues)
+}
+
+func (s *LogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if err != nil {
+		keysAndValues = append([]interface{}{"error", err}, keysAndValues...)
+	}
+	s.output(clog.LevelError, s.callerPC(), msg, keysAndValues)
+}
+
+func (s *LogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	s2 := *s
+	s2.logger = s.logger.With(keysAndValues...)
+	return &s2
+}
+
+func (s *LogSink) WithName(name string) logr.LogSink {
+	s2 := *s
+	s2.logger = s.logger.Named(name)
+	return &s2
+}
+
+func (s *LogSink) WithCallDepth(depth int) logr.LogSink {
+	s2 := *s
+	s2.depth += depth
+	return &s2
+}
+
+func (s *LogSink) output(level clog.LogLevel, pc uintptr, msg string, keysAndValues []interface{}) {
+	logger := s.logger
+	if len(keysAndValues) > 0 {
+		logger = logger.With(keysAndValues...)
+	}
+	logger.Output(level, s.opts.Key, pc, msg)
+}
+
+// Returns the program counter of the code calling logr. It must be called
+// directly from Info or Error.
+func (s *LogSink) callerPC() uintptr {
+	var pcs [1]uintptr
+	// Skip runtime.Callers, callerPC, Info or Error, and the logr frames.
+	if runtime.Callers(3+s.depth, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
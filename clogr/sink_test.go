@@ -0,0 +1,66 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+)
+
+func TestLogSink(t *testing.T) {
+	if !clog.DebugEnabled {
+		t.Skip("debug logging is compiled out")
+	}
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	clog.DisableColor()
+	logger := New(l, nil).WithName("controller").WithValues("bucket", "default")
+
+	logger.V(1).Info("hidden")
+	logger.Info("reconciling", "attempt", 2)
+	l.SetLevel(clog.LevelDebug)
+	logger.WithName("pool").V(1).Info("resized")
+	logger.Error(errors.New("timeout"), "failed")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", b.String())
+	}
+	if lines[0] != "controller: reconciling bucket=default attempt=2" {
+		t.Errorf("Unexpected info line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "DEBU: controller.pool: resized bucket=default -- ") {
+		t.Errorf("Unexpected debug line %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "ERRO: controller: failed bucket=default error=timeout -- ") ||
+		!strings.Contains(lines[2], "TestLogSink() at sink_test.go") {
+		t.Errorf("Unexpected error line %q", lines[2])
+	}
+}
+
+func TestLogSinkKey(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	clog.DisableColor()
+	logger := New(l, &Options{Key: "k8s"})
+
+	if logger.Enabled() {
+		t.Errorf("Expected logger to be disabled until its key is enabled")
+	}
+	logger.Info("hidden")
+	l.EnableKey("k8s")
+	logger.Info("shown")
+	if got := strings.TrimSpace(b.String()); got != "k8s: shown" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
@@ -0,0 +1,76 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/couchbase/clog"
+)
+
+// Forward replaces the outputs of logger, or of the default clog logger if
+// logger is nil, with one writing every entry to to, so that services which
+// have standardized on slog handlers keep their clog call sites working
+// unchanged. The entries' keys, fields, callers and stacks become
+// attributes. to must not write back to logger, as through a Handler.
+func Forward(logger *clog.Logger, to *slog.Logger) {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	logger.SetEntryOutput(func(e clog.Entry) { forward(to.Handler(), e) }, clog.LevelTrace)
+}
+
+// SlogLevel converts a clog level to the corresponding slog level. Levels
+// between slog's are offset from them, as LevelNotice is slog.LevelInfo+2.
+func SlogLevel(level clog.LogLevel) slog.Level {
+	switch level {
+	case clog.LevelTrace:
+		return slog.LevelDebug - 4
+	case clog.LevelDebug:
+		return slog.LevelDebug
+	case clog.LevelNormal:
+		return slog.LevelInfo
+	case clog.LevelNotice:
+		return slog.LevelInfo + 2
+	case clog.LevelWarning:
+		return slog.LevelWarn
+	case clog.LevelError:
+		return slog.LevelError
+	case clog.LevelCritical:
+		return slog.LevelError + 2
+	}
+	return slog.LevelError + 4
+}
+
+func forward(h slog.Handler, e clog.Entry) {
+	ctx := context.Background()
+	level := SlogLevel(e.Level)
+	if !h.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(e.Time, level, e.Message, 0)
+	if e.Key != "" {
+		r.AddAttrs(slog.String("key", e.Key))
+	}
+	for _, f := range e.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if e.Caller != nil {
+		r.AddAttrs(slog.Any(slog.SourceKey, &slog.Source{
+			Function: e.Caller.Function,
+			File:     e.Caller.File,
+			Line:     e.Caller.Line,
+		}))
+	}
+	if e.Stack != "" {
+		r.AddAttrs(slog.String("stack", e.Stack))
+	}
+	h.Handle(ctx, r)
+}
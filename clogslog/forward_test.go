@@ -0,0 +1,58 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogslog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+)
+
+func TestForward(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithKeys("DCP"))
+	to := slog.New(slog.NewTextHandler(b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.SourceKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	Forward(l, to)
+
+	l.Log("plain")
+	l.With("vb", 7).WarnTo("DCP", "stream ended")
+	l.Noticef("rebalance started")
+	l.Tracef("filtered by clog")
+	l.SetLevel(clog.LevelTrace)
+	l.Tracef("filtered by slog")
+
+	exp := "level=INFO msg=plain\n" +
+		"level=WARN msg=\"stream ended\" key=DCP vb=7\n" +
+		"level=INFO+2 msg=\"rebalance started\"\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for level := clog.LevelTrace; level <= clog.LevelPanic; level++ {
+		if got := Level(SlogLevel(level)); got != level && level != clog.LevelCritical && level != clog.LevelPanic {
+			t.Errorf("Level %v doesn't round-trip, got %v", level, got)
+		}
+	}
+	if s := SlogLevel(clog.LevelCritical).String(); !strings.HasPrefix(s, "ERROR+") {
+		t.Errorf("Unexpected critical level %s", s)
+	}
+}
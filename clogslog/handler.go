@@ -0,0 +1,120 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package clogslog provides a log/slog Handler which writes through clog, so
+// that code written against slog shares clog's levels, keys, redaction tags
+// and outputs.
+package clogslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/couchbase/clog"
+)
+
+// HandlerOptions configure a Handler.
+type HandlerOptions struct {
+	// If set, records are only logged while this clog key is enabled.
+	Key string
+}
+
+// Handler is a slog.Handler writing to a clog.Logger.
+type Handler struct {
+	logger *clog.Logger
+	opts   HandlerOptions
+	attrs  []interface{} // Alternating keys and values, from WithAttrs
+	group  string        // Prefix for attribute keys, from WithGroup
+}
+
+// NewHandler creates a Handler writing to logger, or to the default clog
+// logger if logger is nil. opts may be nil.
+func NewHandler(logger *clog.Logger, opts *HandlerOptions) *Handler {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	h := &Handler{logger: logger}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Level converts a slog level to the nearest clog level.
+func Level(level slog.Level) clog.LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return clog.LevelTrace
+	case level < slog.LevelInfo:
+		return clog.LevelDebug
+	case level == slog.LevelInfo:
+		return clog.LevelNormal
+	case level < slog.LevelWarn:
+		return clog.LevelNotice
+	case level < slog.LevelError:
+		return clog.LevelWarning
+	}
+	return clog.LevelError
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return (h.opts.Key == "" || h.logger.KeyEnabled(h.opts.Key)) &&
+		h.logger.GetLevel() <= Level(level)
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	keyvals := make([]interface{}, len(h.attrs), len(h.attrs)+2*r.NumAttrs())
+	copy(keyvals, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		keyvals = appendAttr(keyvals, h.group, a)
+		return true
+	})
+	logger := h.logger
+	if len(keyvals) > 0 {
+		logger = logger.With(keyvals...)
+	}
+	logger.Output(Level(r.Level), h.opts.Key, r.PC, r.Message)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = make([]interface{}, len(h.attrs), len(h.attrs)+2*len(attrs))
+	copy(h2.attrs, h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, h.group, a)
+	}
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+// Appends an attribute to keyvals, flattening groups into dotted keys.
+func appendAttr(keyvals []interface{}, prefix string, a slog.Attr) []interface{} {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			keyvals = appendAttr(keyvals, prefix, ga)
+		}
+		return keyvals
+	}
+	if a.Key == "" {
+		return keyvals
+	}
+	return append(keyvals, prefix+a.Key, v.Any())
+}
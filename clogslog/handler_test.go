@@ -0,0 +1,56 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogslog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+)
+
+func TestHandler(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	clog.DisableColor()
+	logger := slog.New(NewHandler(l, nil))
+
+	logger.Debug("hidden")
+	logger.Info("hello", "bucket", "default")
+	logger.WithGroup("req").With("id", 7).Warn("slow",
+		slog.Group("doc", "key", clog.Tag(clog.UserData, "k1")))
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b.String())
+	}
+	if lines[0] != "hello bucket=default" {
+		t.Errorf("Unexpected info line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "WARN: slow req.id=7 req.doc.key=<ud>k1</ud> -- ") ||
+		!strings.Contains(lines[1], "TestHandler() at handler_test.go") {
+		t.Errorf("Unexpected warning line %q", lines[1])
+	}
+}
+
+func TestHandlerKey(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	logger := slog.New(NewHandler(l, &HandlerOptions{Key: "dcp"}))
+
+	logger.Info("disabled")
+	l.EnableKey("dcp")
+	logger.Info("enabled")
+	if out := b.String(); strings.Contains(out, "disabled") ||
+		!strings.Contains(out, "enabled") {
+		t.Errorf("Expected output only while the key is enabled, got %q", out)
+	}
+}
@@ -0,0 +1,123 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package clogtest captures clog entries in unit tests, so that tests can
+// check what was logged without parsing output text:
+//
+//	capture := clogtest.Capture(t)
+//	doSomething()
+//	capture.AssertContains(clog.LevelError, "timeout")
+package clogtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/couchbase/clog"
+)
+
+// A Recorder holds the entries logged while it is capturing. It is safe for
+// concurrent use.
+type Recorder struct {
+	t       testing.TB
+	mu      sync.Mutex
+	entries []clog.Entry
+}
+
+// Captures the entries written by the default logger until the test
+// completes; see CaptureLogger.
+func Capture(t testing.TB) *Recorder {
+	return CaptureLogger(t, clog.Default())
+}
+
+// Captures the entries written by logger, and the Loggers derived from it,
+// until the test and its subtests complete. Only entries which pass the
+// logger's level and key filters are captured; the logger's other outputs
+// are unaffected.
+func CaptureLogger(t testing.TB, logger *clog.Logger) *Recorder {
+	r := &Recorder{t: t}
+	logger.AddOutput(r, clog.SinkOptions{
+		Formatter: clog.FormatterFunc(r.record),
+	})
+	t.Cleanup(func() { logger.RemoveOutput(r) })
+	return r
+}
+
+func (r *Recorder) record(e clog.Entry) []byte {
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+	return nil
+}
+
+// Discards the rendered output; entries are recorded by the formatter.
+func (r *Recorder) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Returns the captured entries, oldest first.
+func (r *Recorder) Entries() []clog.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]clog.Entry(nil), r.entries...)
+}
+
+// Discards the captured entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// Reports whether an entry at the given level has a message containing
+// substr.
+func (r *Recorder) Contains(level clog.LogLevel, substr string) bool {
+	for _, e := range r.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fails the test unless an entry at the given level has a message
+// containing substr.
+func (r *Recorder) AssertContains(level clog.LogLevel, substr string) {
+	r.t.Helper()
+	if !r.Contains(level, substr) {
+		r.t.Errorf("clogtest: no %v message containing %q; captured:\n%s", level, substr, r)
+	}
+}
+
+// Fails the test if an entry at the given level has a message containing
+// substr.
+func (r *Recorder) AssertNotContains(level clog.LogLevel, substr string) {
+	r.t.Helper()
+	if r.Contains(level, substr) {
+		r.t.Errorf("clogtest: unexpected %v message containing %q; captured:\n%s", level, substr, r)
+	}
+}
+
+// Returns the captured entries, one per line, for failure messages.
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		fmt.Fprintf(&b, "\t%v: ", e.Level)
+		if e.Key != "" {
+			b.WriteString(e.Key + ": ")
+		}
+		b.WriteString(e.Message)
+		for _, f := range e.Fields {
+			fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+)
+
+// A testing.TB which records failures rather than failing.
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func TestCapture(t *testing.T) {
+	l := clog.New(clog.WithOutput(ioutil.Discard))
+	tb := &fakeTB{TB: t}
+	capture := CaptureLogger(tb, l)
+
+	l.Named("dcp").With("vb", 12).Errorf("stream timeout after %ds", 5)
+	l.Log("opened")
+
+	entries := capture.Entries()
+	if len(entries) != 2 || entries[0].Key != "dcp" || entries[0].Fields[0].Value != 12 {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+	capture.AssertContains(clog.LevelError, "timeout")
+	capture.AssertNotContains(clog.LevelError, "opened")
+	if len(tb.errors) != 0 {
+		t.Errorf("Unexpected failures %q", tb.errors)
+	}
+
+	capture.AssertContains(clog.LevelWarning, "timeout")
+	capture.AssertNotContains(clog.LevelNormal, "open")
+	if len(tb.errors) != 2 || !strings.Contains(tb.errors[0], "\terror: dcp: stream timeout after 5s vb=12\n") {
+		t.Errorf("Unexpected failures %q", tb.errors)
+	}
+
+	capture.Reset()
+	for _, f := range tb.cleanups {
+		f()
+	}
+	l.Log("after")
+	if len(capture.Entries()) != 0 {
+		t.Errorf("Expected capturing to stop at cleanup")
+	}
+}
+
+func TestCaptureDefault(t *testing.T) {
+	capture := Capture(t)
+	clog.Warnf("from %s", "default")
+	capture.AssertContains(clog.LevelWarning, "from default")
+}
@@ -0,0 +1,128 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+// Package clogzap provides a go.uber.org/zap Core which writes through clog,
+// so that code using zap's logging and field APIs shares clog's levels, keys,
+// redaction tags and outputs.
+package clogzap
+
+import (
+	"github.com/couchbase/clog"
+	"go.uber.org/zap/zapcore"
+)
+
+// CoreOptions configure a Core.
+type CoreOptions struct {
+	// If set, entries are only logged while this clog key is enabled.
+	Key string
+}
+
+// Core is a zapcore.Core writing to a clog.Logger. Zap logger names become
+// Named child loggers. Panic and fatal entries are logged at LevelPanic;
+// zap itself then panics or exits.
+type Core struct {
+	logger *clog.Logger
+	opts   CoreOptions
+	fields []interface{} // Alternating keys and values, from With
+	ns     string        // Prefix for field keys, from zap.Namespace
+}
+
+var _ zapcore.Core = (*Core)(nil)
+
+// NewCore creates a Core writing to logger, or to the default clog logger
+// if logger is nil. opts may be nil.
+func NewCore(logger *clog.Logger, opts *CoreOptions) *Core {
+	if logger == nil {
+		logger = clog.Default()
+	}
+	c := &Core{logger: logger}
+	if opts != nil {
+		c.opts = *opts
+	}
+	return c
+}
+
+// Level converts a zap level to the nearest clog level.
+func Level(level zapcore.Level) clog.LogLevel {
+	switch {
+	case level < zapcore.DebugLevel:
+		return clog.LevelTrace
+	case level < zapcore.InfoLevel:
+		return clog.LevelDebug
+	case level < zapcore.WarnLevel:
+		return clog.LevelNormal
+	case level < zapcore.ErrorLevel:
+		return clog.LevelWarning
+	case level < zapcore.DPanicLevel:
+		return clog.LevelError
+	case level < zapcore.PanicLevel:
+		return clog.LevelCritical
+	}
+	return clog.LevelPanic
+}
+
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return (c.opts.Key == "" || c.logger.KeyEnabled(c.opts.Key)) &&
+		c.logger.GetLevel() <= Level(level)
+}
+
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	c2 := *c
+	c2.fields, c2.ns = appendFields(c.fields[:len(c.fields):len(c.fields)], c.ns, fields)
+	return &c2
+}
+
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	keyvals, _ := appendFields(c.fields[:len(c.fields):len(c.fields)], c.ns, fields)
+	if ent.Stack != "" {
+		keyvals = append(keyvals, "stacktrace", ent.Stack)
+	}
+	logger := c.logger
+	if ent.LoggerName != "" {
+		logger = logger.Named(ent.LoggerName)
+	}
+	if len(keyvals) > 0 {
+		logger = logger.With(keyvals...)
+	}
+	var pc uintptr
+	if ent.Caller.Defined {
+		pc = ent.Caller.PC
+	}
+	logger.Output(Level(ent.Level), c.opts.Key, pc, ent.Message)
+	return nil
+}
+
+// Flushes the logger's outputs.
+func (c *Core) Sync() error {
+	c.logger.Flush()
+	return nil
+}
+
+// Appends fields to keyvals, in order. Fields following a namespace field
+// have its name, and a dot, prefixed to their keys.
+func appendFields(keyvals []interface{}, ns string, fields []zapcore.Field) ([]interface{}, string) {
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			ns += f.Key + "."
+			continue
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for k, v := range enc.Fields { // Usually a single field
+			keyvals = append(keyvals, ns+k, v)
+		}
+	}
+	return keyvals, ns
+}
@@ -0,0 +1,57 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clogzap
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/clog"
+	"go.uber.org/zap"
+)
+
+func TestCore(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	clog.DisableColor()
+	logger := zap.New(NewCore(l, nil), zap.AddCaller()).Named("xdcr").With(zap.String("bucket", "default"))
+
+	logger.Debug("hidden")
+	logger.Info("replicating", zap.Int("vbs", 1024))
+	logger.With(zap.Namespace("doc")).Warn("conflict",
+		zap.String("key", clog.Tag(clog.UserData, "k1").(string)), zap.Error(errors.New("cas mismatch")))
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b.String())
+	}
+	if lines[0] != "xdcr: replicating bucket=default vbs=1024" {
+		t.Errorf("Unexpected info line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `WARN: xdcr: conflict bucket=default doc.key=<ud>k1</ud> doc.error="cas mismatch" -- `) ||
+		!strings.Contains(lines[1], "TestCore() at core_test.go") {
+		t.Errorf("Unexpected warning line %q", lines[1])
+	}
+}
+
+func TestCoreKey(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clog.New(clog.WithOutput(b), clog.WithFlags(0))
+	core := NewCore(l, &CoreOptions{Key: "zap"})
+	logger := zap.New(core)
+
+	logger.Info("hidden")
+	l.EnableKey("zap")
+	logger.Info("shown")
+	if got := strings.TrimSpace(b.String()); got != "zap: shown" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
@@ -0,0 +1,196 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ColorDepth is the range of colors a terminal can show.
+type ColorDepth int32
+
+const (
+	ColorDepthNone      = ColorDepth(iota) // Monochrome
+	ColorDepth16                           // The basic and bright ANSI colors
+	ColorDepth256                          // The xterm 256 color palette
+	ColorDepthTrueColor                    // 24-bit RGB
+)
+
+// The depth to which colors are reduced, accessed atomically.
+var colorDepth = int32(DetectColorDepth())
+
+// The color of keys in text output, a *Color, or nil for yellow.
+var keyColor unsafe.Pointer
+
+// Returns a 24-bit color, which is reduced to the nearest color the terminal
+// can show.
+func RGB(r, g, b uint8) Color {
+	return Color(fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b))
+}
+
+// Returns a color from the xterm 256 color palette, which is reduced to the
+// nearest basic color if the terminal can't show it.
+func Color256(n uint8) Color {
+	return Color(fmt.Sprintf("\x1b[38;5;%dm", n))
+}
+
+// Guesses the terminal's color depth from the environment: NO_COLOR or
+// TERM=dumb give ColorDepthNone, COLORTERM=truecolor or 24bit gives
+// ColorDepthTrueColor, and a TERM containing "256color" gives
+// ColorDepth256. Otherwise, the basic colors are assumed.
+func DetectColorDepth() ColorDepth {
+	term := os.Getenv("TERM")
+	switch colorterm := os.Getenv("COLORTERM"); {
+	case os.Getenv("NO_COLOR") != "" || term == "dumb":
+		return ColorDepthNone
+	case colorterm == "truecolor" || colorterm == "24bit":
+		return ColorDepthTrueColor
+	case strings.Contains(term, "256color"):
+		return ColorDepth256
+	}
+	return ColorDepth16
+}
+
+// Sets the depth to which the colors of text output are reduced, for all
+// loggers; ColorDepthNone disables color. (default DetectColorDepth)
+func SetColorDepth(depth ColorDepth) {
+	atomic.StoreInt32(&colorDepth, int32(depth))
+}
+
+// Sets the color of keys in text output, for all loggers. (default
+// ColorYellow)
+func SetKeyColor(color Color) {
+	atomic.StorePointer(&keyColor, unsafe.Pointer(&color))
+}
+
+// Returns the color of keys in text output.
+func currentKeyColor() string {
+	if !colorEnabled() {
+		return "" // Color is disabled
+	}
+	if kp := atomic.LoadPointer(&keyColor); kp != nil {
+		return reduceColor(*(*Color)(kp))
+	}
+	return fgYellow
+}
+
+// The xterm RGB values of the 16 basic colors.
+var basicColors = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Returns the escape sequence for color, reduced to the color depth.
+func reduceColor(color Color) string {
+	depth := ColorDepth(atomic.LoadInt32(&colorDepth))
+	if depth == ColorDepthNone {
+		return ""
+	}
+	var rgb [3]int
+	s := string(color)
+	switch {
+	case strings.HasPrefix(s, "\x1b[38;2;"):
+		if depth == ColorDepthTrueColor || !parseColorParams(s[len("\x1b[38;2;"):], rgb[:]) {
+			return s
+		}
+		if depth == ColorDepth256 {
+			return string(Color256(rgbTo256(rgb)))
+		}
+	case strings.HasPrefix(s, "\x1b[38;5;"):
+		var n [1]int
+		if depth >= ColorDepth256 || !parseColorParams(s[len("\x1b[38;5;"):], n[:]) {
+			return s
+		}
+		rgb = xterm256RGB(n[0])
+	default:
+		return s
+	}
+	return basicColorEscape(nearestBasicColor(rgb))
+}
+
+// Parses the semicolon separated numbers ending an SGR sequence, such as
+// "12;34;56m".
+func parseColorParams(s string, params []int) bool {
+	parts := strings.Split(strings.TrimSuffix(s, "m"), ";")
+	if len(parts) != len(params) {
+		return false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 255 {
+			return false
+		}
+		params[i] = n
+	}
+	return true
+}
+
+// Returns the nearest color of the xterm 6x6x6 color cube.
+func rgbTo256(rgb [3]int) uint8 {
+	var c [3]int
+	for i, v := range rgb {
+		if v < 48 {
+			c[i] = 0
+		} else if v < 115 {
+			c[i] = 1
+		} else {
+			c[i] = (v - 35) / 40
+		}
+	}
+	return uint8(16 + 36*c[0] + 6*c[1] + c[2])
+}
+
+// Returns the RGB value of a color of the xterm 256 color palette.
+func xterm256RGB(n int) [3]int {
+	switch {
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		n -= 16
+		level := func(i int) int {
+			if i == 0 {
+				return 0
+			}
+			return 55 + 40*i
+		}
+		return [3]int{level(n / 36), level(n / 6 % 6), level(n % 6)}
+	default:
+		gray := 8 + 10*(n-232)
+		return [3]int{gray, gray, gray}
+	}
+}
+
+func nearestBasicColor(rgb [3]int) int {
+	best, bestDist := 0, -1
+	for i, c := range basicColors {
+		dist := 0
+		for j := range c {
+			d := c[j] - rgb[j]
+			dist += d * d
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+func basicColorEscape(n int) string {
+	if n < 8 {
+		return fmt.Sprintf("\x1b[%dm", 30+n)
+	}
+	return fmt.Sprintf("\x1b[%dm", 90+n-8)
+}
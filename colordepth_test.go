@@ -0,0 +1,75 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectColorDepth(t *testing.T) {
+	for _, test := range []struct {
+		term, colorterm, noColor string
+		expect                   ColorDepth
+	}{
+		{"xterm", "", "", ColorDepth16},
+		{"xterm-256color", "", "", ColorDepth256},
+		{"xterm-256color", "truecolor", "", ColorDepthTrueColor},
+		{"dumb", "", "", ColorDepthNone},
+		{"xterm-256color", "", "1", ColorDepthNone},
+	} {
+		t.Setenv("TERM", test.term)
+		t.Setenv("COLORTERM", test.colorterm)
+		t.Setenv("NO_COLOR", test.noColor)
+		if depth := DetectColorDepth(); depth != test.expect {
+			t.Errorf("%+v: got %d", test, depth)
+		}
+	}
+}
+
+func TestReduceColor(t *testing.T) {
+	defer SetColorDepth(ColorDepth(colorDepth))
+	orange := RGB(255, 135, 0)
+	for _, test := range []struct {
+		depth  ColorDepth
+		color  Color
+		expect string
+	}{
+		{ColorDepthTrueColor, orange, "\x1b[38;2;255;135;0m"},
+		{ColorDepth256, orange, "\x1b[38;5;208m"},
+		{ColorDepth16, orange, "\x1b[33m"},
+		{ColorDepth16, Color256(21), "\x1b[34m"},
+		{ColorDepth256, Color256(21), "\x1b[38;5;21m"},
+		{ColorDepth16, ColorCyan, "\x1b[36m"},
+		{ColorDepthNone, ColorCyan, ""},
+	} {
+		SetColorDepth(test.depth)
+		if got := reduceColor(test.color); got != test.expect {
+			t.Errorf("%q at depth %d: got %q, expected %q", test.color, test.depth, got, test.expect)
+		}
+	}
+}
+
+func TestColorDepthNone(t *testing.T) {
+	defer SetColorDepth(ColorDepth(colorDepth))
+	if !colorEnabled() {
+		defer DisableColor()
+	} else {
+		defer EnableColor()
+	}
+	EnableColor()
+	SetColorDepth(ColorDepthNone)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false), WithKeys("DCP"))
+	l.Warnf("w")
+	l.To("DCP", "k")
+	if b.String() != "WARN: w\nDCP: k\n" {
+		t.Errorf("Expected monochrome output, got %q", b.String())
+	}
+}
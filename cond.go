@@ -0,0 +1,63 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import "fmt"
+
+// If err isn't nil, logs it as an error after the formatted context, as in
+// "opening bucket default: connection refused". Returns err, so that
+//
+//	return clog.ErrorIf(err, "opening bucket %s", name)
+//
+// replaces the usual if err != nil block.
+func ErrorIf(err error, format string, args ...interface{}) error {
+	if err != nil && std.wants(LevelError) {
+		std.doLogf(LevelError, fgRed, "ERRO", "%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return err
+}
+
+// Logs a warning after the formatted context if err isn't nil. Returns err.
+func WarnIf(err error, format string, args ...interface{}) error {
+	if err != nil && std.wants(LevelWarning) {
+		std.doLogf(LevelWarning, fgRed, "WARN", "%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return err
+}
+
+// Logs a message if cond is true.
+func LogIf(cond bool, format string, args ...interface{}) {
+	if cond && std.wants(LevelNormal) {
+		std.doPrintf(format, args...)
+	}
+}
+
+// Logs err as an error after the formatted context, if it isn't nil; see
+// the package-level ErrorIf. Returns err.
+func (l *Logger) ErrorIf(err error, format string, args ...interface{}) error {
+	if err != nil && l.wants(LevelError) {
+		l.doLogf(LevelError, fgRed, "ERRO", "%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return err
+}
+
+// Logs a warning after the formatted context if err isn't nil. Returns err.
+func (l *Logger) WarnIf(err error, format string, args ...interface{}) error {
+	if err != nil && l.wants(LevelWarning) {
+		l.doLogf(LevelWarning, fgRed, "WARN", "%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return err
+}
+
+// Logs a message if cond is true.
+func (l *Logger) LogIf(cond bool, format string, args ...interface{}) {
+	if cond && l.wants(LevelNormal) {
+		l.doPrintf(format, args...)
+	}
+}
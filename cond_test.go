@@ -0,0 +1,41 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestErrorIf(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	DisableColor()
+
+	if err := l.ErrorIf(nil, "opening %s", "default"); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	refused := errors.New("connection refused")
+	if err := l.ErrorIf(refused, "opening %s", "default"); err != refused {
+		t.Errorf("Expected the error to be returned, got %v", err)
+	}
+	l.WarnIf(refused, "retrying")
+	l.LogIf(false, "hidden")
+	l.LogIf(true, "shown")
+
+	re := regexp.MustCompile(`^ERRO: opening default: connection refused -- clog.TestErrorIf\(\) at cond_test.go:\d+
+WARN: retrying: connection refused -- clog.TestErrorIf\(\) at cond_test.go:\d+
+shown
+$`)
+	if !re.MatchString(b.String()) {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
@@ -0,0 +1,197 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Config is a serializable description of a logger's settings, for wiring
+// clog from a service's configuration file. Nil and empty members leave the
+// corresponding setting unchanged.
+type Config struct {
+	Level         *LogLevel       `json:"level,omitempty" yaml:"level,omitempty"`
+	Keys          []string        `json:"keys,omitempty" yaml:"keys,omitempty"` // Replaces the enabled keys
+	Color         *bool           `json:"color,omitempty" yaml:"color,omitempty"`
+	IncludeCaller *bool           `json:"includeCaller,omitempty" yaml:"includeCaller,omitempty"`
+	Outputs       []OutputConfig  `json:"outputs,omitempty" yaml:"outputs,omitempty"` // Replaces the outputs
+	Redaction     *RedactionLevel `json:"redaction,omitempty" yaml:"redaction,omitempty"`
+}
+
+// OutputConfig describes one output in a Config.
+type OutputConfig struct {
+	Path     string           `json:"path" yaml:"path"` // "stderr", "stdout" or a file path
+	Level    LogLevel         `json:"level,omitempty" yaml:"level,omitempty"`
+	Below    LogLevel         `json:"below,omitempty" yaml:"below,omitempty"` // If nonzero, only levels below this are written
+	Color    bool             `json:"color,omitempty" yaml:"color,omitempty"`
+	Format   Format           `json:"format,omitempty" yaml:"format,omitempty"`
+	Template string           `json:"template,omitempty" yaml:"template,omitempty"` // If set, used in place of Format; see NewTemplateFormatter
+	Rotation *RotationOptions `json:"rotation,omitempty" yaml:"rotation,omitempty"` // Files only
+	Buffer   *BufferOptions   `json:"buffer,omitempty" yaml:"buffer,omitempty"`     // Files only; batches writes
+}
+
+// Applies cfg to the default logger; see Logger.Configure.
+func Configure(cfg Config) error {
+	return std.Configure(cfg)
+}
+
+// Applies cfg to the logger. Color and Redaction are global settings,
+// affecting all loggers. Files opened by a previous Configure are closed when
+// the outputs are replaced. If an output can't be opened, an error is
+// returned and the outputs are left unchanged.
+func (l *Logger) Configure(cfg Config) error {
+	if len(cfg.Outputs) > 0 {
+		var sinks []*sink
+		var files []io.Closer
+		for _, o := range cfg.Outputs {
+			var formatter Formatter
+			var err error
+			if o.Template != "" {
+				formatter, err = NewTemplateFormatter(o.Template)
+			}
+			var w io.Writer
+			if err == nil {
+				w, err = openOutput(o)
+			}
+			if err != nil {
+				for _, f := range files {
+					f.Close()
+				}
+				return err
+			}
+			if c, ok := w.(io.Closer); ok && w != os.Stderr && w != os.Stdout {
+				files = append(files, c)
+			}
+			sinks = append(sinks, l.newSink(w, SinkOptions{
+				Level:     o.Level,
+				Below:     o.Below,
+				Color:     o.Color,
+				Format:    o.Format,
+				Formatter: formatter,
+			}))
+		}
+		atomic.StorePointer(&l.sinks, unsafe.Pointer(&sinks))
+		for _, f := range l.configured {
+			f.Close()
+		}
+		l.configured = files
+	}
+	if cfg.Level != nil {
+		l.setLevel(*cfg.Level)
+	}
+	if cfg.Keys != nil {
+		l.keys.replace(cfg.Keys)
+	}
+	if cfg.Color != nil {
+		if *cfg.Color {
+			EnableColor()
+		} else {
+			DisableColor()
+		}
+	}
+	if cfg.IncludeCaller != nil {
+		l.setIncludeCaller(*cfg.IncludeCaller)
+	}
+	if cfg.Redaction != nil {
+		SetRedactionLevel(*cfg.Redaction)
+	}
+	l.configChanged()
+	return nil
+}
+
+func openOutput(o OutputConfig) (io.Writer, error) {
+	switch o.Path {
+	case "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "":
+		return nil, fmt.Errorf("clog: output has no path")
+	}
+	var rotation RotationOptions
+	if o.Rotation != nil {
+		rotation = *o.Rotation
+	}
+	f, err := OpenRotatingFile(o.Path, rotation) // Even without rotation, for Reopen
+	if err != nil {
+		return nil, err
+	}
+	if o.Buffer != nil {
+		return NewBufferedWriter(f, *o.Buffer), nil
+	}
+	return f, nil
+}
+
+var formatNames = []string{
+	FormatText:   "text",
+	FormatJSON:   "json",
+	FormatLogfmt: "logfmt",
+	FormatPretty: "pretty",
+}
+
+// Returns the format's name, such as "json".
+func (f Format) String() string {
+	if f >= 0 && int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Implements encoding.TextMarshaler.
+func (f Format) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// Implements encoding.TextUnmarshaler.
+func (f *Format) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	for i, n := range formatNames {
+		if n == name {
+			*f = Format(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("clog: unknown format %q", text)
+}
+
+var redactionLevelNames = []string{
+	RedactNone:    "none",
+	RedactPartial: "partial",
+	RedactFull:    "full",
+}
+
+// Returns the redaction level's name, such as "partial".
+func (r RedactionLevel) String() string {
+	if r >= 0 && int(r) < len(redactionLevelNames) {
+		return redactionLevelNames[r]
+	}
+	return fmt.Sprintf("RedactionLevel(%d)", int32(r))
+}
+
+// Implements encoding.TextMarshaler.
+func (r RedactionLevel) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Implements encoding.TextUnmarshaler.
+func (r *RedactionLevel) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	for i, n := range redactionLevelNames {
+		if n == name {
+			*r = RedactionLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("clog: unknown redaction level %q", text)
+}
@@ -0,0 +1,93 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	requireDebug(t)
+	dir := t.TempDir()
+	text := filepath.Join(dir, "text.log")
+	js := filepath.Join(dir, "json.log")
+	data := `{
+		"level": "debug",
+		"keys": ["dcp", "rest.*"],
+		"includeCaller": false,
+		"redaction": "partial",
+		"outputs": [
+			{"path": "` + text + `", "level": "warning"},
+			{"path": "` + js + `", "format": "json", "rotation": {"maxSizeMB": 10}}
+		]
+	}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	l := New(WithFlags(0))
+	if err := l.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if l.GetLevel() != LevelDebug || !l.KeyEnabled("rest.auth") || l.IsIncludeCaller() {
+		t.Errorf("Settings were not applied")
+	}
+	l.Debugf("debug")
+	l.Warnf("warn")
+	if err := l.Configure(Config{Outputs: []OutputConfig{{Path: "stderr"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(text); !strings.Contains(string(b), "WARN: warn") ||
+		strings.Contains(string(b), "debug") {
+		t.Errorf("Unexpected text output %q", b)
+	}
+	if b, _ := os.ReadFile(js); strings.Count(string(b), `"level":`) != 2 {
+		t.Errorf("Unexpected JSON output %q", b)
+	}
+
+	err := l.Configure(Config{Outputs: []OutputConfig{{Path: filepath.Join(dir, "no", "such")}}})
+	if err == nil {
+		t.Errorf("Expected an error opening a file in a missing directory")
+	}
+	if err := json.Unmarshal([]byte(`{"outputs":[{"format":"xml"}]}`), &cfg); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+// Run with -race: reconfiguring color must be safe while other goroutines log.
+func TestConfigureColorConcurrently(t *testing.T) {
+	defer DisableColor()
+	l := New(WithOutput(io.Discard), WithKeys("dcp"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Warnf("warning %d", j)
+				l.To("dcp", "keyed %d", j)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		color := i%2 == 0
+		if err := l.Configure(Config{Color: &color}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
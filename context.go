@@ -0,0 +1,132 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"context"
+	"sync/atomic"
+	"unsafe"
+)
+
+type contextFieldsKey struct{}
+
+// Returns a copy of ctx carrying the given fields (alternating keys and
+// values, or Fields maps, as for With), in addition to any it already
+// carries. The fields are included in messages logged with the Ctx functions.
+func ContextWith(ctx context.Context, keyvals ...interface{}) context.Context {
+	fields, _ := ctx.Value(contextFieldsKey{}).([]Field)
+	return context.WithValue(ctx, contextFieldsKey{}, appendKeyvals(fields, keyvals))
+}
+
+// Returns a Logger which adds the fields carried by ctx to every message,
+// along with its correlation ID, its pprof labels if SetPprofLabels is
+// enabled, and those returned by the functions added with AddContextFields.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields, _ := ctx.Value(contextFieldsKey{}).([]Field)
+	fields = fields[:len(fields):len(fields)]
+	if id := CorrelationID(ctx); id != "" {
+		fields = append(fields, Field{Key: "correlationID", Value: id})
+	}
+	fields = l.appendPprofLabels(ctx, fields)
+	if cp := atomic.LoadPointer(&l.contextFields); cp != nil {
+		for _, f := range *(*[]func(context.Context) []Field)(cp) {
+			fields = append(fields, f(ctx)...)
+		}
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	return l.withFields(append(all, fields...))
+}
+
+// Adds a function deriving fields from contexts to the default logger; see
+// Logger.AddContextFields.
+func AddContextFields(f func(context.Context) []Field) {
+	std.AddContextFields(f)
+}
+
+// Adds a function called by WithContext and the Ctx functions, returning
+// fields to add from the context, such as the IDs of a tracing span carried
+// by it (see the clogotel package). The functions are shared with the
+// Loggers derived from l.
+func (l *Logger) AddContextFields(f func(context.Context) []Field) {
+	for {
+		ocp := atomic.LoadPointer(&l.contextFields)
+		var newc []func(context.Context) []Field
+		if ocp != nil {
+			newc = append(newc, *(*[]func(context.Context) []Field)(ocp)...)
+		}
+		newc = append(newc, f)
+		if atomic.CompareAndSwapPointer(&l.contextFields, ocp, unsafe.Pointer(&newc)) {
+			return
+		}
+	}
+}
+
+// Logs a message to the console, but only if the corresponding key is
+// enabled, including the fields carried by ctx.
+func ToCtx(ctx context.Context, key string, format string, args ...interface{}) {
+	if std.wantsKey(LevelNormal, key) {
+		std.WithContext(ctx).doTo(key, format, args...)
+	}
+}
+
+// Logs a message to the console, including the fields carried by ctx.
+func LogCtx(ctx context.Context, format string, args ...interface{}) {
+	if std.wants(LevelNormal) {
+		std.WithContext(ctx).doPrintf(format, args...)
+	}
+}
+
+// Logs a formatted error message to the console, including the fields
+// carried by ctx.
+func ErrorfCtx(ctx context.Context, format string, args ...interface{}) {
+	if std.wants(LevelError) {
+		std.WithContext(ctx).doLogf(LevelError, fgRed, "ERRO", format, args...)
+	}
+}
+
+// Logs a formatted warning to the console, including the fields carried by
+// ctx.
+func WarnfCtx(ctx context.Context, format string, args ...interface{}) {
+	if std.wants(LevelWarning) {
+		std.WithContext(ctx).doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
+
+// Logs a message, but only if the corresponding key is enabled, including
+// the fields carried by ctx.
+func (l *Logger) ToCtx(ctx context.Context, key string, format string, args ...interface{}) {
+	if l.wantsKey(LevelNormal, key) {
+		l.WithContext(ctx).doTo(key, format, args...)
+	}
+}
+
+// Logs a message, including the fields carried by ctx.
+func (l *Logger) LogCtx(ctx context.Context, format string, args ...interface{}) {
+	if l.wants(LevelNormal) {
+		l.WithContext(ctx).doPrintf(format, args...)
+	}
+}
+
+// Logs a formatted error message, including the fields carried by ctx.
+func (l *Logger) ErrorfCtx(ctx context.Context, format string, args ...interface{}) {
+	if l.wants(LevelError) {
+		l.WithContext(ctx).doLogf(LevelError, fgRed, "ERRO", format, args...)
+	}
+}
+
+// Logs a formatted warning, including the fields carried by ctx.
+func (l *Logger) WarnfCtx(ctx context.Context, format string, args ...interface{}) {
+	if l.wants(LevelWarning) {
+		l.WithContext(ctx).doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
@@ -0,0 +1,42 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContextWith(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0)).With("node", "n1")
+
+	ctx := ContextWith(context.Background(), "reqID", 42)
+	ctx = ContextWith(ctx, Fields{"user": "bob"})
+	l.LogCtx(ctx, "hello %s", "world")
+	l.LogCtx(context.Background(), "plain")
+	l.WarnfCtx(ctx, "slow")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", b.String())
+	}
+	if lines[0] != "hello world node=n1 reqID=42 user=bob" {
+		t.Errorf("Unexpected output %q", lines[0])
+	}
+	if lines[1] != "plain node=n1" {
+		t.Errorf("Unexpected output %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "slow node=n1 reqID=42 user=bob") ||
+		!strings.Contains(lines[2], "TestContextWith() at context_test.go") {
+		t.Errorf("Unexpected output %q", lines[2])
+	}
+}
@@ -0,0 +1,51 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type correlationIDKey struct{}
+
+// Returns a copy of ctx carrying the correlation ID, which messages logged
+// with the Ctx functions include as the "correlationID" field, so that the
+// messages of one request can be followed through several components. If id
+// is empty, a new random ID is generated.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		id = NewCorrelationID()
+	}
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// Returns ctx if it carries a correlation ID, or else a copy carrying a new
+// one, along with the ID.
+func EnsureCorrelationID(ctx context.Context) (context.Context, string) {
+	if id := CorrelationID(ctx); id != "" {
+		return ctx, id
+	}
+	id := NewCorrelationID()
+	return context.WithValue(ctx, correlationIDKey{}, id), id
+}
+
+// Returns the correlation ID carried by ctx, or "" if there isn't one.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// Returns a random 16 digit hexadecimal correlation ID.
+func NewCorrelationID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
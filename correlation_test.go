@@ -0,0 +1,37 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestCorrelationID(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+
+	ctx := WithCorrelationID(ContextWith(context.Background(), "user", "bob"), "req-1")
+	l.LogCtx(ctx, "hello")
+	if b.String() != "hello user=bob correlationID=req-1\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+
+	if same, id := EnsureCorrelationID(ctx); same != ctx || id != "req-1" {
+		t.Errorf("Expected the existing ID, got %q", id)
+	}
+	ctx, id := EnsureCorrelationID(context.Background())
+	if len(id) != 16 || CorrelationID(ctx) != id {
+		t.Errorf("Expected a new ID, got %q", id)
+	}
+	if other := CorrelationID(WithCorrelationID(context.Background(), "")); len(other) != 16 || other == id {
+		t.Errorf("Expected a different new ID, got %q", other)
+	}
+}
@@ -0,0 +1,127 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Columns of a CSV formatter which aren't fields.
+const (
+	ColumnTime    = "time"    // Timestamp, as written by the structured formats
+	ColumnLevel   = "level"   // Level name, such as "warning"
+	ColumnKey     = "key"     // Key or logger name
+	ColumnMessage = "message" // Message
+	ColumnCaller  = "caller"  // File and line, if caller information is included
+	ColumnFields  = "fields"  // All fields, as key=value pairs
+	ColumnStack   = "stack"   // Stack trace, if one was requested
+)
+
+// DefaultCSVColumns are the columns written when CSVOptions.Columns is empty.
+var DefaultCSVColumns = []string{ColumnTime, ColumnLevel, ColumnKey, ColumnMessage, ColumnCaller, ColumnFields}
+
+// CSVOptions configure a formatter created by NewCSVFormatter.
+type CSVOptions struct {
+	// Columns in order: the Column constants, or the keys of fields to
+	// write in their own columns. Empty columns are written for missing
+	// fields.
+	Columns []string
+
+	Delimiter rune // Defaults to ','; use '\t' for TSV
+}
+
+// Formatter writing entries as delimiter-separated lines.
+type csvFormatter struct {
+	columns []string
+	comma   rune
+}
+
+// NewCSVFormatter returns a Formatter writing each entry as a line of
+// delimiter-separated values, quoted as described by RFC 4180: values
+// containing the delimiter, quotes or line breaks are quoted, and quotes
+// within them doubled. Use CSVHeader to write a header line.
+func NewCSVFormatter(opts CSVOptions) (Formatter, error) {
+	f := newCSVFormatter(opts)
+	w := csv.NewWriter(io.Discard)
+	w.Comma = f.comma
+	if err := w.Write(nil); err != nil { // Validates the delimiter
+		return nil, fmt.Errorf("clog: invalid CSV delimiter %q", f.comma)
+	}
+	return f, nil
+}
+
+// CSVHeader returns the header line for the options, without a newline.
+func CSVHeader(opts CSVOptions) string {
+	f := newCSVFormatter(opts)
+	return string(f.write(f.columns))
+}
+
+func newCSVFormatter(opts CSVOptions) *csvFormatter {
+	f := &csvFormatter{columns: opts.Columns, comma: opts.Delimiter}
+	if len(f.columns) == 0 {
+		f.columns = DefaultCSVColumns
+	}
+	if f.comma == 0 {
+		f.comma = ','
+	}
+	return f
+}
+
+func (f *csvFormatter) Format(e Entry) []byte {
+	record := make([]string, len(f.columns))
+	for i, c := range f.columns {
+		record[i] = csvColumn(&e, c)
+	}
+	return f.write(record)
+}
+
+// Returns the record as a line, without its newline.
+func (f *csvFormatter) write(record []string) []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Comma = f.comma
+	w.Write(record)
+	w.Flush()
+	return bytes.TrimSuffix(b.Bytes(), []byte{'\n'})
+}
+
+// Returns an entry's value for a column.
+func csvColumn(e *Entry, column string) string {
+	switch column {
+	case ColumnTime:
+		return string(e.appendTimestamp(nil))
+	case ColumnLevel:
+		return e.Level.String()
+	case ColumnKey:
+		return e.Key
+	case ColumnMessage:
+		return e.Message
+	case ColumnCaller:
+		if e.Caller == nil {
+			return ""
+		}
+		return lastComponent(e.Caller.File) + ":" + strconv.Itoa(e.Caller.Line)
+	case ColumnFields:
+		var b bytes.Buffer
+		writeFields(&b, e.Fields)
+		return string(bytes.TrimPrefix(b.Bytes(), []byte{' '}))
+	case ColumnStack:
+		return e.Stack
+	}
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if e.Fields[i].Key == column {
+			return fmt.Sprint(e.Fields[i].Value)
+		}
+	}
+	return ""
+}
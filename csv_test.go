@@ -0,0 +1,54 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCSVFormatter(t *testing.T) {
+	opts := CSVOptions{Columns: []string{ColumnLevel, ColumnKey, "vb", ColumnMessage, ColumnFields}}
+	f, err := NewCSVFormatter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	l.AddOutput(b, SinkOptions{Formatter: f})
+	l.Named("dcp").With("vb", 12, "bucket", "default").Warnf(`rollback, "seqno" %d`, 100)
+	l.Log("line one\nline two")
+
+	exp := "level,key,vb,message,fields\n" +
+		`warning,dcp,12,"rollback, ""seqno"" 100",vb=12 bucket=default` + "\n" +
+		"normal,,,\"line one\nline two\",\n"
+	if got := CSVHeader(opts) + "\n" + b.String(); got != exp {
+		t.Errorf("Expected %q, got %q", exp, got)
+	}
+}
+
+func TestTSVFormatter(t *testing.T) {
+	opts := CSVOptions{Columns: []string{ColumnLevel, ColumnMessage}, Delimiter: '\t'}
+	f, err := NewCSVFormatter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	l.AddOutput(b, SinkOptions{Formatter: f})
+	l.Errorf("a, b\tc")
+	if exp := "error\t\"a, b\tc\"\n"; b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+
+	if _, err := NewCSVFormatter(CSVOptions{Delimiter: '"'}); err == nil {
+		t.Error("Expected an error for an invalid delimiter")
+	}
+}
@@ -0,0 +1,138 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build !clog_nodebug
+
+package clog
+
+import "context"
+
+// DebugEnabled is false when built with the clog_nodebug tag, under which
+// calls to the debug and trace logging functions (Debug, Debugf, DebugTo,
+// DebugfCtx and their Trace counterparts) compile to nothing, for
+// latency-critical binaries; only function calls within their arguments
+// remain. Test it to skip those too:
+//
+//	if clog.DebugEnabled {
+//		clog.Debugf("state: %v", dump(s))
+//	}
+const DebugEnabled = true
+
+// Logs a formatted debug message to the console
+func Debugf(format string, args ...interface{}) {
+	if std.wants(LevelDebug) {
+		std.doLogf(LevelDebug, fgRed, "DEBU", format, args...)
+	}
+}
+
+// Logs a debug message to the console
+func Debug(args ...interface{}) {
+	if std.wants(LevelDebug) {
+		std.doLog(LevelDebug, fgRed, "DEBU", args...)
+	}
+}
+
+// Logs a formatted trace message to the console
+func Tracef(format string, args ...interface{}) {
+	if std.wants(LevelTrace) {
+		std.doLogf(LevelTrace, fgRed, "TRAC", format, args...)
+	}
+}
+
+// Logs a trace message to the console
+func Trace(args ...interface{}) {
+	if std.wants(LevelTrace) {
+		std.doLog(LevelTrace, fgRed, "TRAC", args...)
+	}
+}
+
+// Logs a formatted debug message to the console, but only if the key is enabled.
+func DebugTo(key string, format string, args ...interface{}) {
+	if std.wantsKey(LevelDebug, key) {
+		std.doTof(LevelDebug, key, format, args...)
+	}
+}
+
+// Logs a formatted trace message to the console, but only if the key is enabled.
+func TraceTo(key string, format string, args ...interface{}) {
+	if std.wantsKey(LevelTrace, key) {
+		std.doTof(LevelTrace, key, format, args...)
+	}
+}
+
+// Logs a formatted debug message to the console, including the fields
+// carried by ctx.
+func DebugfCtx(ctx context.Context, format string, args ...interface{}) {
+	if std.wants(LevelDebug) {
+		std.WithContext(ctx).doLogf(LevelDebug, fgRed, "DEBU", format, args...)
+	}
+}
+
+// Logs a formatted trace message to the console, including the fields
+// carried by ctx.
+func TracefCtx(ctx context.Context, format string, args ...interface{}) {
+	if std.wants(LevelTrace) {
+		std.WithContext(ctx).doLogf(LevelTrace, fgRed, "TRAC", format, args...)
+	}
+}
+
+// Logs a formatted debug message.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.wants(LevelDebug) {
+		l.doLogf(LevelDebug, fgRed, "DEBU", format, args...)
+	}
+}
+
+// Logs a debug message.
+func (l *Logger) Debug(args ...interface{}) {
+	if l.wants(LevelDebug) {
+		l.doLog(LevelDebug, fgRed, "DEBU", args...)
+	}
+}
+
+// Logs a formatted trace message.
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	if l.wants(LevelTrace) {
+		l.doLogf(LevelTrace, fgRed, "TRAC", format, args...)
+	}
+}
+
+// Logs a trace message.
+func (l *Logger) Trace(args ...interface{}) {
+	if l.wants(LevelTrace) {
+		l.doLog(LevelTrace, fgRed, "TRAC", args...)
+	}
+}
+
+// Logs a formatted debug message, but only if the key is enabled.
+func (l *Logger) DebugTo(key string, format string, args ...interface{}) {
+	if l.wantsKey(LevelDebug, key) {
+		l.doTof(LevelDebug, key, format, args...)
+	}
+}
+
+// Logs a formatted trace message, but only if the key is enabled.
+func (l *Logger) TraceTo(key string, format string, args ...interface{}) {
+	if l.wantsKey(LevelTrace, key) {
+		l.doTof(LevelTrace, key, format, args...)
+	}
+}
+
+// Logs a formatted debug message, including the fields carried by ctx.
+func (l *Logger) DebugfCtx(ctx context.Context, format string, args ...interface{}) {
+	if l.wants(LevelDebug) {
+		l.WithContext(ctx).doLogf(LevelDebug, fgRed, "DEBU", format, args...)
+	}
+}
+
+// Logs a formatted trace message, including the fields carried by ctx.
+func (l *Logger) TracefCtx(ctx context.Context, format string, args ...interface{}) {
+	if l.wants(LevelTrace) {
+		l.WithContext(ctx).doLogf(LevelTrace, fgRed, "TRAC", format, args...)
+	}
+}
@@ -0,0 +1,72 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build clog_nodebug
+
+package clog
+
+import "context"
+
+// Built with the clog_nodebug tag, the debug and trace logging functions are
+// empty, so calls to them are inlined away along with the packing of their
+// arguments, and Lazy values are never computed. Function calls written in
+// the arguments are still made, as Go requires; guard those with
+// DebugEnabled. The functions taking a level, such as LogFields, Tof and
+// Output, as well as V, VTo and Dumpf, drop debug and trace messages too, but
+// their arguments are still packed.
+const DebugEnabled = false
+
+// Logs a formatted debug message to the console
+func Debugf(format string, args ...interface{}) {}
+
+// Logs a debug message to the console
+func Debug(args ...interface{}) {}
+
+// Logs a formatted trace message to the console
+func Tracef(format string, args ...interface{}) {}
+
+// Logs a trace message to the console
+func Trace(args ...interface{}) {}
+
+// Logs a formatted debug message to the console, but only if the key is enabled.
+func DebugTo(key string, format string, args ...interface{}) {}
+
+// Logs a formatted trace message to the console, but only if the key is enabled.
+func TraceTo(key string, format string, args ...interface{}) {}
+
+// Logs a formatted debug message to the console, including the fields
+// carried by ctx.
+func DebugfCtx(ctx context.Context, format string, args ...interface{}) {}
+
+// Logs a formatted trace message to the console, including the fields
+// carried by ctx.
+func TracefCtx(ctx context.Context, format string, args ...interface{}) {}
+
+// Logs a formatted debug message.
+func (l *Logger) Debugf(format string, args ...interface{}) {}
+
+// Logs a debug message.
+func (l *Logger) Debug(args ...interface{}) {}
+
+// Logs a formatted trace message.
+func (l *Logger) Tracef(format string, args ...interface{}) {}
+
+// Logs a trace message.
+func (l *Logger) Trace(args ...interface{}) {}
+
+// Logs a formatted debug message, but only if the key is enabled.
+func (l *Logger) DebugTo(key string, format string, args ...interface{}) {}
+
+// Logs a formatted trace message, but only if the key is enabled.
+func (l *Logger) TraceTo(key string, format string, args ...interface{}) {}
+
+// Logs a formatted debug message, including the fields carried by ctx.
+func (l *Logger) DebugfCtx(ctx context.Context, format string, args ...interface{}) {}
+
+// Logs a formatted trace message, including the fields carried by ctx.
+func (l *Logger) TracefCtx(ctx context.Context, format string, args ...interface{}) {}
@@ -0,0 +1,123 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State for collapsing repeated messages.
+type deduper struct {
+	window int64 // time.Duration, accessed atomically; 0 = disabled
+
+	mu      sync.Mutex
+	last    *Entry      // Last message written
+	repeats int         // Times it has been repeated since
+	timer   *time.Timer // Writes the repeat count when the window expires
+}
+
+// Collapses repeated messages logged by the default logger; see
+// Logger.EnableDeduplication.
+func EnableDeduplication(window time.Duration) {
+	std.EnableDeduplication(window)
+}
+
+// Collapses repeated messages: when a message is logged again within the
+// window after its first occurrence, it is counted rather than written, and
+// "last message repeated N times" is written when the window expires, or
+// before the next different message if that is sooner. A window <= 0
+// disables deduplication, writing any outstanding repeat count.
+func (l *Logger) EnableDeduplication(window time.Duration) {
+	if window < 0 {
+		window = 0
+	}
+	atomic.StoreInt64(&l.dedup.window, int64(window))
+	if window == 0 {
+		l.dedup.mu.Lock()
+		summary := l.takeRepeats(time.Now())
+		l.dedup.last = nil
+		l.dedup.mu.Unlock()
+		l.writeRepeats(summary)
+	}
+}
+
+// Returns false if the entry repeats the last one and should be suppressed.
+// Writes the repeat count of the last entry, if any, before a different one.
+func (l *Logger) deduplicate(e *Entry) bool {
+	window := time.Duration(atomic.LoadInt64(&l.dedup.window))
+	if window == 0 {
+		return true
+	}
+	d := &l.dedup
+	d.mu.Lock()
+	if last := d.last; last != nil && last.Level == e.Level &&
+		last.Key == e.Key && last.Message == e.Message &&
+		e.Time.Sub(last.Time) < window {
+		d.repeats++
+		if d.timer == nil {
+			var t *time.Timer
+			t = time.AfterFunc(time.Until(last.Time.Add(window)), func() {
+				var summary *Entry
+				d.mu.Lock()
+				if d.timer == t {
+					summary = l.takeRepeats(time.Now())
+					d.last = nil
+				}
+				d.mu.Unlock()
+				l.writeRepeats(summary)
+			})
+			d.timer = t
+		}
+		d.mu.Unlock()
+		return false
+	}
+	summary := l.takeRepeats(e.Time)
+	d.last = e
+	d.mu.Unlock()
+	l.writeRepeats(summary)
+	return true
+}
+
+// Returns the repeat count of the last entry as an entry to write, or nil if
+// it wasn't repeated, stopping the timer. Must be called with dedup.mu held;
+// the entry is written after releasing it, since writing may log again.
+func (l *Logger) takeRepeats(now time.Time) *Entry {
+	d := &l.dedup
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.repeats == 0 {
+		return nil
+	}
+	summary := *d.last
+	summary.Time = now
+	summary.Message = fmt.Sprintf("last message repeated %d times", d.repeats)
+	summary.text, summary.Fields, summary.Caller = "", nil, nil
+	d.repeats = 0
+	return &summary
+}
+
+// Writes a summary returned by takeRepeats, if any.
+func (l *Logger) writeRepeats(summary *Entry) {
+	if summary != nil {
+		l.write(summary)
+	}
+}
+
+// Writes the repeat count of the last entry, if it was repeated.
+func (l *Logger) flushDedup() {
+	l.dedup.mu.Lock()
+	summary := l.takeRepeats(time.Now())
+	l.dedup.mu.Unlock()
+	l.writeRepeats(summary)
+}
@@ -0,0 +1,86 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeduplication(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.EnableDeduplication(time.Minute)
+
+	for i := 0; i < 4; i++ {
+		l.Log("connection refused")
+	}
+	l.Log("connected")
+	l.Log("connected")
+	l.EnableDeduplication(0)
+	l.Log("connected")
+
+	exp := []string{
+		"connection refused",
+		"last message repeated 3 times",
+		"connected",
+		"last message repeated 1 times",
+		"connected",
+	}
+	if got := strings.TrimSpace(b.String()); got != strings.Join(exp, "\n") {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+}
+
+func TestDeduplicationQuiet(t *testing.T) {
+	b := &lockedBuffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.EnableDeduplication(20 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		l.Log("connection refused")
+	}
+	time.Sleep(50 * time.Millisecond)
+	exp := "connection refused\nlast message repeated 2 times\n"
+	if got := b.String(); got != exp {
+		t.Errorf("Expected the count when the window expired, got:\n%s", got)
+	}
+
+	l.Log("connection refused")
+	l.Log("connection refused")
+	l.Flush()
+	exp += "connection refused\nlast message repeated 1 times\n"
+	if got := b.String(); got != exp {
+		t.Errorf("Expected the count on Flush, got:\n%s", got)
+	}
+}
+
+// An output that logs when it sees the repeat count mustn't deadlock.
+func TestDeduplicationReentrant(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	var msgs []string
+	l.AddEntryOutput(func(e Entry) {
+		msgs = append(msgs, e.Message)
+		if strings.HasPrefix(e.Message, "last message repeated") {
+			l.Log("summary written")
+		}
+	}, LevelNormal)
+	l.EnableDeduplication(time.Minute)
+	l.Log("connection refused")
+	l.Log("connection refused")
+	l.Log("connected")
+
+	exp := "connection refused,last message repeated 1 times,summary written,connected"
+	if got := strings.Join(msgs, ","); got != exp {
+		t.Errorf("Unexpected messages %s", got)
+	}
+}
@@ -0,0 +1,99 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"regexp"
+	"sync/atomic"
+	"unsafe"
+)
+
+type dropFilter struct {
+	key     string
+	re      *regexp.Regexp
+	dropped atomic.Int64
+}
+
+// DropFilterStat counts the messages dropped by a filter, as returned by
+// DropFilterStats.
+type DropFilterStat struct {
+	Key     string // "" for all keys
+	Pattern string
+	Dropped int64
+}
+
+// Adds a drop filter to the default logger; see Logger.AddDropFilter.
+func AddDropFilter(key string, re *regexp.Regexp) {
+	std.AddDropFilter(key, re)
+}
+
+// Removes the default logger's drop filters.
+func ClearDropFilters() {
+	std.ClearDropFilters()
+}
+
+// Returns the default logger's drop filter counts; see
+// Logger.DropFilterStats.
+func DropFilterStats() []DropFilterStat {
+	return std.DropFilterStats()
+}
+
+// Drops messages with the key (or logger name) whose messages match re, such
+// as recurring health-check lines, rather than leaving every consumer of the
+// log to filter them out. An empty key matches messages with any key, or
+// none. Dropped messages are counted, by DropFilterStats and as suppressed
+// by KeyStats.
+func (l *Logger) AddDropFilter(key string, re *regexp.Regexp) {
+	f := &dropFilter{key: key, re: re}
+	for {
+		ofp := atomic.LoadPointer(&l.dropFilters)
+		var newf []*dropFilter
+		if ofp != nil {
+			newf = append(newf, *(*[]*dropFilter)(ofp)...)
+		}
+		newf = append(newf, f)
+		if atomic.CompareAndSwapPointer(&l.dropFilters, ofp, unsafe.Pointer(&newf)) {
+			return
+		}
+	}
+}
+
+// Removes the logger's drop filters, and their counts.
+func (l *Logger) ClearDropFilters() {
+	atomic.StorePointer(&l.dropFilters, nil)
+}
+
+// Returns the number of messages dropped by each of the logger's drop
+// filters, in the order they were added.
+func (l *Logger) DropFilterStats() []DropFilterStat {
+	fp := atomic.LoadPointer(&l.dropFilters)
+	if fp == nil {
+		return nil
+	}
+	var stats []DropFilterStat
+	for _, f := range *(*[]*dropFilter)(fp) {
+		stats = append(stats, DropFilterStat{f.key, f.re.String(), f.dropped.Load()})
+	}
+	return stats
+}
+
+// Reports whether the entry is dropped by a filter, counting it if so.
+func (l *Logger) filtered(e *Entry) bool {
+	fp := atomic.LoadPointer(&l.dropFilters)
+	if fp == nil {
+		return false
+	}
+	for _, f := range *(*[]*dropFilter)(fp) {
+		if (f.key == "" || f.key == e.Key) && f.re.MatchString(e.Message) {
+			f.dropped.Add(1)
+			return true
+		}
+	}
+	return false
+}
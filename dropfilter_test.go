@@ -0,0 +1,50 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDropFilters(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("HTTP"))
+	l.AddDropFilter("HTTP", regexp.MustCompile(`^GET /health `))
+	l.AddDropFilter("", regexp.MustCompile(`heartbeat`))
+
+	l.To("HTTP", "GET /health 200")
+	l.To("HTTP", "GET /pools 200")
+	l.To("HTTP", "GET /health 200")
+	l.Log("GET /health 200") // Other keys aren't filtered
+	l.Log("heartbeat from n2")
+
+	expect := "HTTP: GET /pools 200\nGET /health 200\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+	stats := []DropFilterStat{
+		{"HTTP", "^GET /health ", 2},
+		{"", "heartbeat", 1},
+	}
+	if got := l.DropFilterStats(); !reflect.DeepEqual(got, stats) {
+		t.Errorf("Unexpected stats %+v", got)
+	}
+	if ks := l.KeyStats(); len(ks) != 1 || ks[0].Messages != 1 || ks[0].Suppressed != 2 {
+		t.Errorf("Unexpected key stats %+v", ks)
+	}
+
+	l.ClearDropFilters()
+	l.Log("heartbeat from n3")
+	if l.DropFilterStats() != nil || !bytes.HasSuffix(b.Bytes(), []byte("heartbeat from n3\n")) {
+		t.Errorf("Filters not cleared: %q", b.String())
+	}
+}
@@ -0,0 +1,83 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Writes a diagnostic dump from the default logger; see Logger.Dump.
+func Dump() {
+	std.Dump()
+}
+
+// Writes a diagnostic dump to the logger's outputs, for debugging a wedged
+// process: its configuration, the entries kept by EnableRecentEntries, and
+// the stacks of all goroutines. The dump is logged with the key "dump",
+// whether or not that key is enabled.
+func (l *Logger) Dump() {
+	cfg := l.currentConfig()
+	js, _ := json.Marshal(cfg)
+	l.writeDump("configuration: " + string(js))
+	if recent := l.RecentEntries(); len(recent) > 0 {
+		l.writeDump("recent entries:\n" + strings.Join(recent, "\n"))
+	}
+	buf := make([]byte, 1024*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	l.writeDump("goroutines:\n" + strings.TrimSpace(string(buf)))
+	l.Sync()
+}
+
+func (l *Logger) writeDump(msg string) {
+	l.write(&Entry{Time: time.Now(), Level: LevelNormal, Key: "dump", Message: msg})
+}
+
+// Writes diagnostic dumps on signals; see Logger.HandleDumpSignals.
+func HandleDumpSignals(sigs ...os.Signal) (stop func()) {
+	return std.HandleDumpSignals(sigs...)
+}
+
+// Writes a diagnostic dump (see Dump) whenever the process receives one of
+// the given signals, by default SIGQUIT, and SIGUSR1 where it exists.
+// Handling SIGQUIT replaces the Go runtime's behaviour of dumping the
+// goroutines and exiting. Call stop to stop handling the signals.
+func (l *Logger) HandleDumpSignals(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = dumpSignals
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				l.Dump()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
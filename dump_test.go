@@ -0,0 +1,79 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.EnableRecentEntries(RecentOptions{Size: 10, Level: LevelDebug})
+	l.Debugf("kept but not written")
+
+	l.Dump()
+	out := b.String()
+	for _, want := range []string{
+		"dump: configuration: {\"level\":\"normal\"",
+		"dump: recent entries:\n",
+		"DEBU: kept but not written",
+		"dump: goroutines:\ngoroutine ",
+		"clog.TestDump(",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected the dump to contain %q, got %q", want, out)
+		}
+	}
+}
+
+// A bytes.Buffer which is safe for concurrent use.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestHandleDumpSignals(t *testing.T) {
+	b := &lockedBuffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	stop := l.HandleDumpSignals()
+	defer stop()
+
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGQUIT); err != nil {
+		t.Skipf("Can't send SIGQUIT: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if strings.Contains(b.String(), "dump: goroutines:") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected a dump after the signal, got %q", b.String())
+}
@@ -0,0 +1,80 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The default logger is configured from the environment at startup.
+func init() {
+	if err := std.ConfigureFromEnv(); err != nil {
+		std.Warnf("%v", err)
+	}
+}
+
+// Configures the default logger from the environment; see
+// Logger.ConfigureFromEnv. This is done when the program starts.
+func ConfigureFromEnv() error {
+	return std.ConfigureFromEnv()
+}
+
+// Configures the logger from these environment variables, if set:
+//
+//	CLOG_LEVEL   the level, e.g. "debug"
+//	CLOG_KEYS    a comma-separated list of keys to enable, replacing any others
+//	CLOG_COLOR   whether to use color, e.g. "false"
+//	CLOG_FORMAT  the format, e.g. "json"; replaces the outputs by stderr
+//
+// If a variable is invalid, an error is returned and nothing is changed.
+// It is safe to call while other goroutines are logging.
+func (l *Logger) ConfigureFromEnv() error {
+	cfg, err := configFromEnv(os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	return l.Configure(cfg)
+}
+
+func configFromEnv(lookup func(string) (string, bool)) (Config, error) {
+	var cfg Config
+	if v, ok := lookup("CLOG_LEVEL"); ok {
+		level, err := ParseLevel(v)
+		if err != nil {
+			return cfg, fmt.Errorf("clog: CLOG_LEVEL: %v", err)
+		}
+		cfg.Level = &level
+	}
+	if v, ok := lookup("CLOG_KEYS"); ok {
+		cfg.Keys = []string{}
+		for _, key := range strings.Split(v, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				cfg.Keys = append(cfg.Keys, key)
+			}
+		}
+	}
+	if v, ok := lookup("CLOG_COLOR"); ok {
+		color, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("clog: CLOG_COLOR: %v", err)
+		}
+		cfg.Color = &color
+	}
+	if v, ok := lookup("CLOG_FORMAT"); ok {
+		var format Format
+		if err := format.UnmarshalText([]byte(v)); err != nil {
+			return cfg, fmt.Errorf("clog: CLOG_FORMAT: %v", err)
+		}
+		cfg.Outputs = []OutputConfig{{Path: "stderr", Format: format, Color: format.human()}}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,79 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"CLOG_LEVEL":  "debug",
+		"CLOG_KEYS":   "dcp, rest.*,",
+		"CLOG_COLOR":  "false",
+		"CLOG_FORMAT": "json",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	cfg, err := configFromEnv(lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cfg.Level != LevelDebug || !reflect.DeepEqual(cfg.Keys, []string{"dcp", "rest.*"}) ||
+		*cfg.Color || len(cfg.Outputs) != 1 || cfg.Outputs[0].Format != FormatJSON {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+
+	for name, value := range map[string]string{
+		"CLOG_LEVEL": "loud", "CLOG_COLOR": "maybe", "CLOG_FORMAT": "xml",
+	} {
+		env = map[string]string{name: value}
+		if _, err := configFromEnv(lookup); err == nil {
+			t.Errorf("Expected an error for %s=%s", name, value)
+		}
+	}
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	t.Setenv("CLOG_LEVEL", "warn")
+	t.Setenv("CLOG_KEYS", "xdcr")
+	l := New(WithOutput(io.Discard))
+	if err := l.ConfigureFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if l.GetLevel() != LevelWarning || !l.KeyEnabled("xdcr") {
+		t.Errorf("Expected the environment to be applied")
+	}
+}
+
+// Run with -race: CLOG_COLOR must be applied safely while other goroutines log.
+func TestConfigureFromEnvConcurrently(t *testing.T) {
+	defer DisableColor()
+	t.Setenv("CLOG_COLOR", "true")
+	l := New(WithOutput(io.Discard))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Warnf("warning %d", i)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if err := l.ConfigureFromEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,73 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Logs err as an error, followed by the chain of errors it wraps, one per
+// line; see Logger.ErrorChain. Returns err.
+func ErrorChain(err error) error {
+	if std.wants(LevelError) && err != nil {
+		std.doLogf(LevelError, fgRed, "ERRO", "%s", formatErrorChain(err))
+	}
+	return err
+}
+
+// Logs err as an error, followed by the chain of errors it wraps (found with
+// their Unwrap methods), one per line with its index:
+//
+//	ERRO: writing snapshot: open /data/snap: disk full
+//	  [0] writing snapshot
+//	  [1] open /data/snap
+//	  [2] disk full
+//
+// Where an error's message ends with that of the error it wraps, only the
+// part before is shown. Errors wrapping several others, as made by
+// errors.Join, have each of them listed, indented. Returns err.
+func (l *Logger) ErrorChain(err error) error {
+	if l.wants(LevelError) && err != nil {
+		l.doLogf(LevelError, fgRed, "ERRO", "%s", formatErrorChain(err))
+	}
+	return err
+}
+
+func formatErrorChain(err error) string {
+	var b strings.Builder
+	b.WriteString(err.Error())
+	index := 0
+	var walk func(err error, indent string)
+	walk = func(err error, indent string) {
+		for err != nil {
+			msg := err.Error()
+			var next error
+			switch u := err.(type) {
+			case interface{ Unwrap() error }:
+				next = u.Unwrap()
+			case interface{ Unwrap() []error }:
+				fmt.Fprintf(&b, "\n%s[%d] %s", indent, index, msg)
+				index++
+				for _, e := range u.Unwrap() {
+					walk(e, indent+"  ")
+				}
+				return
+			}
+			if next != nil {
+				msg = strings.TrimSuffix(msg, ": "+next.Error())
+			}
+			fmt.Fprintf(&b, "\n%s[%d] %s", indent, index, msg)
+			index++
+			err = next
+		}
+	}
+	walk(err, "  ")
+	return b.String()
+}
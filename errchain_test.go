@@ -0,0 +1,46 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorChain(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+
+	full := errors.New("disk full")
+	err := fmt.Errorf("writing snapshot: %w", fmt.Errorf("open /data/snap: %w", full))
+	if l.ErrorChain(err) != err {
+		t.Errorf("Expected the error to be returned")
+	}
+	expect := "ERRO: writing snapshot: open /data/snap: disk full\n" +
+		"  [0] writing snapshot\n" +
+		"  [1] open /data/snap\n" +
+		"  [2] disk full\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+
+	b.Reset()
+	l.ErrorChain(fmt.Errorf("closing: %w", errors.Join(full, errors.New("timeout"))))
+	expect = "ERRO: closing: disk full\ntimeout\n" +
+		"  [0] closing\n" +
+		"  [1] disk full\ntimeout\n" +
+		"    [2] disk full\n" +
+		"    [3] timeout\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
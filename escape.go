@@ -0,0 +1,88 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sets whether the default logger escapes control characters; see
+// Logger.SetEscapeControlChars.
+func SetEscapeControlChars(enabled bool) {
+	std.SetEscapeControlChars(enabled)
+}
+
+// Thread-safe API for setting whether the text and pretty formats escape
+// newlines and other non-printable characters in messages, as Go string
+// literals do ("\n", "\x1b", "\u2028"), so that user-controlled strings can't
+// forge log lines or send escape sequences to a terminal. Stack traces are
+// not escaped. The structured formats always escape them, as do text
+// outputs in quoted field values. (default false)
+func (l *Logger) SetEscapeControlChars(enabled bool) {
+	atomic.StoreInt32(&l.escapeControl, btoi(enabled))
+}
+
+// Returns whether s has characters which must be escaped to keep it on one
+// line and free of terminal escape sequences.
+func hasControlChars(s string) bool {
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c < 0x20 && c != '\t' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// Writes a message, escaping its control characters if escape is set.
+func writeMessage(b *bytes.Buffer, s string, escape bool) {
+	if !escape || !hasControlChars(s) {
+		b.WriteString(s)
+		return
+	}
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t' || r != utf8.RuneError && unicode.IsPrint(r):
+			b.WriteString(s[i : i+size])
+		case r < utf8.RuneSelf || size == 1:
+			b.WriteString(`\x`)
+			b.WriteByte(hexDigits[s[i]>>4])
+			b.WriteByte(hexDigits[s[i]&0xf])
+		default:
+			shift := 12
+			if r > 0xffff {
+				b.WriteString(`\U`)
+				shift = 28
+			} else {
+				b.WriteString(`\u`)
+			}
+			for ; shift >= 0; shift -= 4 {
+				b.WriteByte(hexDigits[r>>uint(shift)&0xf])
+			}
+		}
+		i += size
+	}
+}
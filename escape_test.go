@@ -0,0 +1,51 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEscapeControlChars(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	forged := "bob\n2024/01/02 03:04:05 WARN: admin logged in"
+	l.Log("login failed for %s", forged)
+	l.SetEscapeControlChars(true)
+	l.Log("login failed for %s", forged)
+	l.Warnf("bell\a esc\x1b[31m tab\t sep\u2028 bad\xff lang\U000e0001")
+	l.With("user", "a\rb").Log("field")
+
+	expect := "login failed for " + forged + "\n" +
+		`login failed for bob\n2024/01/02 03:04:05 WARN: admin logged in` + "\n" +
+		"WARN: bell\\x07 esc\\x1b[31m tab\t sep\\u2028 bad\\xff lang\\U000e0001\n" +
+		`field user="a\rb"` + "\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestJSONEscapesControlChars(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	l.AddOutput(b, SinkOptions{Format: FormatJSON})
+	l.Log("one\ntwo\x1b")
+
+	if strings.Count(b.String(), "\n") != 1 || strings.ContainsRune(b.String(), 0x1b) {
+		t.Fatalf("Unescaped output %q", b.String())
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil || m["msg"] != "one\ntwo\x1b" {
+		t.Errorf("Unexpected output %q: %v", b.String(), err)
+	}
+}
@@ -0,0 +1,22 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+// EventLogOptions configure an output writing to the Windows Event Log.
+type EventLogOptions struct {
+	Source  string   // Event source name, e.g. the service name
+	Level   LogLevel // Minimum level written; at least LevelWarning
+	EventID uint32   // Event identifier of the events written (default 1)
+}
+
+// Writes the default logger's warnings and errors to the Windows Event Log;
+// see Logger.AddEventLogOutput.
+func AddEventLogOutput(opts EventLogOptions) (*EventLogWriter, error) {
+	return std.AddEventLogOutput(opts)
+}
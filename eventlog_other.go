@@ -0,0 +1,33 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build !windows
+
+package clog
+
+import "errors"
+
+// An EventLogWriter writes log entries to the Windows Event Log, which is
+// only available on Windows.
+type EventLogWriter struct{}
+
+// Adds an output writing to the Windows Event Log. It always fails on this
+// platform.
+func (l *Logger) AddEventLogOutput(opts EventLogOptions) (*EventLogWriter, error) {
+	return nil, errors.New("clog: the Windows Event Log is only supported on Windows")
+}
+
+// Returns nil.
+func (w *EventLogWriter) Err() error {
+	return nil
+}
+
+// Returns nil.
+func (w *EventLogWriter) Close() error {
+	return nil
+}
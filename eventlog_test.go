@@ -0,0 +1,34 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestEventLogOutput(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	w, err := l.AddEventLogOutput(EventLogOptions{Source: "clog-test"})
+	if runtime.GOOS != "windows" {
+		if err == nil {
+			t.Errorf("Expected an error on %s", runtime.GOOS)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l.Warnf("test warning")
+	if err := w.Err(); err != nil {
+		t.Error(err)
+	}
+}
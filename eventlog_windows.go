@@ -0,0 +1,115 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build windows
+
+package clog
+
+import (
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+)
+
+// Event types passed to ReportEvent.
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+// An EventLogWriter writes log entries to the Windows Event Log.
+type EventLogWriter struct {
+	opts EventLogOptions
+
+	mu     sync.Mutex // Protects handle and err
+	handle uintptr
+	err    error // Last write error
+}
+
+// Adds an output writing warnings and errors to the Windows Event Log, under
+// the given event source. Event Viewer shows the message text in full if the
+// source is registered, for instance by the service's installer, with
+// EventMessageFile set to a message file passing "%1" through.
+func (l *Logger) AddEventLogOutput(opts EventLogOptions) (*EventLogWriter, error) {
+	if opts.Level < LevelWarning {
+		opts.Level = LevelWarning
+	}
+	if opts.EventID == 0 {
+		opts.EventID = 1
+	}
+	source, err := syscall.UTF16PtrFromString(opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(source)))
+	if h == 0 {
+		return nil, err
+	}
+	w := &EventLogWriter{opts: opts, handle: h}
+	l.addSink(&sink{level: opts.Level, ew: w})
+	return w, nil
+}
+
+// Returns the error from the most recent write, if it failed.
+func (w *EventLogWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Deregisters the event source. Entries logged afterwards are dropped.
+func (w *EventLogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.handle == 0 {
+		return nil
+	}
+	r, _, err := procDeregisterEventSource.Call(w.handle)
+	w.handle = 0
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (w *EventLogWriter) writeEntry(e *Entry) {
+	etype := eventlogInformationType
+	switch {
+	case e.Level == levelTemp:
+	case e.Level >= LevelError:
+		etype = eventlogErrorType
+	case e.Level >= LevelWarning:
+		etype = eventlogWarningType
+	}
+	msg, err := syscall.UTF16PtrFromString(strings.ReplaceAll(formatText(e, false), "\x00", ""))
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.handle == 0 {
+		return
+	}
+	strs := [1]*uint16{msg}
+	r, _, err := procReportEventW.Call(w.handle, uintptr(etype), 0, uintptr(w.opts.EventID),
+		0, 1, 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if r == 0 {
+		w.err = err
+	} else {
+		w.err = nil
+	}
+}
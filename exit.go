@@ -0,0 +1,166 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Exit status used by Fatal and Fatalf (stored atomically).
+var exitCode = int32(1)
+
+var (
+	exitHooksMu sync.Mutex
+	exitHooks   []func()
+)
+
+// Implemented by outputs which buffer entries.
+type flusher interface {
+	Flush() error
+}
+
+// Implemented by outputs which can commit written data to stable storage,
+// such as *os.File.
+type syncer interface {
+	Sync() error
+}
+
+// Registers a function to be called before Fatal, Fatalf or FatalWithCode
+// exits the process. Hooks are called in the order registered, before the
+// outputs are flushed, so they may log.
+func OnExit(f func()) {
+	exitHooksMu.Lock()
+	exitHooks = append(exitHooks, f)
+	exitHooksMu.Unlock()
+}
+
+// Thread-safe API for setting the exit status used by Fatal and Fatalf.
+// (default 1)
+func SetExitCode(code int) {
+	atomic.StoreInt32(&exitCode, int32(code))
+}
+
+// Thread-safe API for fetching the exit status used by Fatal and Fatalf.
+func GetExitCode() int {
+	return int(atomic.LoadInt32(&exitCode))
+}
+
+// Logs a message to the console, then exits the process with the given
+// status.
+func FatalWithCode(code int, args ...interface{}) {
+	std.doLog(LevelPanic, fgRed, "FATA", args...)
+	std.exit(code)
+}
+
+// Logs a formatted message to the console, then exits the process with the
+// given status.
+func FatalfWithCode(code int, format string, args ...interface{}) {
+	std.doLogf(LevelPanic, fgRed, "FATA", format, args...)
+	std.exit(code)
+}
+
+// Logs a message, then exits the process with the given status.
+func (l *Logger) FatalWithCode(code int, args ...interface{}) {
+	l.doLog(LevelPanic, fgRed, "FATA", args...)
+	l.exit(code)
+}
+
+// Logs a formatted message, then exits the process with the given status.
+func (l *Logger) FatalfWithCode(code int, format string, args ...interface{}) {
+	l.doLogf(LevelPanic, fgRed, "FATA", format, args...)
+	l.exit(code)
+}
+
+// Runs the exit hooks and shuts down the logger, then exits.
+func (l *Logger) exit(code int) {
+	exitHooksMu.Lock()
+	hooks := append([]func(){}, exitHooks...)
+	exitHooksMu.Unlock()
+	for _, f := range hooks {
+		f()
+	}
+	l.Shutdown()
+	exit(code)
+}
+
+// Shuts down the default logger; see Logger.Shutdown.
+func Shutdown() error {
+	return std.Shutdown()
+}
+
+// Writes out any queued entries and stops asynchronous output, flushes the
+// outputs, then closes the files opened by SetFileOutput and Configure,
+// waiting for the compression of rotated files to finish. Call it before the
+// process exits; Fatal calls it after running the OnExit hooks. Messages
+// logged to the closed files afterwards are written to stderr. Returns the
+// first error.
+func (l *Logger) Shutdown() error {
+	l.EnableAsync(AsyncOptions{})
+	first := l.Sync()
+	files := l.configured
+	if l.file != nil {
+		files = append(files, l.file)
+	}
+	l.configured, l.file = nil, nil
+	for _, f := range files {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Flushes the default logger's outputs; see Logger.Flush.
+func Flush() {
+	std.Flush()
+}
+
+// Writes any outstanding counts of rate limited or repeated messages, waits
+// for any queued entries to be written (see EnableAsync), flushes any
+// outputs which buffer entries, and syncs those writing to files.
+func (l *Logger) Flush() {
+	l.Sync()
+}
+
+// Flushes and syncs the default logger's outputs; see Logger.Sync.
+func Sync() error {
+	return std.Sync()
+}
+
+// Like Flush, but returns the first error.
+func (l *Logger) Sync() error {
+	l.flushRateLimits()
+	l.flushDedup()
+	if q := l.loadAsync(); q != nil {
+		q.flush()
+	}
+	var first error
+	for _, s := range l.loadSinks() {
+		var w interface{} = s.ew
+		if s.ew == nil {
+			w = s.logger.Writer()
+		}
+		if f, ok := w.(flusher); ok {
+			if err := f.Flush(); err != nil && first == nil {
+				first = err
+			}
+		}
+		if w == os.Stderr || w == os.Stdout {
+			continue // Syncing a terminal or pipe fails
+		}
+		if f, ok := w.(syncer); ok {
+			if err := f.Sync(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
@@ -0,0 +1,81 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushed int
+}
+
+func (f *flushRecorder) Flush() error {
+	f.flushed++
+	return nil
+}
+
+func TestFatalExitHooks(t *testing.T) {
+	var status []int
+	exit = func(i int) { status = append(status, i) }
+	defer func() { exit = os.Exit }()
+	defer SetExitCode(1)
+
+	b := &flushRecorder{}
+	l := New(WithOutput(b), WithFlags(0))
+	OnExit(func() { l.Log("exit hook") })
+	defer func() { exitHooks = nil }()
+
+	l.Fatal("first")
+	SetExitCode(3)
+	l.Fatalf("second %d", 2)
+	l.FatalWithCode(4, "third")
+
+	if len(status) != 3 || status[0] != 1 || status[1] != 3 || status[2] != 4 {
+		t.Errorf("Unexpected exit statuses %v", status)
+	}
+	if b.flushed != 3 {
+		t.Errorf("Expected the output to be flushed before each exit, got %d", b.flushed)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 6 || lines[1] != "exit hook" {
+		t.Errorf("Expected exit hooks to run after each message, got %q", lines)
+	}
+}
+
+func TestFatalShutdown(t *testing.T) {
+	exit = func(int) {}
+	defer func() { exit = os.Exit }()
+
+	path := filepath.Join(t.TempDir(), "fatal.log")
+	l := New(WithFlags(0), WithIncludeCaller(false))
+	if err := l.SetFileOutput(path, RotationOptions{Compress: true}); err != nil {
+		t.Fatal(err)
+	}
+	f := l.file
+	l.EnableAsync(AsyncOptions{QueueSize: 100})
+	l.Log("queued")
+	l.Fatal("fatal")
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "queued\n") || !strings.Contains(string(data), "fatal") {
+		t.Errorf("Expected queued messages to be written before exiting, got %q", data)
+	}
+	if l.loadAsync() != nil {
+		t.Errorf("Expected asynchronous output to be stopped")
+	}
+	if _, err := f.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("Expected the file to be closed, got %v", err)
+	}
+}
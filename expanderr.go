@@ -0,0 +1,69 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Sets the minimum level at which the default logger expands error
+// arguments; see Logger.SetExpandErrorsLevel.
+func SetExpandErrorsLevel(level LogLevel) {
+	std.SetExpandErrorsLevel(level)
+}
+
+// Thread-safe API for setting the minimum level of messages whose error
+// arguments are formatted with %+v where they are formatted with %v, for
+// instance LevelError so that errors from github.com/pkg/errors show their
+// stacks in error messages only. This applies to the arguments of the
+// logging functions, including Error's, but not to fields. Expansion is
+// disabled by default; a level above LevelPanic disables it again.
+func (l *Logger) SetExpandErrorsLevel(level LogLevel) {
+	atomic.StoreInt32(&l.expandErrors, int32(level))
+}
+
+// Returns args with its errors wrapped to be formatted verbosely, if
+// messages at level expand them. args is returned unchanged otherwise.
+func (l *Logger) expandErrorArgs(level LogLevel, args []interface{}) []interface{} {
+	if !l.expandsErrors(level) {
+		return args
+	}
+	var expanded []interface{}
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			if expanded == nil {
+				expanded = append([]interface{}(nil), args...)
+			}
+			expanded[i] = verboseError{err}
+		}
+	}
+	if expanded == nil {
+		return args
+	}
+	return expanded
+}
+
+// Returns whether messages at level have their error arguments expanded.
+func (l *Logger) expandsErrors(level LogLevel) bool {
+	return level >= LogLevel(atomic.LoadInt32(&l.expandErrors))
+}
+
+// Formats an error with %+v in place of %v. Other verbs are passed through.
+type verboseError struct {
+	err error
+}
+
+func (v verboseError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && !s.Flag('#') {
+		fmt.Fprintf(s, "%+v", v.err)
+		return
+	}
+	fmt.Fprintf(s, fmt.FormatString(s, verb), v.err)
+}
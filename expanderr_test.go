@@ -0,0 +1,52 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// An error formatting itself with detail under %+v, like those of
+// github.com/pkg/errors.
+type detailedError struct{}
+
+func (detailedError) Error() string { return "disk full" }
+
+func (e detailedError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprint(s, "disk full (detail)")
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+func TestExpandErrors(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	var err error = detailedError{}
+
+	l.Errorf("write failed: %v", err)
+	l.SetExpandErrorsLevel(LevelError)
+	l.Warnf("retrying: %v", err)
+	l.Errorf("write failed: %v (%q)", err, err)
+	l.Critical("giving up: ", err)
+	l.Error(err)
+
+	exp := "ERRO: write failed: disk full\n" +
+		"WARN: retrying: disk full\n" +
+		"ERRO: write failed: disk full (detail) (disk full)\n" +
+		"CRIT: giving up: disk full (detail)\n" +
+		"ERRO: disk full (detail)\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
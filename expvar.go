@@ -0,0 +1,81 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"expvar"
+)
+
+// Publishes the default logger's state as the expvar "clog"; see
+// Logger.PublishExpvars.
+func PublishExpvars() {
+	std.PublishExpvars("clog")
+}
+
+// Publishes the logger's state as an expvar map with the given name, so that
+// it is served at /debug/vars along with the service's own variables:
+//
+//	"clog": {"level": "normal", "keys": ["DCP"], "messages": {"warning": 3, ...},
+//	         "asyncQueue": 0, "dropped": 0, "filtered": 0}
+//
+// The values are read when the variables are. If name is already published,
+// nothing is done.
+func (l *Logger) PublishExpvars(name string) {
+	if expvar.Get(name) != nil {
+		return
+	}
+	m := new(expvar.Map)
+	m.Set("level", expvar.Func(func() interface{} { return l.GetLevel().String() }))
+	m.Set("keys", expvar.Func(func() interface{} {
+		keys := l.EnabledKeys()
+		if keys == nil {
+			keys = []string{}
+		}
+		return keys
+	}))
+	m.Set("messages", expvar.Func(func() interface{} { return l.LevelCounts() }))
+	m.Set("asyncQueue", expvar.Func(func() interface{} {
+		if q := l.loadAsync(); q != nil {
+			return len(q.entries)
+		}
+		return 0
+	}))
+	m.Set("dropped", expvar.Func(func() interface{} {
+		var n int64
+		for _, d := range l.Dropped() {
+			n += d.Count
+		}
+		return n
+	}))
+	m.Set("filtered", expvar.Func(func() interface{} {
+		var n int64
+		for _, s := range l.DropFilterStats() {
+			n += s.Dropped
+		}
+		return n
+	}))
+	expvar.Publish(name, m)
+}
+
+// Returns the number of messages the default logger has written at each
+// level; see Logger.LevelCounts.
+func LevelCounts() map[string]int64 {
+	return std.LevelCounts()
+}
+
+// Returns the number of messages written at each level by l and the Loggers
+// derived from it, by level name. Messages below the level, or suppressed by
+// sampling, rate limiting, deduplication or drop filters, aren't counted.
+func (l *Logger) LevelCounts() map[string]int64 {
+	counts := make(map[string]int64, len(l.levelCounts))
+	for level := range l.levelCounts {
+		counts[LogLevel(level).String()] = l.levelCounts[level].Load()
+	}
+	return counts
+}
@@ -0,0 +1,42 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/json"
+	"expvar"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestPublishExpvars(t *testing.T) {
+	l := New(WithOutput(io.Discard), WithKeys("DCP"))
+	l.PublishExpvars("clogtest")
+	l.PublishExpvars("clogtest") // Doesn't panic
+	l.Warnf("one")
+	l.Warnf("two")
+	l.Debugf("filtered")
+	l.To("DCP", "three")
+
+	var vars struct {
+		Level      string
+		Keys       []string
+		Messages   map[string]int64
+		AsyncQueue int
+		Dropped    int64
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("clogtest").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if vars.Level != "normal" || !reflect.DeepEqual(vars.Keys, []string{"DCP"}) ||
+		vars.Messages["warning"] != 2 || vars.Messages["normal"] != 1 || vars.Messages["debug"] != 0 {
+		t.Errorf("Unexpected vars %+v", vars)
+	}
+}
@@ -0,0 +1,61 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Where messages go when their output fails.
+var fallbackOutput io.Writer = os.Stderr
+
+// Sets the default logger's error handler; see Logger.SetErrorHandler.
+func SetErrorHandler(f func(error)) {
+	std.SetErrorHandler(f)
+}
+
+// Sets a function to be called whenever writing to one of the logger's
+// outputs fails, such as when the disk is full or a pipe is broken. The
+// message is written to stderr in its place. Without a handler, the failure
+// is noted on stderr once, until the output works again. Pass nil to remove
+// the handler.
+//
+// The handler must not log to the failing logger. If it does, or if another
+// write fails while it runs, the handler isn't called again for that failure;
+// the message still goes to stderr.
+func (l *Logger) SetErrorHandler(f func(error)) {
+	var p unsafe.Pointer
+	if f != nil {
+		p = unsafe.Pointer(&f)
+	}
+	atomic.StorePointer(&l.errorHandler, p)
+}
+
+// Reports a failed write to an output, and writes the entry to the fallback
+// output instead.
+func (l *Logger) writeFailed(s *sink, e *Entry, err error) {
+	err = fmt.Errorf("clog: writing log output: %w", err)
+	first := atomic.CompareAndSwapInt32(&s.failing, 0, 1)
+	hp := atomic.LoadPointer(&l.errorHandler)
+	if hp != nil && atomic.CompareAndSwapInt32(&l.inHandler, 0, 1) {
+		defer atomic.StoreInt32(&l.inHandler, 0)
+		(*(*func(error))(hp))(err)
+	} else if first {
+		fmt.Fprintf(fallbackOutput, "%v; writing to stderr\n", err)
+	}
+	if s.logger.Writer() == fallbackOutput {
+		return
+	}
+	log.New(fallbackOutput, "", l.sinkFlags(l.Flags())).Print(formatText(e, false))
+}
@@ -0,0 +1,87 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var errDiskFull = errors.New("disk full")
+
+// A writer which fails while broken is set.
+type failingWriter struct {
+	broken bool
+	b      bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.broken {
+		return 0, errDiskFull
+	}
+	return w.b.Write(p)
+}
+
+func TestFallbackOutput(t *testing.T) {
+	fallback := &bytes.Buffer{}
+	defer func(w io.Writer) { fallbackOutput = w }(fallbackOutput)
+	fallbackOutput = fallback
+
+	w := &failingWriter{broken: true}
+	l := New(WithOutput(w), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+
+	l.Log("one")
+	l.Log("two")
+	if got := fallback.String(); got != "clog: writing log output: disk full; writing to stderr\none\ntwo\n" {
+		t.Errorf("Unexpected fallback output %q", got)
+	}
+
+	var errs []error
+	l.SetErrorHandler(func(err error) { errs = append(errs, err) })
+	fallback.Reset()
+	l.Warnf("three")
+	if len(errs) != 1 || !errors.Is(errs[0], errDiskFull) {
+		t.Errorf("Expected the handler to get the error, got %v", errs)
+	}
+	if got := fallback.String(); got != "WARN: three\n" {
+		t.Errorf("Unexpected fallback output %q", got)
+	}
+
+	w.broken = false
+	fallback.Reset()
+	l.Log("four")
+	if fallback.Len() != 0 || !strings.HasSuffix(w.b.String(), "four\n") {
+		t.Errorf("Expected output to resume, got %q", w.b.String())
+	}
+}
+
+func TestErrorHandlerLogs(t *testing.T) {
+	fallback := &bytes.Buffer{}
+	defer func(w io.Writer) { fallbackOutput = w }(fallbackOutput)
+	fallbackOutput = fallback
+
+	l := New(WithOutput(&failingWriter{broken: true}), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	calls := 0
+	l.SetErrorHandler(func(err error) {
+		calls++
+		l.Warnf("handler: %v", err)
+	})
+	l.Log("one")
+	if calls != 1 {
+		t.Errorf("Expected the handler to be called once, got %d", calls)
+	}
+	if got := fallback.String(); !strings.Contains(got, "one\n") || !strings.Contains(got, "WARN: handler: ") {
+		t.Errorf("Unexpected fallback output %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fields is a set of structured attributes which can be passed to With.
+type Fields map[string]interface{}
+
+// A Field is a structured attribute of an Entry. Fields can be created
+// with F, or as a literal with Key and Value.
+type Field struct {
+	Key   string
+	Value interface{}
+
+	kind fieldKind // Set by F if the value isn't in Value
+	num  uint64    // Numeric and bool values from F
+	str  string    // String values from F
+}
+
+// Returns a Logger which attaches the given attributes to every message
+// logged through it. The arguments are alternating keys and values, and may
+// also include Fields maps:
+//
+//	clog.With("reqID", id, "bucket", name).Log("opened")
+//	clog.With(clog.Fields{"reqID": id}).Warnf("slow: %v", d)
+//
+// The returned Logger shares all other settings with its parent.
+func With(keyvals ...interface{}) *Logger {
+	return std.With(keyvals...)
+}
+
+// Returns a Logger which attaches the given attributes to every message
+// logged through it; see the package-level With.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	return l.withFields(appendKeyvals(l.fields, keyvals))
+}
+
+// Returns a copy of fields with keyvals (as passed to With) appended.
+func appendKeyvals(fields []Field, keyvals []interface{}) []Field {
+	result := make([]Field, len(fields), len(fields)+len(keyvals)/2)
+	copy(result, fields)
+	for i := 0; i < len(keyvals); i++ {
+		switch kv := keyvals[i].(type) {
+		case Field:
+			result = append(result, kv.resolve())
+		case Fields:
+			keys := make([]string, 0, len(kv))
+			for k := range kv {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				result = append(result, Field{Key: k, Value: kv[k]})
+			}
+		default:
+			f := Field{Key: fmt.Sprint(kv), Value: "(MISSING)"}
+			if i+1 < len(keyvals) {
+				i++
+				f.Value = keyvals[i]
+			}
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Appends fields to a text message as key=value pairs.
+func writeFields(b *bytes.Buffer, fields []Field) {
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		writeFieldValue(b, f.Value)
+	}
+}
+
+// Writes a field value, quoted if necessary. Common types are formatted
+// without allocating.
+func writeFieldValue(b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		writeQuotedIfNeeded(b, v)
+	case int:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(v), 10))
+	case int64:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), v, 10))
+	case uint64:
+		b.Write(strconv.AppendUint(b.AvailableBuffer(), v, 10))
+	case bool:
+		b.Write(strconv.AppendBool(b.AvailableBuffer(), v))
+	default:
+		writeQuotedIfNeeded(b, fmt.Sprint(value))
+	}
+}
+
+func writeQuotedIfNeeded(b *bytes.Buffer, s string) {
+	if s == "" || strings.ContainsAny(s, " \t\"=") || hasControlChars(s) {
+		b.Write(strconv.AppendQuote(b.AvailableBuffer(), s))
+	} else {
+		b.WriteString(s)
+	}
+}
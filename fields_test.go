@@ -0,0 +1,47 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+
+	tests := []struct {
+		l      *Logger
+		output string
+	}{
+		{l, "msg"},
+		{l.With("reqID", 12, "bucket", "default"), "msg reqID=12 bucket=default"},
+		{l.With("reqID", 12).With("name", "a b"), "msg reqID=12 name=\"a b\""},
+		{l.With(Fields{"b": 2, "a": 1}, "c", 3), "msg a=1 b=2 c=3"},
+		{l.With("odd"), "msg odd=(MISSING)"},
+	}
+
+	for _, test := range tests {
+		b.Reset()
+		test.l.Log("msg")
+		if got := b.String(); got != test.output+"\n" {
+			t.Errorf("Expected %q, got %q", test.output+"\n", got)
+		}
+	}
+
+	// Derived loggers share their parent's settings.
+	child := l.With("a", 1)
+	l.SetLevel(LevelError)
+	b.Reset()
+	child.Log("msg")
+	if b.Len() != 0 {
+		t.Errorf("Expected child to share the parent's level, got %q", b.String())
+	}
+}
@@ -0,0 +1,71 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"flag"
+	"strings"
+)
+
+// Returns a flag.Value setting the default logger's level; see
+// Logger.LevelFlag.
+func LevelFlag() flag.Value {
+	return std.LevelFlag()
+}
+
+// Returns a flag.Value enabling the default logger's keys; see
+// Logger.KeysFlag.
+func KeysFlag() flag.Value {
+	return std.KeysFlag()
+}
+
+// Returns a flag.Value which sets the logger's level, parsed by ParseLevel:
+//
+//	flag.Var(clog.LevelFlag(), "loglevel", "log level (trace, debug, normal, ...)")
+func (l *Logger) LevelFlag() flag.Value {
+	return levelFlag{l}
+}
+
+// Returns a flag.Value which enables the logger's keys. The flag takes a
+// comma-separated list as for ParseLogFlag, and may be repeated.
+func (l *Logger) KeysFlag() flag.Value {
+	return keysFlag{l}
+}
+
+type levelFlag struct{ l *Logger }
+
+func (f levelFlag) String() string {
+	if f.l == nil {
+		return "" // The zero value, for flag.PrintDefaults
+	}
+	return f.l.GetLevel().String()
+}
+
+func (f levelFlag) Set(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	f.l.SetLevel(level)
+	return nil
+}
+
+type keysFlag struct{ l *Logger }
+
+func (f keysFlag) String() string {
+	if f.l == nil {
+		return ""
+	}
+	return strings.Join(f.l.EnabledKeys(), ",")
+}
+
+func (f keysFlag) Set(s string) error {
+	f.l.ParseLogFlag(s)
+	return nil
+}
@@ -0,0 +1,45 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLevelAndKeysFlags(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(l.LevelFlag(), "loglevel", "log level")
+	fs.Var(l.KeysFlag(), "logkeys", "log keys")
+
+	if err := fs.Parse([]string{"-loglevel", "debug", "-logkeys", "dcp,xdcr", "-logkeys", "rest"}); err != nil {
+		t.Fatal(err)
+	}
+	if l.GetLevel() != LevelDebug {
+		t.Errorf("Expected level debug, got %v", l.GetLevel())
+	}
+	if got := fs.Lookup("logkeys").Value.String(); got != "dcp,rest,xdcr" {
+		t.Errorf("Unexpected keys %q", got)
+	}
+	if err := fs.Parse([]string{"-loglevel", "loud"}); err == nil ||
+		!strings.Contains(err.Error(), "unknown log level") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+
+	var b strings.Builder
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	if !strings.Contains(b.String(), "-loglevel value") {
+		t.Errorf("Unexpected defaults %q", b.String())
+	}
+}
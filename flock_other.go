@@ -0,0 +1,20 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build !unix
+
+package clog
+
+// Advisory locks are only supported on Unix, and elsewhere files which are
+// open in another process can't be renamed, so OpenRotatingFile rejects
+// shared files.
+const lockSupported = false
+
+func lockFile(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
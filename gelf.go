@@ -0,0 +1,266 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GELFOptions configure an output sending entries to Graylog.
+type GELFOptions struct {
+	Address   string        // host:port of the GELF input
+	Network   string        // "udp" (default) or "tcp"
+	Host      string        // The host field (default os.Hostname)
+	Level     LogLevel      // Minimum level sent
+	Compress  bool          // Gzip messages (UDP only)
+	ChunkSize int           // Maximum UDP datagram size (default 1420)
+	Timeout   time.Duration // Limit on connecting and on each write (default 5s)
+	Batch     BatchOptions  // FlushInterval defaults to 100ms
+	Circuit   CircuitOptions
+}
+
+// A GELFWriter sends log entries to a Graylog GELF input. Large UDP
+// messages are split into GELF chunks.
+type GELFWriter struct {
+	opts  GELFOptions
+	batch *batcher
+
+	mu   sync.Mutex // Protects conn
+	conn net.Conn
+}
+
+// Maximum number of chunks in a chunked GELF message.
+const gelfMaxChunks = 128
+
+// Sends the default logger's entries to Graylog; see Logger.AddGELFOutput.
+func AddGELFOutput(opts GELFOptions) (*GELFWriter, error) {
+	return std.AddGELFOutput(opts)
+}
+
+// Adds an output sending entries to Graylog in GELF format. Keys are sent as
+// the _subsystem field, and fields as additional fields. Entries are sent in
+// batches from a background goroutine, so that a stalled input can't hold up
+// logging, and a batch which can't be sent is retried with backoff, then
+// dropped; a TCP connection is re-established if it fails. Close the writer
+// to send any outstanding entries.
+func (l *Logger) AddGELFOutput(opts GELFOptions) (*GELFWriter, error) {
+	if opts.Network == "" {
+		opts.Network = "udp"
+	}
+	if opts.Network != "udp" && opts.Network != "tcp" {
+		return nil, fmt.Errorf("clog: unsupported GELF network %q", opts.Network)
+	}
+	if opts.Host == "" {
+		opts.Host, _ = os.Hostname()
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 1420
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Batch.FlushInterval <= 0 {
+		opts.Batch.FlushInterval = 100 * time.Millisecond
+	}
+	conn, err := net.DialTimeout(opts.Network, opts.Address, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	g := &GELFWriter{opts: opts, conn: conn}
+	g.batch = newBatcher(opts.Batch, newCircuit("gelf "+opts.Network+" "+opts.Address, opts.Circuit), g.send, nil)
+	l.addSink(&sink{level: opts.Level, ew: g})
+	return g, nil
+}
+
+// Sends any outstanding entries, returning the error from doing so.
+func (g *GELFWriter) Flush() error {
+	return g.batch.Flush()
+}
+
+// Returns the error from the most recent send, if it failed.
+func (g *GELFWriter) Err() error {
+	return g.batch.Err()
+}
+
+// Returns the number of entries dropped because too many were waiting to be
+// sent, or they were logged after Close.
+func (g *GELFWriter) Dropped() uint64 {
+	return g.batch.Dropped()
+}
+
+// Sends any outstanding entries, then closes the connection. Entries logged
+// afterwards are dropped.
+func (g *GELFWriter) Close() error {
+	err := g.batch.Close()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.conn != nil {
+		if cerr := g.conn.Close(); err == nil {
+			err = cerr
+		}
+		g.conn = nil
+	}
+	return err
+}
+
+func (g *GELFWriter) writeEntry(e *Entry) {
+	g.batch.add(batchRecord{time: e.Time, value: formatGELF(e, g.opts.Host)})
+}
+
+func (g *GELFWriter) health() SinkState {
+	return g.batch.circuit.health()
+}
+
+// Sends a batch of messages, reconnecting first if the TCP connection failed.
+func (g *GELFWriter) send(batch []batchRecord) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.opts.Network == "tcp" {
+		return g.sendTCP(batch)
+	}
+	for _, r := range batch {
+		if err := g.sendUDP(r.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sends null-terminated messages over TCP. Must be called with mu held.
+func (g *GELFWriter) sendTCP(batch []batchRecord) error {
+	if g.conn == nil {
+		conn, err := net.DialTimeout(g.opts.Network, g.opts.Address, g.opts.Timeout)
+		if err != nil {
+			return err
+		}
+		g.conn = conn
+	}
+	var b bytes.Buffer
+	for _, r := range batch {
+		b.Write(r.value)
+		b.WriteByte(0)
+	}
+	g.conn.SetWriteDeadline(time.Now().Add(g.opts.Timeout))
+	if _, err := g.conn.Write(b.Bytes()); err != nil {
+		g.conn.Close()
+		g.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (g *GELFWriter) sendUDP(msg []byte) error {
+	if g.opts.Compress {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		zw.Write(msg)
+		zw.Close()
+		msg = b.Bytes()
+	}
+	if len(msg) <= g.opts.ChunkSize {
+		_, err := g.conn.Write(msg)
+		return err
+	}
+	// Each chunk has a 12 byte header: magic bytes, message ID, sequence
+	// number and count.
+	size := g.opts.ChunkSize - 12
+	count := (len(msg) + size - 1) / size
+	if size <= 0 || count > gelfMaxChunks {
+		return fmt.Errorf("clog: GELF message of %d bytes is too large", len(msg))
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	for i := 0; i < count; i++ {
+		chunk := msg[i*size:]
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+		header := append([]byte{0x1e, 0x0f}, id...)
+		if _, err := g.conn.Write(append(append(header, byte(i), byte(count)), chunk...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Syslog severities, as used by GELF, by level.
+var gelfLevels = []int{
+	LevelTrace:    7, // Debug
+	LevelDebug:    7, // Debug
+	LevelNormal:   6, // Informational
+	LevelNotice:   5, // Notice
+	LevelWarning:  4, // Warning
+	LevelError:    3, // Error
+	LevelCritical: 2, // Critical
+	LevelPanic:    1, // Alert
+}
+
+func formatGELF(e *Entry, host string) []byte {
+	level := 6
+	if e.Level >= 0 && int(e.Level) < len(gelfLevels) {
+		level = gelfLevels[e.Level]
+	}
+	var b bytes.Buffer
+	b.WriteByte('{')
+	appendJSON(&b, "version", "1.1")
+	appendJSON(&b, "host", host)
+	appendJSON(&b, "short_message", e.Message)
+	if e.Stack != "" {
+		appendJSON(&b, "full_message", e.Message+"\n"+e.Stack)
+	}
+	appendJSON(&b, "timestamp", float64(e.Time.UnixNano())/float64(time.Second))
+	appendJSON(&b, "level", level)
+	if e.Key != "" {
+		appendJSON(&b, "_subsystem", e.Key)
+	}
+	if e.Caller != nil {
+		appendJSON(&b, "_file", e.Caller.File)
+		appendJSON(&b, "_line", e.Caller.Line)
+		appendJSON(&b, "_function", e.Caller.Function)
+	}
+	for _, f := range e.Fields {
+		appendJSON(&b, gelfFieldName(f.Key), gelfValue(f.Value))
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+// Returns the name of an additional field, which may only contain word
+// characters, dots and dashes, and may not be "_id".
+func gelfFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+	if name == "id" {
+		name = "id_"
+	}
+	return "_" + name
+}
+
+// GELF field values are strings or numbers.
+func gelfValue(v interface{}) interface{} {
+	switch v.(type) {
+	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	}
+	return fmt.Sprint(v)
+}
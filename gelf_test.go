@@ -0,0 +1,168 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	l := New(WithOutput(io.Discard))
+	g, err := l.AddGELFOutput(GELFOptions{
+		Address:   pc.LocalAddr().String(),
+		Host:      "node1",
+		Level:     LevelWarning,
+		Compress:  true,
+		ChunkSize: 64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	l.Log("not sent")
+	l.Named("dcp").With("vb", 12, "id", "x", "doc key", UD("k1")).
+		Warnf("stream closed: %s", strings.Repeat("x", 100))
+
+	// Reassemble the chunks
+	var chunks [][]byte
+	buf := make([]byte, 2048)
+	for count := 1; len(chunks) < count; {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 64 || buf[0] != 0x1e || buf[1] != 0x0f {
+			t.Fatalf("Unexpected chunk %q", buf[:n])
+		}
+		if chunks == nil {
+			count = int(buf[11])
+			chunks = make([][]byte, 0, count)
+		}
+		if int(buf[10]) != len(chunks) {
+			t.Fatalf("Chunk %d out of order", buf[10])
+		}
+		chunks = append(chunks, append([]byte{}, buf[12:n]...))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.NewDecoder(zr).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m["version"] != "1.1" || m["host"] != "node1" || m["level"] != 4.0 ||
+		m["_subsystem"] != "dcp" || m["_vb"] != 12.0 || m["_id_"] != "x" ||
+		m["_doc_key"] != "<ud>k1</ud>" || m["_line"] == nil ||
+		!strings.HasPrefix(m["short_message"].(string), "stream closed: xxx") {
+		t.Errorf("Unexpected GELF message %v", m)
+	}
+}
+
+func TestGELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	msgs := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			msgs <- strings.TrimSuffix(msg, "\x00")
+		}
+	}()
+
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	g, err := l.AddGELFOutput(GELFOptions{Address: ln.Addr().String(), Network: "tcp", Host: "h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	l.Errorf("disk full")
+	l.Log("recovered")
+
+	for _, exp := range []string{`"short_message":"disk full"`, `"short_message":"recovered"`} {
+		select {
+		case msg := <-msgs:
+			if !strings.Contains(msg, exp) {
+				t.Errorf("Expected %s in %s", exp, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+	if err := g.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGELFTCPStalled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			// Accept connections but never read from them.
+			if _, err := ln.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+
+	l := New(WithOutput(io.Discard))
+	g, err := l.AddGELFOutput(GELFOptions{
+		Address: ln.Addr().String(),
+		Network: "tcp",
+		Timeout: 50 * time.Millisecond,
+		Batch:   BatchOptions{FlushInterval: time.Hour, MaxRetries: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	big := strings.Repeat("x", 64<<10)
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		l.Log("%s", big)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Logging was held up by the stalled input for %v", d)
+	}
+	if err := g.Flush(); err == nil {
+		t.Error("Expected the write to time out")
+	}
+}
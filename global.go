@@ -0,0 +1,68 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"os"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Sets the default logger's global fields; see Logger.SetGlobalFields.
+func SetGlobalFields(fields Fields) {
+	std.SetGlobalFields(fields)
+}
+
+// Sets whether the default logger's text outputs show its global fields.
+func SetGlobalFieldsInText(enabled bool) {
+	std.SetGlobalFieldsInText(enabled)
+}
+
+// Returns fields identifying this process, "host" and "pid", for
+// SetGlobalFields.
+func HostFields() Fields {
+	host, _ := os.Hostname()
+	return Fields{"host": host, "pid": os.Getpid()}
+}
+
+// Sets fields, such as the host name, service name or version, which are
+// attached to every entry logged by l and the Loggers derived from it,
+// ahead of their own fields, so that aggregated logs show their source.
+// They appear in the structured outputs and are passed to hooks; text
+// outputs only show them with SetGlobalFieldsInText. This replaces any
+// global fields set before; pass nil to remove them.
+func (l *Logger) SetGlobalFields(fields Fields) {
+	var p unsafe.Pointer
+	if len(fields) > 0 {
+		global := appendKeyvals(nil, []interface{}{fields})
+		p = unsafe.Pointer(&global)
+	}
+	atomic.StorePointer(&l.globalFields, p)
+}
+
+// Thread-safe API for setting whether text outputs show the global fields,
+// in brackets before each message. (default false)
+func (l *Logger) SetGlobalFieldsInText(enabled bool) {
+	atomic.StoreInt32(&l.globalsInText, btoi(enabled))
+}
+
+// Returns the fields to attach to a new entry, and how many of them are
+// global.
+func (l *Logger) entryFields() ([]Field, int) {
+	gp := atomic.LoadPointer(&l.globalFields)
+	if gp == nil {
+		return l.fields[:len(l.fields):len(l.fields)], 0 // Hooks may append
+	}
+	global := *(*[]Field)(gp)
+	fields := global[:len(global):len(global)]
+	if len(l.fields) > 0 {
+		fields = append(fields, l.fields...)
+	}
+	return fields, len(global)
+}
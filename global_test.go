@@ -0,0 +1,53 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGlobalFields(t *testing.T) {
+	text, js := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(text), WithFlags(0), WithIncludeCaller(false))
+	l.AddOutput(js, SinkOptions{Format: FormatJSON})
+	DisableColor()
+
+	l.SetGlobalFields(Fields{"service": "indexer", "pid": 42})
+	l.With("vb", 7).Warnf("slow")
+	l.SetGlobalFieldsInText(true)
+	l.Log("plain")
+	l.SetGlobalFields(nil)
+	l.Log("none")
+
+	if got := text.String(); got != "WARN: slow vb=7\n[pid=42 service=indexer] plain\nnone\n" {
+		t.Errorf("Unexpected text output %q", got)
+	}
+	lines := strings.Split(js.String(), "\n")
+	if !strings.HasSuffix(lines[0], `"msg":"slow","pid":42,"service":"indexer","vb":7}`) ||
+		!strings.HasSuffix(lines[1], `"msg":"plain","pid":42,"service":"indexer"}`) ||
+		!strings.HasSuffix(lines[2], `"msg":"none"}`) {
+		t.Errorf("Unexpected JSON output %q", js.String())
+	}
+}
+
+func TestHostFields(t *testing.T) {
+	f := HostFields()
+	if f["pid"] != os.Getpid() || f["host"] == "" {
+		t.Errorf("Unexpected host fields %v", f)
+	}
+	l := New(WithOutput(io.Discard))
+	l.SetGlobalFields(f)
+	if fields, n := l.entryFields(); n != 2 || len(fields) != 2 {
+		t.Errorf("Expected 2 global fields, got %v", fields)
+	}
+}
@@ -0,0 +1,70 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+)
+
+// Sets whether the default logger includes goroutine IDs; see
+// Logger.SetGoroutineIDs.
+func SetGoroutineIDs(enabled bool) {
+	std.SetGoroutineIDs(enabled)
+}
+
+// Sets whether the default logger includes pprof labels; see
+// Logger.SetPprofLabels.
+func SetPprofLabels(enabled bool) {
+	std.SetPprofLabels(enabled)
+}
+
+// Thread-safe API for setting whether each entry has a "goroutine" field
+// holding the ID of the goroutine which logged it, so that the interleaved
+// messages of concurrent workers can be told apart. Finding the ID costs
+// about a microsecond per entry. (default false)
+func (l *Logger) SetGoroutineIDs(enabled bool) {
+	atomic.StoreInt32(&l.goroutineIDs, btoi(enabled))
+}
+
+// Thread-safe API for setting whether the Ctx functions and WithContext add
+// the pprof labels carried by the context (see pprof.Do) as fields, so that
+// messages carry the same labels as the profiles. (default false)
+func (l *Logger) SetPprofLabels(enabled bool) {
+	atomic.StoreInt32(&l.pprofLabels, btoi(enabled))
+}
+
+// Returns the current goroutine's ID, parsed from the header of its stack
+// trace, "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// Appends the pprof labels carried by ctx to fields, if they are enabled.
+func (l *Logger) appendPprofLabels(ctx context.Context, fields []Field) []Field {
+	if atomic.LoadInt32(&l.pprofLabels) == 0 {
+		return fields
+	}
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		fields = append(fields, Field{Key: key, Value: value})
+		return true
+	})
+	return fields
+}
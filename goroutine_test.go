@@ -0,0 +1,53 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestGoroutineIDs(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0)).With("vb", 7)
+	l.SetGoroutineIDs(true)
+	l.Log("first")
+	id := goroutineID()
+	done := make(chan uint64)
+	go func() {
+		l.Log("second")
+		done <- goroutineID()
+	}()
+	other := <-done
+	l.SetGoroutineIDs(false)
+	l.Log("third")
+
+	expect := fmt.Sprintf("first vb=7 goroutine=%d\nsecond vb=7 goroutine=%d\nthird vb=7\n", id, other)
+	if id == 0 || id == other || b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestPprofLabels(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	ctx := ContextWith(context.Background(), "reqID", 42)
+	pprof.Do(ctx, pprof.Labels("worker", "dcp-3"), func(ctx context.Context) {
+		l.LogCtx(ctx, "without")
+		l.SetPprofLabels(true)
+		l.LogCtx(ctx, "with")
+	})
+
+	if b.String() != "without reqID=42\nwith reqID=42 worker=dcp-3\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
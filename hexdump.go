@@ -0,0 +1,55 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// The number of bytes of data shown by Dumpf. The rest are summarized.
+const dumpLimit = 4096
+
+// Logs a hex dump of data at LevelDebug, but only if the key is enabled;
+// see Logger.Dumpf.
+func Dumpf(key, label string, data []byte) {
+	if std.wantsKey(LevelDebug, key) {
+		std.doTof(LevelDebug, key, "%s", formatDump(label, data))
+	}
+}
+
+// Logs a hex dump of data at LevelDebug, but only if the key is enabled, for
+// debugging binary protocols such as DCP and memcached. Each line shows the
+// offset, 16 bytes in hex and their printable ASCII characters, as
+// "hexdump -C" does. Only the first 4KB of data are shown.
+func (l *Logger) Dumpf(key, label string, data []byte) {
+	if l.wantsKey(LevelDebug, key) {
+		l.doTof(LevelDebug, key, "%s", formatDump(label, data))
+	}
+}
+
+func formatDump(label string, data []byte) string {
+	var b strings.Builder
+	b.WriteString(label)
+	b.WriteString(" (")
+	b.WriteString(strconv.Itoa(len(data)))
+	b.WriteString(" bytes):\n")
+	shown := data
+	if len(shown) > dumpLimit {
+		shown = shown[:dumpLimit]
+	}
+	b.WriteString(strings.TrimSuffix(hex.Dump(shown), "\n"))
+	if len(data) > len(shown) {
+		b.WriteString("\n… ")
+		b.WriteString(strconv.Itoa(len(data) - len(shown)))
+		b.WriteString(" more bytes")
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDumpf(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false), WithLevel(LevelDebug))
+	packet := []byte("\x80\x57\x00\x05\x00\x00\x00\x00mutation")
+	l.Dumpf("DCP", "packet", packet) // Key not enabled
+	l.EnableKey("DCP")
+	l.Dumpf("DCP", "packet", packet)
+
+	expect := "DEBU: DCP: packet (16 bytes):\n" +
+		"00000000  80 57 00 05 00 00 00 00  6d 75 74 61 74 69 6f 6e  |.W......mutation|\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+
+	b.Reset()
+	l.Dumpf("DCP", "body", make([]byte, dumpLimit+100))
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2+dumpLimit/16 || lines[len(lines)-1] != "… 100 more bytes" {
+		t.Errorf("Unexpected output %d lines, ending %q", len(lines), lines[len(lines)-1])
+	}
+}
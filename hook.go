@@ -0,0 +1,73 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// A Hook is passed each entry before it is logged, and returns the entry to
+// log in its place, or false to drop it. Hooks may modify or add to the
+// entry's fields, and may pass it on elsewhere. They are called in the order
+// they were added, each with the entry returned by the one before.
+type Hook func(Entry) (Entry, bool)
+
+// Adds a hook to the default logger; see Logger.AddHook.
+func AddHook(h Hook) {
+	std.AddHook(h)
+}
+
+// Adds a hook, called for each entry which passes the logger's level and key
+// filters, or is kept by EnableRecentEntries. Any number of hooks may be
+// added, for instance one prefixing messages and another counting them. If
+// one drops the entry, the later hooks aren't called and it is neither
+// written nor kept. If the hooks change the entry's level, it is checked
+// against the logger's level again. Hooks are shared with the Loggers
+// derived from l.
+func (l *Logger) AddHook(h Hook) {
+	for {
+		ohp := atomic.LoadPointer(&l.hooks)
+		var newh []Hook
+		if ohp != nil {
+			newh = append(newh, *(*[]Hook)(ohp)...)
+		}
+		newh = append(newh, h)
+		if atomic.CompareAndSwapPointer(&l.hooks, ohp, unsafe.Pointer(&newh)) {
+			return
+		}
+	}
+}
+
+// Removes all of the logger's hooks.
+func (l *Logger) ClearHooks() {
+	atomic.StorePointer(&l.hooks, nil)
+	if atomic.SwapInt32(&l.rules, 0) != 0 {
+		l.updateFloor()
+	}
+}
+
+// Passes the entry through the hooks. Returns false if it was dropped.
+func (l *Logger) runHooks(e *Entry) bool {
+	hp := atomic.LoadPointer(&l.hooks)
+	if hp == nil {
+		return true
+	}
+	level := e.Level
+	for _, h := range *(*[]Hook)(hp) {
+		var ok bool
+		if *e, ok = h(*e); !ok {
+			return false
+		}
+	}
+	if e.Level != level {
+		e.prefix, e.color = levelPrefix(e.Level), fgRed
+	}
+	return true
+}
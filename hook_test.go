@@ -0,0 +1,95 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+
+	var seen []Entry
+	l.AddHook(func(e Entry) (Entry, bool) {
+		seen = append(seen, e)
+		return e, !strings.Contains(e.Message, "secret")
+	})
+	l.AddHook(func(e Entry) (Entry, bool) {
+		e.Fields = append(e.Fields, Field{Key: "node", Value: "n1"})
+		if e.Key == "dcp" {
+			e.Level = LevelWarning
+		}
+		return e, true
+	})
+
+	db := l.With("bucket", "default")
+	db.Log("opened")
+	db.Log("secret")
+	l.Named("dcp").Log("rollback")
+	db.Log("closed")
+
+	exp := []string{
+		"opened bucket=default node=n1",
+		"WARN: dcp: rollback node=n1",
+		"closed bucket=default node=n1",
+	}
+	if got := strings.TrimSpace(b.String()); got != strings.Join(exp, "\n") {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+	if len(seen) != 4 || seen[2].Key != "dcp" || seen[2].Level != LevelNormal {
+		t.Errorf("Unexpected entries passed to the first hook: %+v", seen)
+	}
+
+	b.Reset()
+	l.ClearHooks()
+	l.Log("plain")
+	if got := b.String(); got != "plain\n" {
+		t.Errorf("Unexpected output after ClearHooks %q", got)
+	}
+}
+
+func TestHookChain(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false), WithKeys("CRUD"))
+	DisableColor()
+
+	var counted []string
+	l.AddHook(func(e Entry) (Entry, bool) { // Prefixing, as Sync Gateway does
+		e.Message = "c:#042 " + e.Message
+		return e, true
+	})
+	l.AddHook(func(e Entry) (Entry, bool) { // Suppression
+		if strings.Contains(e.Message, "chatty") {
+			e.Level = LevelDebug
+		}
+		return e, !strings.Contains(e.Message, "password")
+	})
+	l.AddHook(func(e Entry) (Entry, bool) { // Metrics
+		counted = append(counted, e.Message)
+		return e, true
+	})
+
+	l.To("CRUD", "doc updated")
+	l.Log("password is hunter2")
+	l.Warnf("chatty warning")
+	l.Debugf("below the level")
+	l.Warnf("slow query")
+
+	exp := "CRUD: c:#042 doc updated\nWARN: c:#042 slow query\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+	if len(counted) != 3 || counted[1] != "c:#042 chatty warning" {
+		t.Errorf("Unexpected entries counted %q", counted)
+	}
+}
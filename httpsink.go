@@ -0,0 +1,301 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPBody selects how an HTTP output encodes a batch of entries.
+type HTTPBody int
+
+const (
+	HTTPBodyNDJSON      = HTTPBody(iota) // One JSON entry per line
+	HTTPBodyElasticBulk                  // An Elasticsearch _bulk request indexing each entry
+	HTTPBodyLoki                         // A Grafana Loki push request, with the entries as lines
+	HTTPBodySplunkHEC                    // Splunk HTTP Event Collector events
+)
+
+// HTTPOptions configure an output posting batches of entries to an HTTP
+// endpoint.
+type HTTPOptions struct {
+	Endpoint string            // e.g. "https://es:9200/logs/_bulk"
+	Headers  map[string]string // Added to each request, e.g. for authentication
+	Body     HTTPBody
+	Labels   map[string]string // Loki stream labels (default job=clog)
+	Gzip     bool              // Compress request bodies
+	Level    LogLevel          // Minimum level sent
+	Batch    BatchOptions
+	Circuit  CircuitOptions
+	Client   *http.Client  // Default http.DefaultClient
+	Timeout  time.Duration // Limit on each request, including reading the response (default 30s)
+
+	// If set, batches which can't be sent are saved as files in this
+	// directory, and sent once the endpoint is reachable again.
+	SpoolDir   string
+	SpoolMaxMB int // Oldest spooled batches are removed beyond this (default 100)
+}
+
+// An HTTPWriter posts log entries to an HTTP endpoint in batches.
+type HTTPWriter struct {
+	rejected uint64 // Batches dropped because the endpoint rejected them, accessed atomically
+
+	opts  HTTPOptions
+	batch *batcher
+
+	spoolMu sync.Mutex // Serializes use of the spool directory
+}
+
+// Posts the default logger's entries to an HTTP endpoint; see
+// Logger.AddHTTPOutput.
+func AddHTTPOutput(opts HTTPOptions) (*HTTPWriter, error) {
+	return std.AddHTTPOutput(opts)
+}
+
+// Adds an output posting entries to an HTTP endpoint, such as Elasticsearch,
+// Loki or Splunk. Entries are sent in batches from a background goroutine,
+// and a batch which can't be sent is retried with backoff, then spooled if
+// there is a SpoolDir, or else dropped; Err returns the error. Only transport
+// errors and 429 or 5xx statuses are retried: a batch rejected with any other
+// status is dropped and counted by Rejected. Close the writer to send any
+// outstanding entries.
+func (l *Logger) AddHTTPOutput(opts HTTPOptions) (*HTTPWriter, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("clog: HTTP output has no endpoint")
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if len(opts.Labels) == 0 {
+		opts.Labels = map[string]string{"job": "clog"}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.SpoolMaxMB <= 0 {
+		opts.SpoolMaxMB = 100
+	}
+	if opts.SpoolDir != "" {
+		if err := os.MkdirAll(opts.SpoolDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	h := &HTTPWriter{opts: opts}
+	h.batch = newBatcher(opts.Batch, newCircuit("http "+opts.Endpoint, opts.Circuit), h.send, h.spool)
+	l.addSink(&sink{level: opts.Level, ew: h})
+	return h, nil
+}
+
+// Sends any outstanding entries, returning the error from doing so.
+func (h *HTTPWriter) Flush() error {
+	return h.batch.Flush()
+}
+
+// Returns the error from the most recent send, if it failed.
+func (h *HTTPWriter) Err() error {
+	return h.batch.Err()
+}
+
+// Stops the background goroutine and sends any outstanding entries.
+func (h *HTTPWriter) Close() error {
+	return h.batch.Close()
+}
+
+// Returns the number of batches, including spooled ones, which were dropped
+// because the endpoint rejected them.
+func (h *HTTPWriter) Rejected() uint64 {
+	return atomic.LoadUint64(&h.rejected)
+}
+
+// Returns the number of entries dropped because too many were waiting to be
+// sent, or they were logged after Close.
+func (h *HTTPWriter) Dropped() uint64 {
+	return h.batch.Dropped()
+}
+
+func (h *HTTPWriter) health() SinkState {
+	return h.batch.circuit.health()
+}
+
+func (h *HTTPWriter) writeEntry(e *Entry) {
+	h.batch.add(batchRecord{time: e.Time, value: formatJSON(e)})
+}
+
+// Posts a batch, then any spooled batches.
+func (h *HTTPWriter) send(batch []batchRecord) error {
+	if err := h.post(h.encode(batch)); err != nil {
+		return err
+	}
+	if h.opts.SpoolDir != "" {
+		h.sendSpooled()
+	}
+	return nil
+}
+
+// Returns the request body for a batch, compressed if necessary.
+func (h *HTTPWriter) encode(batch []batchRecord) []byte {
+	b := &bytes.Buffer{}
+	switch h.opts.Body {
+	case HTTPBodyElasticBulk:
+		for _, r := range batch {
+			b.WriteString(`{"index":{}}` + "\n")
+			b.Write(r.value)
+			b.WriteByte('\n')
+		}
+	case HTTPBodyLoki:
+		b.WriteString(`{"streams":[{"stream":{`)
+		for _, k := range sortedKeys(h.opts.Labels) {
+			appendJSONString(b, k, h.opts.Labels[k])
+		}
+		b.WriteString(`},"values":[`)
+		for i, r := range batch {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(`["`)
+			b.WriteString(strconv.FormatInt(r.time.UnixNano(), 10))
+			b.WriteString(`","`)
+			writeJSONEscaped(b, string(r.value))
+			b.WriteString(`"]`)
+		}
+		b.WriteString("]}]}")
+	case HTTPBodySplunkHEC:
+		for _, r := range batch {
+			b.WriteString(`{"time":`)
+			b.WriteString(strconv.FormatFloat(float64(r.time.UnixMilli())/1000, 'f', 3, 64))
+			b.WriteString(`,"event":`)
+			b.Write(r.value)
+			b.WriteString("}\n")
+		}
+	default:
+		for _, r := range batch {
+			b.Write(r.value)
+			b.WriteByte('\n')
+		}
+	}
+	if !h.opts.Gzip {
+		return b.Bytes()
+	}
+	z := &bytes.Buffer{}
+	gz := gzip.NewWriter(z)
+	gz.Write(b.Bytes())
+	gz.Close()
+	return z.Bytes()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (h *HTTPWriter) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", h.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if h.opts.Body == HTTPBodyLoki {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	if h.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range h.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf("clog: HTTP output to %s: %s", h.opts.Endpoint, resp.Status)
+	if resp.StatusCode/100 != 5 && resp.StatusCode != http.StatusTooManyRequests {
+		err = permanentError{err}
+	}
+	return err
+}
+
+// Saves a batch which couldn't be sent to the spool directory, removing the
+// oldest spooled batches if the spool has grown too large. A rejected batch
+// is counted and dropped instead.
+func (h *HTTPWriter) spool(batch []batchRecord, err error) {
+	if isPermanent(err) {
+		atomic.AddUint64(&h.rejected, 1)
+		return
+	}
+	if h.opts.SpoolDir == "" {
+		return
+	}
+	h.spoolMu.Lock()
+	defer h.spoolMu.Unlock()
+	name := fmt.Sprintf("batch-%020d.spool", time.Now().UnixNano())
+	if err := os.WriteFile(filepath.Join(h.opts.SpoolDir, name), h.encode(batch), 0644); err != nil {
+		return
+	}
+	files := h.spooled()
+	var total int64
+	max := int64(h.opts.SpoolMaxMB) * 1024 * 1024
+	for i := len(files) - 1; i >= 0; i-- {
+		if info, err := os.Stat(files[i]); err == nil {
+			if total += info.Size(); total > max {
+				os.Remove(files[i])
+			}
+		}
+	}
+}
+
+// Lists the spooled batches, oldest first.
+func (h *HTTPWriter) spooled() []string {
+	files, _ := filepath.Glob(filepath.Join(h.opts.SpoolDir, "batch-*.spool"))
+	sort.Strings(files)
+	return files
+}
+
+// Posts the spooled batches, oldest first, removing each once it is sent or
+// rejected. Stops at the first other failure.
+func (h *HTTPWriter) sendSpooled() {
+	h.spoolMu.Lock()
+	defer h.spoolMu.Unlock()
+	for _, name := range h.spooled() {
+		body, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		if err := h.post(body); isPermanent(err) {
+			atomic.AddUint64(&h.rejected, 1)
+		} else if err != nil {
+			return
+		}
+		os.Remove(name)
+	}
+}
@@ -0,0 +1,188 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPOutputSpool(t *testing.T) {
+	var mu sync.Mutex
+	up := false
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "gzip" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected headers %v", r.Header)
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := io.ReadAll(gz)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	l := New(WithOutput(io.Discard), WithFlags(0))
+	h, err := l.AddHTTPOutput(HTTPOptions{
+		Endpoint: srv.URL,
+		Body:     HTTPBodyElasticBulk,
+		Gzip:     true,
+		Batch:    BatchOptions{FlushInterval: time.Hour, MaxRetries: 1, RetryBackoff: time.Millisecond},
+		SpoolDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log("first")
+	if err := h.Flush(); err == nil {
+		t.Fatal("Expected the endpoint to be down")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Fatalf("Expected one spooled batch, got %v", files)
+	}
+
+	mu.Lock()
+	up = true
+	mu.Unlock()
+	l.Log("second")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("Expected the spool to be emptied, got %v", files)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[0], `"msg":"second"`) ||
+		!strings.HasPrefix(bodies[1], `{"index":{}}`+"\n"+`{"time":`) || !strings.Contains(bodies[1], `"msg":"first"`) {
+		t.Errorf("Unexpected requests %q", bodies)
+	}
+}
+
+func TestHTTPOutputRejected(t *testing.T) {
+	var mu sync.Mutex
+	up := false
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(b))
+		switch {
+		case !up:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.Contains(string(b), "rejected"):
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	l := New(WithOutput(io.Discard), WithFlags(0))
+	h, err := l.AddHTTPOutput(HTTPOptions{
+		Endpoint: srv.URL,
+		Batch:    BatchOptions{FlushInterval: time.Hour, MaxRetries: 2, RetryBackoff: time.Millisecond},
+		SpoolDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l.Log("spooled then rejected")
+	if err := h.Flush(); err == nil || len(requests) != 3 {
+		t.Fatalf("Expected the batch to be retried then spooled, got %v after %d requests", err, len(requests))
+	}
+
+	mu.Lock()
+	up = true
+	requests = nil
+	mu.Unlock()
+	l.Log("rejected")
+	if err := h.Flush(); err == nil || len(requests) != 1 {
+		t.Errorf("Expected the batch to be rejected without retrying, got %v after %d requests", err, len(requests))
+	}
+	l.Log("accepted")
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("Expected rejected batches to be dropped, got %v", files)
+	}
+	if len(requests) != 3 || h.Rejected() != 2 {
+		t.Errorf("Expected 2 rejected batches, got %d after requests %q", h.Rejected(), requests)
+	}
+}
+
+func TestHTTPOutputTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	dir := t.TempDir()
+	l := New(WithOutput(io.Discard))
+	h, err := l.AddHTTPOutput(HTTPOptions{
+		Endpoint: srv.URL,
+		Timeout:  50 * time.Millisecond,
+		Batch:    BatchOptions{FlushInterval: time.Hour, MaxRetries: -1},
+		SpoolDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log("hung")
+	if err := h.Flush(); err == nil {
+		t.Error("Expected the request to time out")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("Expected the batch to be spooled, got %v", files)
+	}
+}
+
+func TestHTTPBodies(t *testing.T) {
+	when := time.Unix(1714557600, 123000000)
+	batch := []batchRecord{{time: when, value: []byte(`{"msg":"hi"}`)}}
+
+	h := &HTTPWriter{opts: HTTPOptions{Body: HTTPBodyLoki, Labels: map[string]string{"job": "clog", "app": "idx"}}}
+	var loki struct {
+		Streams []struct {
+			Stream map[string]string
+			Values [][2]string
+		}
+	}
+	if err := json.Unmarshal(h.encode(batch), &loki); err != nil {
+		t.Fatal(err)
+	}
+	if s := loki.Streams[0]; s.Stream["app"] != "idx" || s.Values[0] != [2]string{"1714557600123000000", `{"msg":"hi"}`} {
+		t.Errorf("Unexpected Loki request %+v", loki)
+	}
+
+	h.opts.Body = HTTPBodySplunkHEC
+	if body := string(h.encode(batch)); body != `{"time":1714557600.123,"event":{"msg":"hi"}}`+"\n" {
+		t.Errorf("Unexpected Splunk request %q", body)
+	}
+}
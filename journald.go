@@ -0,0 +1,112 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JournaldOptions configure an output writing to the systemd journal.
+type JournaldOptions struct {
+	Identifier string   // SYSLOG_IDENTIFIER (default the program name)
+	Level      LogLevel // Minimum level written
+	SocketPath string   // Default "/run/systemd/journal/socket"
+}
+
+// Writes the default logger's entries to the journal; see
+// Logger.AddJournaldOutput.
+func AddJournaldOutput(opts JournaldOptions) (*JournaldWriter, error) {
+	return std.AddJournaldOutput(opts)
+}
+
+// Syslog priorities, as used by the journal, by level.
+var journalPriorities = []int{
+	LevelTrace:    7, // debug
+	LevelDebug:    7, // debug
+	LevelNormal:   6, // info
+	LevelNotice:   5, // notice
+	LevelWarning:  4, // warning
+	LevelError:    3, // err
+	LevelCritical: 2, // crit
+	LevelPanic:    1, // alert
+}
+
+// Returns where the entry was logged, whether or not its Caller is shown.
+func (e *Entry) codeLocation() *Caller {
+	if e.Caller != nil {
+		return e.Caller
+	}
+	return e.origin
+}
+
+// Encodes an entry in the journal's native protocol.
+func formatJournal(e *Entry, identifier string) []byte {
+	priority := 6
+	if e.Level >= 0 && int(e.Level) < len(journalPriorities) {
+		priority = journalPriorities[e.Level]
+	}
+	var b bytes.Buffer
+	message := e.Message
+	if e.Stack != "" {
+		message += "\n" + e.Stack
+	}
+	appendJournal(&b, "MESSAGE", message)
+	appendJournal(&b, "PRIORITY", strconv.Itoa(priority))
+	if identifier != "" {
+		appendJournal(&b, "SYSLOG_IDENTIFIER", identifier)
+	}
+	if e.Key != "" {
+		appendJournal(&b, "CLOG_KEY", e.Key)
+	}
+	if c := e.codeLocation(); c != nil {
+		appendJournal(&b, "CODE_FILE", c.File)
+		appendJournal(&b, "CODE_LINE", strconv.Itoa(c.Line))
+		appendJournal(&b, "CODE_FUNC", c.Function)
+	}
+	for _, f := range e.Fields {
+		appendJournal(&b, journalFieldName(f.Key), fmt.Sprint(f.Value))
+	}
+	return b.Bytes()
+}
+
+// Appends a variable to a journal message. Values containing newlines are
+// length-prefixed.
+func appendJournal(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		b.WriteByte('=')
+	} else {
+		b.WriteByte('\n')
+		binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	}
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// Returns the journal variable name for a field: upper case letters, digits
+// and underscores, not starting with an underscore or digit.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		}
+		return '_'
+	}, key)
+	if name == "" || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		name = "F" + name
+	}
+	return name
+}
@@ -0,0 +1,101 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build linux
+
+package clog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// A JournaldWriter writes log entries to the systemd journal using its
+// native protocol, so that they carry their priority and source location.
+type JournaldWriter struct {
+	opts JournaldOptions
+	conn *net.UnixConn
+	addr *net.UnixAddr
+
+	mu  sync.Mutex
+	err error // Last send error
+}
+
+// Adds an output writing entries to the systemd journal. Levels map to
+// journal priorities, so "journalctl -p err" shows errors and above; keys
+// are written as CLOG_KEY and fields as journal variables. Each entry's code
+// location is sent as CODE_FILE, CODE_LINE and CODE_FUNC, whether or not
+// callers are included in other outputs.
+func (l *Logger) AddJournaldOutput(opts JournaldOptions) (*JournaldWriter, error) {
+	if opts.SocketPath == "" {
+		opts.SocketPath = "/run/systemd/journal/socket"
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	if _, err := os.Stat(opts.SocketPath); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	j := &JournaldWriter{
+		opts: opts,
+		conn: conn,
+		addr: &net.UnixAddr{Name: opts.SocketPath, Net: "unixgram"},
+	}
+	atomic.StoreInt32(&l.needOrigin, 1) // For CODE_FILE and the like
+	l.addSink(&sink{level: opts.Level, ew: j})
+	return j, nil
+}
+
+// Returns the error from the most recent write, if it failed.
+func (j *JournaldWriter) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Closes the writer's socket.
+func (j *JournaldWriter) Close() error {
+	return j.conn.Close()
+}
+
+func (j *JournaldWriter) writeEntry(e *Entry) {
+	err := j.send(formatJournal(e, j.opts.Identifier))
+	j.mu.Lock()
+	j.err = err
+	j.mu.Unlock()
+}
+
+func (j *JournaldWriter) send(msg []byte) error {
+	_, err := j.conn.WriteToUnix(msg, j.addr)
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+	// Too large for a datagram: pass the message in an unlinked file.
+	f, err := os.CreateTemp("/dev/shm", "clog-journal-")
+	if err != nil {
+		if f, err = os.CreateTemp("", "clog-journal-"); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+	if _, err := f.Write(msg); err != nil {
+		return err
+	}
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), j.addr)
+	return err
+}
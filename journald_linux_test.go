@@ -0,0 +1,80 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build linux
+
+package clog
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournaldOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	journal, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	l := New(WithOutput(io.Discard))
+	j, err := l.AddJournaldOutput(JournaldOptions{Identifier: "test", SocketPath: path, Level: LevelWarning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	l.Log("not sent")
+	l.Errorf("disk full")
+
+	buf := make([]byte, 4096)
+	journal.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := journal.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "MESSAGE=disk full\nPRIORITY=3\nSYSLOG_IDENTIFIER=test\n") ||
+		!strings.Contains(msg, "CODE_FUNC=github.com/couchbase/clog.TestJournaldOutput\n") {
+		t.Errorf("Unexpected journal message %q", msg)
+	}
+	if err := j.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJournaldCodeLocation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	journal, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	j, err := l.AddJournaldOutput(JournaldOptions{Identifier: "test", SocketPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	l.Log("bucket created")
+
+	buf := make([]byte, 4096)
+	journal.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := journal.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(buf[:n]); !strings.Contains(msg, "CODE_FUNC=github.com/couchbase/clog.TestJournaldCodeLocation\n") {
+		t.Errorf("Expected the code location without callers, got %q", msg)
+	}
+}
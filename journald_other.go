@@ -0,0 +1,33 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build !linux
+
+package clog
+
+import "errors"
+
+// A JournaldWriter writes log entries to the systemd journal. The journal
+// is only available on Linux.
+type JournaldWriter struct{}
+
+// Adds an output writing entries to the systemd journal. It always fails
+// on this platform.
+func (l *Logger) AddJournaldOutput(opts JournaldOptions) (*JournaldWriter, error) {
+	return nil, errors.New("clog: journald is only supported on Linux")
+}
+
+// Returns nil.
+func (j *JournaldWriter) Err() error {
+	return nil
+}
+
+// Returns nil.
+func (j *JournaldWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatJournal(t *testing.T) {
+	e := &Entry{
+		Time:    time.Now(),
+		Level:   LevelError,
+		Key:     "dcp",
+		Message: "stream closed",
+		Fields:  []Field{{Key: "vb", Value: 12}, {Key: "_private", Value: "x"}, {Key: "reason", Value: "a\nb"}},
+		Caller:  &Caller{"main.run", "/src/main.go", 42},
+	}
+	exp := "MESSAGE=stream closed\nPRIORITY=3\nSYSLOG_IDENTIFIER=indexer\n" +
+		"CLOG_KEY=dcp\nCODE_FILE=/src/main.go\nCODE_LINE=42\nCODE_FUNC=main.run\n" +
+		"VB=12\nF_PRIVATE=x\nREASON\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"
+	if got := string(formatJournal(e, "indexer")); got != exp {
+		t.Errorf("Expected %q, got %q", exp, got)
+	}
+}
@@ -0,0 +1,141 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// A KafkaMessage is a log entry to be published.
+type KafkaMessage struct {
+	Topic string
+	Key   []byte // Nil unless partitioning by key or host
+	Value []byte // The entry, as JSON
+}
+
+// A KafkaProducer publishes batches of messages, wrapping a Kafka client
+// library such as sarama or franz-go, so that clog doesn't depend on one.
+// Produce should return once the messages are acknowledged.
+type KafkaProducer interface {
+	Produce(messages []KafkaMessage) error
+}
+
+// KafkaPartitioning selects the message key, which Kafka's partitioners use
+// to choose a partition.
+type KafkaPartitioning int
+
+const (
+	KafkaPartitionNone   = KafkaPartitioning(iota) // No key; messages are spread across partitions
+	KafkaPartitionByKey                            // The entry's key, keeping each subsystem's messages in order
+	KafkaPartitionByHost                           // The host name, keeping each host's messages in order
+)
+
+// KafkaOptions configure an output publishing entries to Kafka.
+type KafkaOptions struct {
+	Brokers   []string
+	Topic     string
+	Partition KafkaPartitioning
+	Level     LogLevel // Minimum level published
+	Batch     BatchOptions
+	Circuit   CircuitOptions
+
+	// Creates the producer for the brokers.
+	NewProducer func(brokers []string) (KafkaProducer, error)
+}
+
+// A KafkaWriter publishes log entries to a Kafka topic, as JSON.
+type KafkaWriter struct {
+	opts     KafkaOptions
+	producer KafkaProducer
+	host     []byte
+	batch    *batcher
+}
+
+// Publishes the default logger's entries to Kafka; see
+// Logger.AddKafkaOutput.
+func AddKafkaOutput(opts KafkaOptions) (*KafkaWriter, error) {
+	return std.AddKafkaOutput(opts)
+}
+
+// Adds an output publishing entries to a Kafka topic. Entries are sent in
+// batches from a background goroutine, and a batch which can't be sent is
+// retried with backoff, then dropped; Err returns the error. Close the
+// writer to send any outstanding entries.
+func (l *Logger) AddKafkaOutput(opts KafkaOptions) (*KafkaWriter, error) {
+	if opts.Topic == "" {
+		return nil, errors.New("clog: Kafka output has no topic")
+	}
+	if opts.NewProducer == nil {
+		return nil, errors.New("clog: Kafka output has no producer")
+	}
+	producer, err := opts.NewProducer(opts.Brokers)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	k := &KafkaWriter{opts: opts, producer: producer, host: []byte(host)}
+	k.batch = newBatcher(opts.Batch, newCircuit("kafka "+opts.Topic, opts.Circuit), k.produce, nil)
+	l.addSink(&sink{level: opts.Level, ew: k})
+	return k, nil
+}
+
+// Sends any outstanding entries, returning the error from doing so.
+func (k *KafkaWriter) Flush() error {
+	return k.batch.Flush()
+}
+
+// Returns the error from the most recent send, if it failed.
+func (k *KafkaWriter) Err() error {
+	return k.batch.Err()
+}
+
+// Stops the background goroutine and sends any outstanding entries. The
+// producer is closed too, if it is an io.Closer.
+func (k *KafkaWriter) Close() error {
+	err := k.batch.Close()
+	if c, ok := k.producer.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Returns the number of entries dropped because too many were waiting to be
+// sent, or they were logged after Close.
+func (k *KafkaWriter) Dropped() uint64 {
+	return k.batch.Dropped()
+}
+
+func (k *KafkaWriter) health() SinkState {
+	return k.batch.circuit.health()
+}
+
+func (k *KafkaWriter) writeEntry(e *Entry) {
+	r := batchRecord{time: e.Time, value: formatJSON(e)}
+	switch k.opts.Partition {
+	case KafkaPartitionByKey:
+		if e.Key != "" {
+			r.key = []byte(e.Key)
+		}
+	case KafkaPartitionByHost:
+		r.key = k.host
+	}
+	k.batch.add(r)
+}
+
+func (k *KafkaWriter) produce(batch []batchRecord) error {
+	messages := make([]KafkaMessage, len(batch))
+	for i, r := range batch {
+		messages[i] = KafkaMessage{Topic: k.opts.Topic, Key: r.key, Value: r.value}
+	}
+	return k.producer.Produce(messages)
+}
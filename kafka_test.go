@@ -0,0 +1,128 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeProducer struct {
+	mu       sync.Mutex
+	failures int // Number of calls to fail before succeeding
+	calls    int
+	messages []KafkaMessage
+	closed   bool
+}
+
+func (p *fakeProducer) Produce(messages []KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestKafkaOutput(t *testing.T) {
+	p := &fakeProducer{failures: 2}
+	var brokers []string
+	l := New(WithOutput(io.Discard), WithKeys("DCP"))
+	k, err := l.AddKafkaOutput(KafkaOptions{
+		Brokers:   []string{"kafka1:9092", "kafka2:9092"},
+		Topic:     "logs",
+		Partition: KafkaPartitionByKey,
+		Batch:     BatchOptions{FlushInterval: time.Hour, RetryBackoff: time.Millisecond},
+		NewProducer: func(b []string) (KafkaProducer, error) {
+			brokers = b
+			return p, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.To("DCP", "stream opened")
+	l.Warnf("slow")
+	if err := k.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(brokers, []string{"kafka1:9092", "kafka2:9092"}) {
+		t.Errorf("Unexpected brokers %v", brokers)
+	}
+	if p.calls != 3 || !p.closed || len(p.messages) != 2 {
+		t.Fatalf("Expected 2 messages after 2 retries, got %d calls, %+v", p.calls, p.messages)
+	}
+	var entry struct{ Msg string }
+	json.Unmarshal(p.messages[0].Value, &entry)
+	if m := p.messages[0]; m.Topic != "logs" || string(m.Key) != "DCP" || entry.Msg != "stream opened" {
+		t.Errorf("Unexpected message %q %q %s", m.Topic, m.Key, m.Value)
+	}
+	if p.messages[1].Key != nil {
+		t.Errorf("Expected no key for an entry without one, got %q", p.messages[1].Key)
+	}
+}
+
+func TestKafkaOutputDropsAfterRetries(t *testing.T) {
+	p := &fakeProducer{failures: 10}
+	l := New(WithOutput(io.Discard))
+	k, err := l.AddKafkaOutput(KafkaOptions{
+		Topic:       "logs",
+		Batch:       BatchOptions{FlushInterval: time.Hour, MaxRetries: 1, RetryBackoff: time.Millisecond},
+		NewProducer: func([]string) (KafkaProducer, error) { return p, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log("lost")
+	if err := k.Flush(); err == nil || k.Err() != err {
+		t.Errorf("Expected the send error, got %v", err)
+	}
+	if err := k.Flush(); err != nil || p.calls != 2 {
+		t.Errorf("Expected the batch to be dropped after 2 calls, got %d calls, error %v", p.calls, err)
+	}
+	k.Close()
+}
+
+func TestKafkaOutputDropsWhenFull(t *testing.T) {
+	p := &fakeProducer{}
+	l := New(WithOutput(io.Discard))
+	k, err := l.AddKafkaOutput(KafkaOptions{
+		Topic:       "logs",
+		Batch:       BatchOptions{FlushInterval: time.Hour, BatchSize: 10, MaxPending: 2},
+		NewProducer: func([]string) (KafkaProducer, error) { return p, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log("first")
+	l.Log("second")
+	l.Log("overflow")
+	if err := k.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Log("after close")
+	if err := k.Flush(); err != nil || len(p.messages) != 2 || k.Dropped() != 2 {
+		t.Errorf("Expected 2 messages sent and 2 dropped, got %d sent, %d dropped, error %v",
+			len(p.messages), k.Dropped(), err)
+	}
+}
@@ -0,0 +1,209 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Bits recorded for each key in a keyMap.
+const (
+	keySelf     = 1 << iota // The key itself is listed, e.g. "rest"
+	keyChildren             // Its children are listed, e.g. "rest.*"
+)
+
+// A set of keys. Keys map to their keySelf/keyChildren bits, so that "rest"
+// and "rest.*" share an entry, and each update touches only its own entry
+// rather than copying the whole set.
+type keyMap struct {
+	m sync.Map // string -> int
+}
+
+// The set of enabled To() keys. If all is set, every key is enabled except
+// those excluded, unless a more specific key is included.
+type keySet struct {
+	included keyMap
+	excluded keyMap
+	all      int32    // 0 or 1, accessed atomically
+	known    sync.Map // Keys which have been checked, for KnownKeys
+	numKnown int32    // Entries in known, accessed atomically
+}
+
+// Keys recorded for KnownKeys, beyond which new ones are ignored, so that
+// keys built from data can't grow the set without limit.
+const maxKnownKeys = 1024
+
+// Returns the default logger's enabled keys; see Logger.EnabledKeys.
+func EnabledKeys() []string {
+	return std.EnabledKeys()
+}
+
+// Returns the keys the default logger has logged to; see Logger.KnownKeys.
+func KnownKeys() []string {
+	return std.KnownKeys()
+}
+
+// Returns the enabled keys, sorted. If all keys have been enabled, they are
+// followed by "*" and the keys it excludes, prefixed with "-".
+func (l *Logger) EnabledKeys() []string {
+	return l.keys.list()
+}
+
+// Returns the keys which have been logged to, or checked with KeyEnabled,
+// whether or not they are enabled or their messages pass the level, and the
+// names of Named loggers, sorted.
+// Only the first 1024 keys are recorded.
+func (l *Logger) KnownKeys() []string {
+	keys := []string{}
+	l.keys.known.Range(func(k, _ interface{}) bool {
+		keys = append(keys, k.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Reports whether key enables every key.
+func isAllKey(key string) bool {
+	return key == "*" || key == "all"
+}
+
+func (s *keySet) enable(key string) {
+	if isAllKey(key) {
+		s.excluded.m.Clear() // Later settings override earlier ones
+		atomic.StoreInt32(&s.all, 1)
+		return
+	}
+	s.excluded.remove(key)
+	s.included.add(key)
+}
+
+func (s *keySet) disable(key string) {
+	if isAllKey(key) {
+		atomic.StoreInt32(&s.all, 0)
+		return
+	}
+	s.included.remove(key)
+	s.excluded.add(key)
+}
+
+// Records a key for KnownKeys, unless maxKnownKeys have been. It doesn't
+// allocate unless the key is recorded.
+func (s *keySet) note(key string) {
+	if _, ok := s.known.Load(key); ok || atomic.LoadInt32(&s.numKnown) >= maxKnownKeys {
+		return
+	}
+	if _, loaded := s.known.LoadOrStore(key, struct{}{}); !loaded {
+		atomic.AddInt32(&s.numKnown, 1)
+	}
+}
+
+// Reports whether key is enabled. It doesn't allocate.
+func (s *keySet) enabled(key string) bool {
+	if atomic.LoadInt32(&s.all) == 0 {
+		return s.included.match(key) >= 0
+	}
+	inc, exc := s.included.match(key), s.excluded.match(key)
+	return exc < 0 || (inc >= 0 && inc <= exc)
+}
+
+// Replaces the enabled keys. Keys prefixed with "-" or "!" are disabled.
+// Concurrent readers may briefly see a mixture of the old and new keys.
+func (s *keySet) replace(keys []string) {
+	atomic.StoreInt32(&s.all, 0)
+	s.included.m.Clear()
+	s.excluded.m.Clear()
+	for _, k := range keys {
+		if strings.HasPrefix(k, "-") || strings.HasPrefix(k, "!") {
+			s.disable(k[1:])
+		} else {
+			s.enable(k)
+		}
+	}
+}
+
+// Returns the enabled keys, sorted, followed by "*" and the keys it excludes
+// (prefixed with "-") if all keys are enabled.
+func (s *keySet) list() []string {
+	keys := s.included.list("")
+	if atomic.LoadInt32(&s.all) != 0 {
+		keys = append(keys, "*")
+		keys = append(keys, s.excluded.list("-")...)
+	}
+	return keys
+}
+
+func splitWildcard(key string) (string, int) {
+	if strings.HasSuffix(key, ".*") {
+		return key[:len(key)-2], keyChildren
+	}
+	return key, keySelf
+}
+
+func (km *keyMap) add(key string) {
+	key, bit := splitWildcard(key)
+	for {
+		old, loaded := km.m.LoadOrStore(key, bit)
+		if !loaded || old.(int)&bit != 0 || km.m.CompareAndSwap(key, old, old.(int)|bit) {
+			return
+		}
+	}
+}
+
+func (km *keyMap) remove(key string) {
+	key, bit := splitWildcard(key)
+	for {
+		old, ok := km.m.Load(key)
+		if !ok || old.(int)&bit == 0 {
+			return
+		}
+		if bits := old.(int) &^ bit; bits == 0 {
+			if km.m.CompareAndDelete(key, old) {
+				return
+			}
+		} else if km.m.CompareAndSwap(key, old, bits) {
+			return
+		}
+	}
+}
+
+// Returns how many dotted levels above key the nearest listed key is: 0 for
+// the key itself, 1 for its parent and so on, or -1 if none is listed.
+func (km *keyMap) match(key string) int {
+	if bits, ok := km.m.Load(key); ok && bits.(int)&keySelf != 0 {
+		return 0
+	}
+	up := 1
+	for i := strings.LastIndexByte(key, '.'); i > 0; i = strings.LastIndexByte(key[:i], '.') {
+		if _, ok := km.m.Load(key[:i]); ok {
+			return up
+		}
+		up++
+	}
+	return -1
+}
+
+// Returns the listed keys, sorted, with the given prefix.
+func (km *keyMap) list(prefix string) []string {
+	keys := []string{}
+	km.m.Range(func(k, bits interface{}) bool {
+		if bits.(int)&keySelf != 0 {
+			keys = append(keys, prefix+k.(string))
+		}
+		if bits.(int)&keyChildren != 0 {
+			keys = append(keys, prefix+k.(string)+".*")
+		}
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
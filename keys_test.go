@@ -0,0 +1,141 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestKeySet(t *testing.T) {
+	var s keySet
+	s.enable("rest")
+	s.enable("rest.*")
+	s.enable("dcp")
+	s.disable("rest")
+	if s.enabled("rest") || !s.enabled("rest.auth") || !s.enabled("dcp.stream") {
+		t.Errorf("Unexpected keys %v", s.list())
+	}
+	if exp := []string{"dcp", "rest.*"}; !reflect.DeepEqual(s.list(), exp) {
+		t.Errorf("Expected %v, got %v", exp, s.list())
+	}
+	s.disable("rest.*")
+	s.disable("xdcr")
+	if s.enabled("rest.auth") {
+		t.Errorf("Unexpected keys %v", s.list())
+	}
+	s.replace([]string{"xdcr"})
+	if exp := []string{"xdcr"}; !reflect.DeepEqual(s.list(), exp) {
+		t.Errorf("Expected %v, got %v", exp, s.list())
+	}
+}
+
+func TestKeySetConcurrent(t *testing.T) {
+	var s keySet
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.enable(fmt.Sprintf("k%d.%d", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := len(s.list()); n != 800 {
+		t.Errorf("Expected 800 keys, got %d", n)
+	}
+}
+
+func TestKeyEnabledAllocs(t *testing.T) {
+	requireNoRace(t)
+	var s keySet
+	s.enable("rest.*")
+	allocs := testing.AllocsPerRun(100, func() {
+		s.enabled("rest.auth.session")
+		s.enabled("dcp.stream")
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestKeySetAll(t *testing.T) {
+	var s keySet
+	s.replace([]string{"all", "-dcp", "!xdcr.*", "dcp.stream"})
+	for key, exp := range map[string]bool{
+		"rest":        true,
+		"rest.auth":   true,
+		"dcp":         false,
+		"dcp.conn":    false,
+		"dcp.stream":  true,
+		"xdcr":        true,
+		"xdcr.pipe.1": false,
+	} {
+		if s.enabled(key) != exp {
+			t.Errorf("Expected KeyEnabled(%q) to be %v", key, exp)
+		}
+	}
+	if exp := []string{"dcp.stream", "*", "-dcp", "-xdcr.*"}; !reflect.DeepEqual(s.list(), exp) {
+		t.Errorf("Expected %v, got %v", exp, s.list())
+	}
+	s.disable("*")
+	if s.enabled("rest") || !s.enabled("dcp.stream") {
+		t.Errorf("Unexpected keys after disabling all: %v", s.list())
+	}
+}
+
+func TestParseLogFlagsNegation(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	l.ParseLogFlag("dcp,xdcr,-dcp")
+	if l.KeyEnabled("dcp") || !l.KeyEnabled("xdcr") {
+		t.Errorf("Expected only xdcr, got %v", l.EnabledKeys())
+	}
+	l.ParseLogFlag("*,!xdcr")
+	if !l.KeyEnabled("dcp") || l.KeyEnabled("xdcr") {
+		t.Errorf("Expected all but xdcr, got %v", l.EnabledKeys())
+	}
+}
+
+func TestKnownKeys(t *testing.T) {
+	l := New(WithOutput(io.Discard), WithKeys("dcp"))
+	l.To("dcp", "enabled")
+	l.To("xdcr", "disabled")
+	l.Named("rest").Named("auth")
+	// Keys filtered out by the level are known too.
+	l.Tof(LevelDebug, "gsi", "debug")
+	l.SetLevel(LevelError)
+	l.WarnTo("fts", "warning")
+	l.Output(LevelNormal, "n1ql", 0, "message")
+	if exp := []string{"dcp"}; !reflect.DeepEqual(l.EnabledKeys(), exp) {
+		t.Errorf("Expected enabled keys %v, got %v", exp, l.EnabledKeys())
+	}
+	if exp := []string{"dcp", "fts", "gsi", "n1ql", "rest", "rest.auth", "xdcr"}; !reflect.DeepEqual(l.KnownKeys(), exp) {
+		t.Errorf("Expected known keys %v, got %v", exp, l.KnownKeys())
+	}
+	allocs := testing.AllocsPerRun(100, func() { l.KeyEnabled("xdcr") })
+	if allocs != 0 {
+		t.Errorf("Expected no allocations for a known key, got %v", allocs)
+	}
+}
+
+func TestKnownKeysLimit(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	for i := 0; i < maxKnownKeys+10; i++ {
+		l.KeyEnabled("doc" + strconv.Itoa(i))
+	}
+	if n := len(l.KnownKeys()); n != maxKnownKeys {
+		t.Errorf("Expected %d known keys, got %d", maxKnownKeys, n)
+	}
+}
@@ -0,0 +1,91 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// KeyStat counts the messages logged with a key, as returned by KeyStats.
+type KeyStat struct {
+	Key        string
+	Messages   int64     // Messages written
+	Bytes      int64     // Total length of the messages written, excluding fields
+	Suppressed int64     // Messages dropped by sampling, rate limiting, deduplication or drop filters
+	Last       time.Time // Time of the last message written
+}
+
+type keyCounters struct {
+	messages, bytes, suppressed atomic.Int64
+	last                        atomic.Int64 // UnixNano
+}
+
+// Returns the default logger's per-key statistics; see Logger.KeyStats.
+func KeyStats() []KeyStat {
+	return std.KeyStats()
+}
+
+// Returns counts of the messages logged with each key by l and the Loggers
+// derived from it (including the names of Named Loggers), sorted by key, to
+// show which subsystems are producing the most output. Messages to disabled
+// keys aren't counted.
+func (l *Logger) KeyStats() []KeyStat {
+	var stats []KeyStat
+	l.keyStats.Range(func(k, v interface{}) bool {
+		c := v.(*keyCounters)
+		s := KeyStat{
+			Key:        k.(string),
+			Messages:   c.messages.Load(),
+			Bytes:      c.bytes.Load(),
+			Suppressed: c.suppressed.Load(),
+		}
+		if last := c.last.Load(); last != 0 {
+			s.Last = time.Unix(0, last)
+		}
+		stats = append(stats, s)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// Clears the default logger's per-key statistics.
+func ResetKeyStats() {
+	std.ResetKeyStats()
+}
+
+// Clears the per-key statistics.
+func (l *Logger) ResetKeyStats() {
+	l.keyStats.Range(func(k, _ interface{}) bool {
+		l.keyStats.Delete(k)
+		return true
+	})
+}
+
+func (l *Logger) keyCounters(key string) *keyCounters {
+	if c, ok := l.keyStats.Load(key); ok {
+		return c.(*keyCounters)
+	}
+	c, _ := l.keyStats.LoadOrStore(key, &keyCounters{})
+	return c.(*keyCounters)
+}
+
+// Counts an entry with a key, written or suppressed.
+func (l *Logger) countKey(e *Entry, written bool) {
+	c := l.keyCounters(e.Key)
+	if !written {
+		c.suppressed.Add(1)
+		return
+	}
+	c.messages.Add(1)
+	c.bytes.Add(int64(len(e.Message)))
+	c.last.Store(e.Time.UnixNano())
+}
@@ -0,0 +1,49 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestKeyStats(t *testing.T) {
+	l := New(WithOutput(io.Discard), WithKeys("DCP", "GSI"))
+	l.SetKeyRateLimit("GSI", 1, time.Hour)
+	before := time.Now()
+	l.To("DCP", "stream %d opened", 1)
+	l.To("DCP", "stream %d closed", 1)
+	for i := 0; i < 3; i++ {
+		l.To("GSI", "scan")
+	}
+	l.To("KV", "disabled")
+	l.Named("rebalance").Log("started")
+	l.Log("unkeyed")
+
+	stats := l.KeyStats()
+	if len(stats) != 3 {
+		t.Fatalf("Expected stats for 3 keys, got %+v", stats)
+	}
+	dcp, gsi, named := stats[0], stats[1], stats[2]
+	if dcp.Key != "DCP" || dcp.Messages != 2 || dcp.Bytes != 30 || dcp.Suppressed != 0 || dcp.Last.Before(before) {
+		t.Errorf("Unexpected DCP stats %+v", dcp)
+	}
+	if gsi.Key != "GSI" || gsi.Messages != 1 || gsi.Suppressed != 2 {
+		t.Errorf("Unexpected GSI stats %+v", gsi)
+	}
+	if named.Key != "rebalance" || named.Messages != 1 {
+		t.Errorf("Unexpected rebalance stats %+v", named)
+	}
+
+	l.ResetKeyStats()
+	if stats := l.KeyStats(); len(stats) != 0 {
+		t.Errorf("Expected no stats after a reset, got %+v", stats)
+	}
+}
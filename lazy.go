@@ -0,0 +1,36 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Lazy defers computing a log argument or field value until the message is
+// formatted, so that the work is skipped if the level or key filters the
+// message out:
+//
+//	clog.Debugf("state: %v", clog.Lazy(func() interface{} { return dump(s) }))
+//
+// The function may be called more than once, once for each output.
+type Lazy func() interface{}
+
+// Formats the value with the verb and flags the Lazy was formatted with.
+func (f Lazy) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), f())
+}
+
+func (f Lazy) String() string {
+	return fmt.Sprint(f())
+}
+
+func (f Lazy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f())
+}
@@ -0,0 +1,42 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	b, js := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.AddOutput(js, SinkOptions{Format: FormatJSON})
+	DisableColor()
+
+	calls := 0
+	lazy := Lazy(func() interface{} {
+		calls++
+		return 3.14159
+	})
+	l.Debugf("hidden %v", lazy)
+	l.To("hidden", "hidden %v", lazy)
+	l.With("pi", lazy).Debugf("hidden")
+	if calls != 0 {
+		t.Errorf("Expected no calls for filtered messages, got %d", calls)
+	}
+
+	l.With("pi", lazy).Warnf("pi is %.2f", lazy)
+	if got := strings.TrimSpace(b.String()); got != "WARN: pi is 3.14 pi=3.14159" {
+		t.Errorf("Unexpected output %q", got)
+	}
+	if !strings.Contains(js.String(), `"pi":3.14159`) {
+		t.Errorf("Unexpected JSON output %q", js.String())
+	}
+}
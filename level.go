@@ -0,0 +1,87 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of the levels, as returned by String.
+var levelNames = []string{
+	LevelTrace:    "trace",
+	LevelDebug:    "debug",
+	LevelNormal:   "normal",
+	LevelNotice:   "notice",
+	LevelWarning:  "warning",
+	LevelError:    "error",
+	LevelCritical: "critical",
+	LevelPanic:    "panic",
+}
+
+// Other names accepted by ParseLevel.
+var levelAliases = map[string]LogLevel{
+	"info":  LevelNormal,
+	"warn":  LevelWarning,
+	"crit":  LevelCritical,
+	"fatal": LevelPanic,
+}
+
+// Returns the level's name, such as "warning".
+func (level LogLevel) String() string {
+	if level >= 0 && int(level) < len(levelNames) {
+		return levelNames[level]
+	}
+	if level == levelTemp {
+		return "temp"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int32(level))
+}
+
+// Parses a level name, as returned by String, case-insensitively. The names
+// "info", "warn", "crit" and "fatal" are also accepted, as are "temp" and
+// the "LogLevel(n)" form of other levels, so that any level round-trips.
+func ParseLevel(name string) (LogLevel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level, n := range levelNames {
+		if n == name {
+			return LogLevel(level), nil
+		}
+	}
+	if level, ok := levelAliases[name]; ok {
+		return level, nil
+	}
+	if name == "temp" {
+		return levelTemp, nil
+	}
+	if n, ok := strings.CutPrefix(name, "loglevel("); ok {
+		if n, ok := strings.CutSuffix(n, ")"); ok {
+			if level, err := strconv.ParseInt(n, 10, 32); err == nil {
+				return LogLevel(level), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("clog: unknown log level %q", name)
+}
+
+// Implements encoding.TextMarshaler.
+func (level LogLevel) MarshalText() ([]byte, error) {
+	return []byte(level.String()), nil
+}
+
+// Implements encoding.TextUnmarshaler.
+func (level *LogLevel) UnmarshalText(text []byte) error {
+	l, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*level = l
+	return nil
+}
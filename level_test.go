@@ -0,0 +1,175 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for level := LevelTrace; level <= LevelPanic; level++ {
+		got, err := ParseLevel(level.String())
+		if err != nil || got != level {
+			t.Errorf("Expected %v to round-trip, got %v, %v", level, got, err)
+		}
+	}
+
+	tests := map[string]LogLevel{
+		"WARN":   LevelWarning,
+		" info ": LevelNormal,
+		"Debug":  LevelDebug,
+		"fatal":  LevelPanic,
+		"crit":   LevelCritical,
+		"Notice": LevelNotice,
+	}
+	for name, exp := range tests {
+		if got, err := ParseLevel(name); err != nil || got != exp {
+			t.Errorf("Expected %q to parse as %v, got %v, %v", name, exp, got, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Errorf("Expected an error for an unknown level")
+	}
+	if s := LogLevel(42).String(); s != "LogLevel(42)" {
+		t.Errorf("Unexpected name %q for an unknown level", s)
+	}
+	for _, level := range []LogLevel{levelTemp, 42, -1} {
+		text, _ := level.MarshalText()
+		var got LogLevel
+		if err := got.UnmarshalText(text); err != nil || got != level {
+			t.Errorf("Expected %v to round-trip, got %v, %v", level, got, err)
+		}
+	}
+	if _, err := ParseLevel("LogLevel(x)"); err == nil {
+		t.Errorf("Expected an error for a malformed level")
+	}
+}
+
+func TestNoticeAndCritical(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false), WithLevel(LevelNotice))
+	DisableColor()
+	l.Log("filtered")
+	l.Noticef("bucket %s created", "default")
+	l.Criticalf("disk %d failed", 2)
+	l.SetLevel(LevelCritical)
+	l.Errorf("filtered")
+	l.Critical("data loss")
+
+	exp := "NOTI: bucket default created\nCRIT: disk 2 failed\nCRIT: data loss\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+	if level, _, ok := cutLevel("CRIT: disk failed"); !ok || level != LevelCritical {
+		t.Errorf("Expected CRIT to be parsed as critical, got %v", level)
+	}
+	for level, priority := range map[LogLevel]string{LevelNotice: "5", LevelCritical: "2", LevelPanic: "1"} {
+		e := &Entry{Level: level, Message: "m"}
+		if js := string(formatJournal(e, "test")); !strings.Contains(js, "PRIORITY="+priority+"\n") {
+			t.Errorf("Unexpected journal priority for %v: %q", level, js)
+		}
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	var cfg struct{ Level LogLevel }
+	if err := json.Unmarshal([]byte(`{"Level":"error"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != LevelError {
+		t.Errorf("Expected LevelError, got %v", cfg.Level)
+	}
+	b, _ := json.Marshal(cfg)
+	if string(b) != `{"Level":"error"}` {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"Level":"loud"}`), &cfg); err == nil {
+		t.Errorf("Expected an error for an unknown level")
+	}
+}
+
+func TestSetLevelConcurrent(t *testing.T) {
+	l := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(level LogLevel) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.SetLevel(level)
+				l.GetLevel()
+			}
+		}(LogLevel(i))
+	}
+	wg.Wait()
+	if level := l.GetLevel(); level < LevelTrace || level > LevelWarning {
+		t.Errorf("Unexpected level %v", level)
+	}
+	if Level != LevelNormal {
+		t.Errorf("SetLevel changed the deprecated Level variable to %v", Level)
+	}
+}
+
+func TestLevelEnabled(t *testing.T) {
+	requireDebug(t)
+	l := New(WithOutput(io.Discard))
+	named := l.Named("xdcr")
+	if l.LevelEnabled(LevelDebug) || !l.LevelEnabled(LevelNormal) {
+		t.Error("Expected only LevelNormal and above to be enabled")
+	}
+
+	l.SetLevel(LevelDebug)
+	l.SetLevel(LevelWarning)
+	if l.LevelEnabled(LevelNormal) || named.LevelEnabled(LevelNormal) {
+		t.Error("Expected LevelNormal to be disabled after raising the level")
+	}
+
+	named.SetLevel(LevelTrace)
+	if !named.LevelEnabled(LevelTrace) || l.LevelEnabled(LevelTrace) {
+		t.Error("Expected LevelTrace to be enabled for the named logger only")
+	}
+
+	named.ResetLevel()
+	l.EnableRecentEntries(RecentOptions{Size: 4, Level: LevelDebug})
+	if !l.LevelEnabled(LevelDebug) || l.LevelEnabled(LevelTrace) {
+		t.Error("Expected LevelDebug to be enabled for recent entries")
+	}
+	l.EnableRecentEntries(RecentOptions{})
+	if l.LevelEnabled(LevelDebug) {
+		t.Error("Expected LevelDebug to be disabled again")
+	}
+}
+
+func TestFilteredAllocs(t *testing.T) {
+	requireNoRace(t)
+	l := New(WithOutput(io.Discard), WithLevel(LevelError))
+	named := l.Named("xdcr")
+	calls := map[string]func(){
+		"Debugf":    func() { l.Debugf("filtered %s", "message") },
+		"Warnf":     func() { l.Warnf("filtered %s", "message") },
+		"Log":       func() { l.Log("filtered %s", "message") },
+		"DebugTo":   func() { l.DebugTo("xdcr", "filtered %s", "message") },
+		"named Log": func() { named.Log("filtered %s", "message") },
+		"guarded": func() {
+			if l.LevelEnabled(LevelDebug) {
+				l.Debugf("filtered %d", 1<<20)
+			}
+		},
+	}
+	for name, f := range calls {
+		if n := testing.AllocsPerRun(100, f); n != 0 {
+			t.Errorf("Filtered %s made %v allocations", name, n)
+		}
+	}
+}
@@ -0,0 +1,727 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// A Logger carries its own level, enabled keys, output and formatting
+// callback, so that several subsystems in one process can log with different
+// settings. The package-level functions use a default Logger.
+type Logger struct {
+	*config                  // Settings shared with derived Loggers
+	fields    []Field        // Attached using With
+	name      string         // Component name, from Named
+	levels    *levelNode     // Level overrides of Named Loggers; nil for the root
+	skip      int            // Stack frames skipped by callers, from WithCallerSkip
+	msgPrefix string         // Prepended to messages, from WithPrefix
+	prefix    unsafe.Pointer // *string set by SetPrefix, or nil
+	parent    *Logger        // The Logger l was derived from, whose prefix it inherits
+}
+
+type config struct {
+	level         atomic.Int32   // LogLevel of the root Logger
+	floor         atomic.Int32   // Lowest LogLevel which may be logged or kept; see updateFloor
+	namedFloor    atomic.Int32   // Lowest LogLevel set on a Named Logger
+	floorMu       sync.Mutex     // Serializes updates of floor
+	includeCaller int32          // 0 or 1, accessed atomically
+	keys          keySet         // Enabled To() keys
+	sinks         unsafe.Pointer // *[]*sink, replaced on update
+	flags         int32          // Output flags, accessed atomically
+	timeFormat    unsafe.Pointer // *timeFormat, or nil to use the flags
+	hooks         unsafe.Pointer // *[]Hook, or nil
+	rules         int32          // Number of rules added by AddRule, accessed atomically
+	observers     unsafe.Pointer // *[]func(Config), or nil
+	contextFields unsafe.Pointer // *[]func(context.Context) []Field, or nil
+	errorHandler  unsafe.Pointer // *func(error), or nil
+	inHandler     int32          // 1 while the error handler runs, accessed atomically
+	globalFields  unsafe.Pointer // *[]Field set by SetGlobalFields, or nil
+	globalsInText int32          // 0 or 1, whether text outputs show the global fields
+	rateLimits    unsafe.Pointer // *map[string]*rateLimiter, or nil
+	samplers      unsafe.Pointer // *map[string]*sampler, or nil
+	dedup         deduper
+	keyStats      sync.Map                     // Key to *keyCounters
+	keyVerbosity  sync.Map                     // Key to int32 verbosity, set by SetKeyVerbosity
+	verbosity     int32                        // Verbosity of V, accessed atomically
+	levelCounts   [LevelPanic + 1]atomic.Int64 // Messages written, by level
+	lastWritten   atomic.Int64                 // UnixNano time of the last entry stamped with TimeSincePrevious
+	recent        unsafe.Pointer               // *ring of recent entries, or nil
+	async         unsafe.Pointer               // *asyncQueue, or nil if output is synchronous
+	audit         unsafe.Pointer               // *auditLog, or nil
+	stackLevel    int32                        // LogLevel from which stack traces are logged
+	stackAll      int32                        // 0 or 1, whether stack traces include all goroutines
+	verboseErrors int32                        // 0 or 1, whether Error prints errors' stacks
+	expandErrors  int32                        // LogLevel from which error arguments are formatted with %+v
+	goroutineIDs  int32                        // 0 or 1, whether entries have a goroutine field
+	pprofLabels   int32                        // 0 or 1, whether the Ctx functions add pprof labels
+	maxMessageLen int32                        // Messages are truncated to this many bytes, if nonzero
+	escapeControl int32                        // 0 or 1, whether text outputs escape control characters
+	jsonTags      int32                        // 0 or 1, whether JSON outputs keep redaction tags in messages
+	continuation  unsafe.Pointer               // *string prefixing continuation lines, or nil
+	callerOpts    unsafe.Pointer               // *CallerOptions, or nil for the defaults
+	callerSkip    int32                        // Stack frames skipped by callers, from SetCallerSkip
+	packageLevels unsafe.Pointer               // *packageLevels, or nil
+	dropFilters   unsafe.Pointer               // *[]*dropFilter, or nil
+	prefixed      int32                        // 1 once SetPrefix has been called, accessed atomically
+	needOrigin    int32                        // 1 if an output records every entry's caller, accessed atomically
+	entryCallback unsafe.Pointer               // *EntryCallback, or nil
+	callback      func(level, format string, args ...interface{}) string
+	file          *RotatingFile  // Set by SetFileOutput
+	configured    []io.Closer    // Files opened by Configure
+	scopeMu       sync.Mutex     // Held by Scoped until the test completes
+	scopeOwner    unsafe.Pointer // *string name of the test holding scopeMu, or nil
+}
+
+// An Option configures a Logger created with New.
+type Option func(*Logger)
+
+// The default Logger, used by the package-level functions.
+var std = newLogger(LevelNormal)
+
+// New creates a Logger writing to stderr at LevelNormal, with caller
+// information included, then applies the given options.
+func New(opts ...Option) *Logger {
+	l := newLogger(LevelNormal)
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func newLogger(level LogLevel) *Logger {
+	l := &Logger{config: &config{
+		includeCaller: 1,
+		sinks:         unsafe.Pointer(&[]*sink{}),
+		flags:         log.LstdFlags,
+		stackLevel:    int32(LevelPanic) + 1,
+		expandErrors:  int32(LevelPanic) + 1,
+	}}
+	l.namedFloor.Store(int32(LevelPanic) + 1)
+	l.setLevel(level)
+	l.SetOutput(os.Stderr)
+	return l
+}
+
+// Default returns the Logger used by the package-level functions.
+func Default() *Logger {
+	return std
+}
+
+// WithLevel sets the initial log level.
+func WithLevel(level LogLevel) Option {
+	return func(l *Logger) { l.setLevel(level) }
+}
+
+// WithOutput sets the output destination.
+func WithOutput(w io.Writer) Option {
+	return func(l *Logger) { l.SetOutput(w) }
+}
+
+// WithFlags sets the output flags (see the log package).
+func WithFlags(flags int) Option {
+	return func(l *Logger) { l.SetFlags(flags) }
+}
+
+// WithKeys enables logging of messages sent to the given keys.
+func WithKeys(keys ...string) Option {
+	return func(l *Logger) {
+		for _, key := range keys {
+			l.EnableKey(key)
+		}
+	}
+}
+
+// WithIncludeCaller sets whether caller information is included in log output.
+func WithIncludeCaller(enabled bool) Option {
+	return func(l *Logger) { l.includeCaller = btoi(enabled) }
+}
+
+// WithLoggerCallback sets the formatting callback; see SetLoggerCallback.
+func WithLoggerCallback(k func(level, format string, args ...interface{}) string) Option {
+	return func(l *Logger) { l.SetLoggerCallback(k) }
+}
+
+// Thread-safe API for setting log level.
+func (l *Logger) SetLevel(to LogLevel) {
+	l.setLevel(to)
+	l.configChanged()
+}
+
+func (l *Logger) setLevel(to LogLevel) {
+	if l.levels != nil {
+		atomic.StoreInt32(&l.levels.level, int32(to))
+		if to != inheritLevel {
+			l.lowerNamedFloor(to)
+		}
+	} else {
+		l.level.Store(int32(to))
+	}
+	l.updateFloor()
+}
+
+// Records a level set on a Named Logger. The named floor is never raised, as
+// the Named Loggers aren't tracked, so once one has been set below the root
+// Logger's level, messages below it take the slower path through wants.
+func (l *Logger) lowerNamedFloor(to LogLevel) {
+	for {
+		old := l.namedFloor.Load()
+		if int32(to) >= old || l.namedFloor.CompareAndSwap(old, int32(to)) {
+			return
+		}
+	}
+}
+
+// Recomputes the floor, after a change to the levels, the recent entries,
+// the package levels or the rules.
+func (l *Logger) updateFloor() {
+	l.floorMu.Lock()
+	defer l.floorMu.Unlock()
+	floor := min(l.level.Load(), l.namedFloor.Load())
+	if r := l.loadRing(); r != nil {
+		floor = min(floor, int32(r.opts.Level))
+	}
+	if p := (*packageLevels)(atomic.LoadPointer(&l.packageLevels)); p != nil {
+		floor = min(floor, int32(p.min))
+	}
+	if atomic.LoadInt32(&l.rules) > 0 {
+		// A rule may raise any message above the level.
+		floor = int32(LevelTrace)
+	}
+	l.floor.Store(floor)
+}
+
+// Reports whether messages at the given level may be logged or kept. Calls
+// to the logging functions which are filtered out by the level return
+// without allocating, but Go boxes non-constant arguments, such as most
+// integers, in interfaces before the call is made, so guard calls on hot
+// paths:
+//
+//	if l.LevelEnabled(clog.LevelDebug) {
+//		l.Debugf("vb %d at seqno %d", vb, seqno)
+//	}
+func (l *Logger) LevelEnabled(level LogLevel) bool {
+	return l.wants(level)
+}
+
+// Thread-safe API for fetching log level.
+func (l *Logger) GetLevel() LogLevel {
+	for n := l.levels; n != nil; n = n.parent {
+		if level := atomic.LoadInt32(&n.level); level != inheritLevel {
+			return LogLevel(level)
+		}
+	}
+	return LogLevel(l.level.Load())
+}
+
+// Thread-safe API for configuring whether caller information is included in log output. (default true)
+func (l *Logger) SetIncludeCaller(enabled bool) {
+	l.setIncludeCaller(enabled)
+	l.configChanged()
+}
+
+func (l *Logger) setIncludeCaller(enabled bool) {
+	for {
+		if atomic.CompareAndSwapInt32(&l.includeCaller,
+			btoi(l.IsIncludeCaller()), btoi(enabled)) {
+			break
+		}
+	}
+}
+
+// Thread-safe API for indicating whether caller information is included in log output.
+func (l *Logger) IsIncludeCaller() bool {
+	return atomic.LoadInt32(&l.includeCaller) == 1
+}
+
+// Flags returns the output flags for the logger.
+func (l *Logger) Flags() int {
+	return int(atomic.LoadInt32(&l.flags))
+}
+
+// SetFlags sets the output flags for the logger's text outputs.
+func (l *Logger) SetFlags(flags int) {
+	atomic.StoreInt32(&l.flags, int32(flags))
+	for _, s := range l.loadSinks() {
+		if s.ew == nil && s.formatter == nil && s.format.human() {
+			s.logger.SetFlags(l.sinkFlags(flags))
+		}
+	}
+}
+
+// Disable timestamps in logs.
+func (l *Logger) DisableTime() {
+	l.SetFlags(l.Flags() &^ (log.Ldate | log.Ltime | log.Lmicroseconds))
+}
+
+// SetOutput sets the output destination for the logger, replacing any
+// outputs added with AddOutput.
+func (l *Logger) SetOutput(w io.Writer) {
+	atomic.StorePointer(&l.sinks,
+		unsafe.Pointer(&[]*sink{l.newSink(w, SinkOptions{Color: true})}))
+	l.configChanged()
+}
+
+// Set a prefix function for the log message. Prefix function is called for
+// each log message and it returns a prefix which is logged before each message
+//
+// Deprecated: Use SetEntryCallback, which is passed the whole Entry, or
+// AddHook, which can be stacked.
+func (l *Logger) SetLoggerCallback(k func(level, format string, args ...interface{}) string) {
+	// Clear the date and time flag
+	l.DisableTime()
+	l.callback = k
+}
+
+// Parses a comma-separated list of log keys, probably coming from an argv flag.
+// The key "bw" is interpreted as a call to NoColor, not a key.
+func (l *Logger) ParseLogFlag(flag string) {
+	l.ParseLogFlags(strings.Split(flag, ","))
+}
+
+// Parses an array of log keys, probably coming from a argv flags.
+// The key "bw" is interpreted as a call to NoColor, not a key.
+// "all" or "*" enables every key, and a key prefixed with "-" or "!" is
+// disabled, so "all,-dcp" enables everything except "dcp" and its children.
+func (l *Logger) ParseLogFlags(flags []string) {
+	for _, key := range flags {
+		switch key {
+		case "bw":
+			DisableColor()
+		case "notime":
+			l.DisableTime()
+		default:
+			if strings.HasPrefix(key, "-") || strings.HasPrefix(key, "!") {
+				l.DisableKey(key[1:]) // "-foo" disables "foo", even with "all"
+				continue
+			}
+			l.EnableKey(key)
+			for strings.HasSuffix(key, "+") {
+				key = key[:len(key)-1]
+				l.EnableKey(key) // "foo+" also enables "foo"
+			}
+		}
+	}
+	l.Log("Enabling logging: %s", flags)
+}
+
+// Enable logging messages sent to this key
+func (l *Logger) EnableKey(key string) {
+	l.keys.enable(key)
+	l.configChanged()
+}
+
+// Disable logging messages sent to this key
+func (l *Logger) DisableKey(key string) {
+	l.keys.disable(key)
+	l.configChanged()
+}
+
+// Check to see if logging is enabled for a key. Keys form a hierarchy
+// separated by dots: "rest.auth" is also enabled by enabling "rest" or
+// "rest.*" (which enables only the children of "rest").
+func (l *Logger) KeyEnabled(key string) bool {
+	l.keys.note(key)
+	return l.keys.enabled(key)
+}
+
+// Reports whether messages to the key at the given level may be logged. The
+// key is recorded for KnownKeys even if the level filters it out.
+func (l *Logger) wantsKey(level LogLevel, key string) bool {
+	l.keys.note(key)
+	return l.wants(level) && l.keys.enabled(key)
+}
+
+// Logs a message, but only if the corresponding key is enabled.
+func (l *Logger) To(key string, format string, args ...interface{}) {
+	if l.wantsKey(LevelNormal, key) {
+		l.doTo(key, format, args...)
+	}
+}
+
+// Logs a message at the given level, but only if the key is enabled. It
+// doesn't panic or exit at LevelPanic.
+func (l *Logger) Tof(level LogLevel, key string, format string, args ...interface{}) {
+	if l.wantsKey(level, key) {
+		l.doTof(level, key, format, args...)
+	}
+}
+
+// Logs a formatted warning, but only if the key is enabled.
+func (l *Logger) WarnTo(key string, format string, args ...interface{}) {
+	if l.wantsKey(LevelWarning, key) {
+		l.doTof(LevelWarning, key, format, args...)
+	}
+}
+
+// Logs a formatted error message, but only if the key is enabled.
+func (l *Logger) ErrorTo(key string, format string, args ...interface{}) {
+	if l.wantsKey(LevelError, key) {
+		l.doTof(LevelError, key, format, args...)
+	}
+}
+
+// Logs a message.
+func (l *Logger) Log(format string, args ...interface{}) {
+	if l.wants(LevelNormal) {
+		l.doPrintf(format, args...)
+	}
+}
+
+// Prints a formatted message.
+func (l *Logger) Printf(format string, args ...interface{}) {
+	if l.wants(LevelNormal) {
+		l.doPrintf(format, args...)
+	}
+}
+
+// Prints a message.
+func (l *Logger) Print(args ...interface{}) {
+	if l.wants(LevelNormal) {
+		l.doPrint(args...)
+	}
+}
+
+// If the error is not nil, logs the error. Returns the input error for easy
+// chaining.
+func (l *Logger) Error(err error) error {
+	if l.wants(LevelError) && err != nil {
+		l.doError(err)
+	}
+	return err
+}
+
+// Logs a formatted error message.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	if l.wants(LevelError) {
+		l.doLogf(LevelError, fgRed, "ERRO", format, args...)
+	}
+}
+
+// Logs a formatted critical error message.
+func (l *Logger) Criticalf(format string, args ...interface{}) {
+	if l.wants(LevelCritical) {
+		l.doLogf(LevelCritical, fgRed, "CRIT", format, args...)
+	}
+}
+
+// Logs a critical error message.
+func (l *Logger) Critical(args ...interface{}) {
+	if l.wants(LevelCritical) {
+		l.doLog(LevelCritical, fgRed, "CRIT", args...)
+	}
+}
+
+// Logs a formatted warning.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	if l.wants(LevelWarning) {
+		l.doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
+
+// Logs a warning.
+func (l *Logger) Warn(args ...interface{}) {
+	if l.wants(LevelWarning) {
+		l.doLog(LevelWarning, fgRed, "WARN", args...)
+	}
+}
+
+// Logs a formatted notice.
+func (l *Logger) Noticef(format string, args ...interface{}) {
+	if l.wants(LevelNotice) {
+		l.doLogf(LevelNotice, fgRed, "NOTI", format, args...)
+	}
+}
+
+// Logs a notice.
+func (l *Logger) Notice(args ...interface{}) {
+	if l.wants(LevelNotice) {
+		l.doLog(LevelNotice, fgRed, "NOTI", args...)
+	}
+}
+
+// Logs a highlighted message prefixed with "TEMP"; see TEMPf.
+func (l *Logger) TEMPf(format string, args ...interface{}) {
+	l.doLogf(levelTemp, fgYellow, "TEMP", format, args...)
+}
+
+// Logs a highlighted message prefixed with "TEMP"; see TEMP.
+func (l *Logger) TEMP(args ...interface{}) {
+	l.doLog(levelTemp, fgYellow, "TEMP", args...)
+}
+
+// Logs a formatted warning, then panics.
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.doLogf(LevelPanic, fgRed, "CRIT", format, args...)
+	panic(fmt.Sprintf(format, args...))
+}
+
+// Logs a warning, then panics.
+func (l *Logger) Panic(args ...interface{}) {
+	l.doLog(LevelPanic, fgRed, "CRIT", args...)
+	panic(fmt.Sprint(args...))
+}
+
+// Logs a formatted warning, then exits the process.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.doLogf(LevelPanic, fgRed, "FATA", format, args...)
+	l.exit(GetExitCode())
+}
+
+// Logs a warning, then exits the process.
+func (l *Logger) Fatal(args ...interface{}) {
+	l.doLog(LevelPanic, fgRed, "FATA", args...)
+	l.exit(GetExitCode())
+}
+
+// Prefixes of messages logged by Output, by level. Normal level messages have
+// no prefix, like those logged by Log. Critical messages share the CRIT
+// prefix Panic has always used, so that anything watching for it sees both;
+// Writer parses it as LevelCritical, as the lines it reads don't panic.
+var levelPrefixes = []string{
+	LevelTrace:    "TRAC",
+	LevelDebug:    "DEBU",
+	LevelNormal:   "",
+	LevelNotice:   "NOTI",
+	LevelWarning:  "WARN",
+	LevelError:    "ERRO",
+	LevelCritical: "CRIT",
+	LevelPanic:    "CRIT",
+}
+
+// Returns the prefix of messages at a level, as logged by Output.
+func levelPrefix(level LogLevel) string {
+	if level >= 0 && int(level) < len(levelPrefixes) {
+		return levelPrefixes[level]
+	}
+	return "CRIT"
+}
+
+// Output logs a preformatted message at the given level, if the level and
+// key (if not empty) are enabled. pc identifies the calling function, or is 0
+// if unknown. This is the low-level primitive used by adapters from other
+// logging APIs; it does not panic or exit at LevelPanic.
+func (l *Logger) Output(level LogLevel, key string, pc uintptr, message string) {
+	if key == "" && !l.wants(level) || key != "" && !l.wantsKey(level, key) {
+		return
+	}
+	prefix := levelPrefix(level)
+	e := l.newEntry(level, fgRed, prefix, message)
+	if key != "" {
+		e.Key = key
+	}
+	if l.callback != nil {
+		label := prefix
+		if label == "" {
+			label = "INFO"
+		}
+		if e.text = l.callback(label, "%s", message); e.text == "" {
+			return
+		}
+	}
+	if pc != 0 {
+		c := cachedCallerForPC(pc)
+		if prefix != "" && l.wantsCaller(level) {
+			e.Caller = c
+		}
+		e.origin = c
+	}
+	l.output(e)
+}
+
+func (l *Logger) doTo(key string, format string, args ...interface{}) {
+	e := l.newEntry(LevelNormal, "", "", fmt.Sprintf(format, args...))
+	e.Key = key
+	if l.callback != nil {
+		if e.text = l.callbackText("INFO", format, args); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), false)
+	l.output(e)
+}
+
+// Like doLogf, for messages sent to a key; it must also be called directly
+// from the exported logging function. At LevelNormal it logs as doTo.
+func (l *Logger) doTof(level LogLevel, key string, format string, args ...interface{}) {
+	prefix := levelPrefix(level)
+	e := l.newEntry(level, fgRed, prefix, fmt.Sprintf(format, l.expandErrorArgs(level, args)...))
+	e.Key = key
+	if l.callback != nil {
+		label := prefix
+		if label == "" {
+			label = "INFO"
+		}
+		if e.text = l.callbackText(label, format, args); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), prefix != "" && l.wantsCaller(level))
+	e.Stack = l.stackTrace(level, l.callerDepth())
+	l.output(e)
+}
+
+func (l *Logger) doPrintf(format string, args ...interface{}) {
+	e := l.newEntry(LevelNormal, "", "", fmt.Sprintf(format, args...))
+	if l.callback != nil {
+		if e.text = l.callbackText("INFO", format, args); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), false)
+	l.output(e)
+}
+
+func (l *Logger) doPrint(args ...interface{}) {
+	e := l.newEntry(LevelNormal, "", "", fmt.Sprint(args...))
+	if l.callback != nil {
+		if e.text = l.callbackText("INFO", "", args); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), false)
+	l.output(e)
+}
+
+// doLog and doLogf must be called directly from the exported logging
+// function, so that callerDepth identifies the exported function's
+// caller.
+func (l *Logger) doLog(level LogLevel, color string, prefix string, args ...interface{}) {
+	e := l.newEntry(level, color, prefix, fmt.Sprint(l.expandErrorArgs(level, args)...))
+	if l.callback != nil {
+		if e.text = l.callbackText(prefix, "", args); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), l.wantsCaller(level))
+	e.Stack = l.stackTrace(level, l.callerDepth())
+	l.output(e)
+}
+
+func (l *Logger) doLogf(level LogLevel, color string, prefix string, format string, args ...interface{}) {
+	e := l.newEntry(level, color, prefix, fmt.Sprintf(format, l.expandErrorArgs(level, args)...))
+	if l.callback != nil {
+		if e.text = l.callbackText(prefix, format, args); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), l.wantsCaller(level))
+	e.Stack = l.stackTrace(level, l.callerDepth())
+	l.output(e)
+}
+
+// Calls the logger callback with a copy of args, so that the arguments of
+// logging calls don't escape to the heap when there is no callback.
+func (l *Logger) callbackText(label, format string, args []interface{}) string {
+	return l.callback(label, format, append([]interface{}(nil), args...)...)
+}
+
+// Like doLogf for Error, which prints the stack carried by err if verbose
+// errors are enabled and there isn't a stack trace already.
+func (l *Logger) doError(err error) {
+	message := fmt.Sprint(err)
+	if l.expandsErrors(LevelError) {
+		message = fmt.Sprintf("%+v", err)
+	}
+	e := l.newEntry(LevelError, fgRed, "ERRO", message)
+	if l.callback != nil {
+		if e.text = l.callbackText("ERRO", "%v", []interface{}{err}); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), l.wantsCaller(LevelError))
+	e.Stack = l.stackTrace(LevelError, l.callerDepth())
+	if e.Stack == "" && atomic.LoadInt32(&l.verboseErrors) == 1 {
+		e.Stack = errorStack(err)
+	}
+	l.output(e)
+}
+
+func (l *Logger) newEntry(level LogLevel, color, prefix, message string) *Entry {
+	fields, globals := l.entryFields()
+	callerOpts := l.GetCallerOptions()
+	if atomic.LoadInt32(&l.goroutineIDs) == 1 {
+		fields = append(fields, Field{Key: "goroutine", Value: goroutineID()})
+	}
+	return &Entry{
+		Time:          time.Now(),
+		Level:         level,
+		Key:           l.name,
+		Message:       message,
+		Fields:        fields,
+		color:         color,
+		prefix:        prefix,
+		globals:       globals,
+		globalsInText: globals > 0 && atomic.LoadInt32(&l.globalsInText) == 1,
+		escape:        atomic.LoadInt32(&l.escapeControl) == 1,
+		keepTags:      atomic.LoadInt32(&l.jsonTags) == 1,
+		continuation:  l.continuationPrefix(),
+		callerFormat:  callerOpts.Format,
+		callerFirst:   callerOpts.First,
+	}
+}
+
+// Writes an entry to the logger's outputs, unless it is suppressed.
+func (l *Logger) output(e *Entry) {
+	l.addPrefix(e)
+	l.truncate(e)
+	if !l.runHooks(e) {
+		return
+	}
+	written := e.Level >= l.GetLevel() || l.packageWants(e)
+	l.keepRecent(e, written)
+	if !written {
+		return
+	}
+	suppressed := l.filtered(e) ||
+		e.Key != "" && (!l.sample(e) || !l.rateLimit(e)) || !l.deduplicate(e) ||
+		!l.runEntryCallback(e)
+	if e.Key != "" {
+		l.countKey(e, !suppressed)
+	}
+	if !suppressed {
+		if e.Level >= 0 && int(e.Level) < len(l.levelCounts) {
+			l.levelCounts[e.Level].Add(1)
+		}
+		l.write(e)
+	}
+}
+
+// Writes an entry to each of the logger's outputs that accepts its level, or
+// queues it to be written if output is asynchronous.
+func (l *Logger) write(e *Entry) {
+	if q := l.loadAsync(); q != nil {
+		if e.Level >= LevelPanic {
+			// The caller is about to panic or exit, so the entry can't wait.
+			q.flush()
+		} else if q.enqueue(e) {
+			return
+		}
+	}
+	l.writeNow(e)
+}
+
+func (l *Logger) writeNow(e *Entry) {
+	l.stamp(e)
+	for _, s := range l.loadSinks() {
+		if s.accepts(e.Level) {
+			if err := s.write(e); err != nil {
+				l.writeFailed(s, e, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerIndependence(t *testing.T) {
+	requireDebug(t)
+	b1, b2 := &bytes.Buffer{}, &bytes.Buffer{}
+	l1 := New(WithOutput(b1), WithLevel(LevelDebug), WithKeys("dcp"))
+	l2 := New(WithOutput(b2), WithLevel(LevelWarning))
+
+	l1.Debugf("debug %d", 1)
+	l2.Debugf("debug %d", 2)
+	l1.To("dcp", "stream %d", 1)
+	l2.To("dcp", "stream %d", 2)
+	l2.Warnf("warn %d", 2)
+
+	if out := b1.String(); !strings.Contains(out, "debug 1") ||
+		!strings.Contains(out, "stream 1") {
+		t.Errorf("Expected debug and key output from l1, got %q", out)
+	}
+	if out := b2.String(); strings.Contains(out, "debug 2") ||
+		strings.Contains(out, "stream 2") || !strings.Contains(out, "warn 2") {
+		t.Errorf("Expected only the warning from l2, got %q", out)
+	}
+	if KeyEnabled("dcp") {
+		t.Errorf("Enabling a key on a Logger should not affect the default")
+	}
+	if Default().GetLevel() != GetLevel() {
+		t.Errorf("Default() should share the package level")
+	}
+}
+
+func TestLoggerCaller(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.Warn("here")
+	if !strings.Contains(b.String(), "TestLoggerCaller() at logger_test.go") {
+		t.Errorf("Expected caller to be the test, got %q", b.String())
+	}
+
+	b.Reset()
+	l.SetIncludeCaller(false)
+	l.Warn("here")
+	if strings.Contains(b.String(), " -- ") {
+		t.Errorf("Expected no caller, got %q", b.String())
+	}
+}
+
+func TestToLevels(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("dcp"))
+	DisableColor()
+
+	l.DebugTo("dcp", "hidden")
+	l.WarnTo("xdcr", "hidden")
+	l.WarnTo("dcp", "stream %d stalled", 7)
+	l.ErrorTo("dcp", "stream %d closed", 7)
+	l.Tof(LevelNormal, "dcp", "plain")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", b.String())
+	}
+	if !strings.HasPrefix(lines[0], "WARN: dcp: stream 7 stalled -- ") ||
+		!strings.Contains(lines[0], "TestToLevels() at logger_test.go") {
+		t.Errorf("Unexpected warning %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "ERRO: dcp: stream 7 closed -- ") {
+		t.Errorf("Unexpected error %q", lines[1])
+	}
+	if lines[2] != "dcp: plain" {
+		t.Errorf("Unexpected message %q", lines[2])
+	}
+}
@@ -0,0 +1,64 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Sets the default logger's continuation prefix; see
+// Logger.SetContinuationPrefix.
+func SetContinuationPrefix(prefix string) {
+	std.SetContinuationPrefix(prefix)
+}
+
+// Thread-safe API for setting a prefix, such as "  | ", which the text and
+// pretty formats write at the start of each continuation line of a message
+// or stack trace. Multi-line entries then stay visually attached to their
+// first line, and lines not starting with the prefix each begin a new entry,
+// so that tools can reassemble whole entries. (default "", no prefix)
+func (l *Logger) SetContinuationPrefix(prefix string) {
+	if prefix == "" {
+		atomic.StorePointer(&l.continuation, nil)
+		return
+	}
+	atomic.StorePointer(&l.continuation, unsafe.Pointer(&prefix))
+}
+
+// Returns the logger's continuation prefix, or "" if there is none.
+func (l *Logger) continuationPrefix() string {
+	if p := atomic.LoadPointer(&l.continuation); p != nil {
+		return *(*string)(p)
+	}
+	return ""
+}
+
+// Writes prefix after each newline in b from start, except a final one.
+func indentLines(b *bytes.Buffer, start int, prefix string) {
+	text := b.Bytes()[start:]
+	if prefix == "" || bytes.IndexByte(bytes.TrimSuffix(text, []byte{'\n'}), '\n') < 0 {
+		return
+	}
+	tb := getBuffer()
+	tb.Write(text)
+	b.Truncate(start)
+	for text := tb.Bytes(); len(text) > 0; {
+		i := bytes.IndexByte(text, '\n')
+		if i < 0 || i == len(text)-1 {
+			b.Write(text)
+			break
+		}
+		b.Write(text[:i+1])
+		b.WriteString(prefix)
+		text = text[i+1:]
+	}
+	putBuffer(tb)
+}
@@ -0,0 +1,51 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestContinuationPrefix(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.Log("config:\nbucket: default\nram: 256\n")
+	l.SetContinuationPrefix("  | ")
+	l.Log("config:\nbucket: default\nram: 256\n")
+	l.Log("single line")
+	l.SetContinuationPrefix("")
+	l.Log("a\nb")
+
+	expect := "config:\nbucket: default\nram: 256\n" +
+		"config:\n  | bucket: default\n  | ram: 256\n" +
+		"single line\n" +
+		"a\nb\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestContinuationPrefixStack(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.SetContinuationPrefix("\t| ")
+	l.SetStackTraceLevel(LevelError)
+	l.Errorf("failed")
+
+	lines := bytes.Split(bytes.TrimSuffix(b.Bytes(), []byte{'\n'}), []byte{'\n'})
+	if len(lines) < 3 || string(lines[0]) != "ERRO: failed" {
+		t.Fatalf("Unexpected output %q", b.String())
+	}
+	for _, line := range lines[1:] {
+		if !bytes.HasPrefix(line, []byte("\t| ")) {
+			t.Errorf("Continuation line %q isn't prefixed", line)
+		}
+	}
+}
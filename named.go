@@ -0,0 +1,68 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Marks a Named Logger which has no level of its own.
+const inheritLevel = math.MinInt32
+
+// A Named Logger's own level, falling back to its parent's.
+type levelNode struct {
+	level  int32 // LogLevel, or inheritLevel; accessed atomically
+	parent *levelNode
+}
+
+// Returns a child of the default logger for a component; see Logger.Named.
+func Named(name string) *Logger {
+	return std.Named(name)
+}
+
+// Returns a child Logger for a component. Its messages are prefixed with the
+// name (joined to the parent's name, if any, with a dot, like a key), and it
+// inherits the parent's level until given its own with SetLevel. All other
+// settings, such as the outputs, are shared with the parent.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	l.keys.note(name)
+	return &Logger{
+		config:    l.config,
+		fields:    l.fields,
+		name:      name,
+		levels:    &levelNode{level: inheritLevel, parent: l.levels},
+		skip:      l.skip,
+		msgPrefix: l.msgPrefix,
+		parent:    l,
+	}
+}
+
+// Returns the Logger's name, or "" for a root Logger.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Makes a Named Logger inherit its parent's level again, after SetLevel.
+// This has no effect on a root Logger.
+func (l *Logger) ResetLevel() {
+	if l.levels != nil {
+		atomic.StoreInt32(&l.levels.level, inheritLevel)
+		l.configChanged()
+	}
+}
+
+// Returns a Logger like l but with the given fields.
+func (l *Logger) withFields(fields []Field) *Logger {
+	return &Logger{config: l.config, fields: fields, name: l.name, levels: l.levels,
+		skip: l.skip, msgPrefix: l.msgPrefix, parent: l}
+}
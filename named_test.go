@@ -0,0 +1,47 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	root := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	dcp := root.Named("dcp")
+	stream := dcp.Named("stream").With("vb", 3)
+
+	root.Debugf("root debug")
+	stream.Log("opened")
+	dcp.SetLevel(LevelDebug)
+	stream.Debugf("stream debug")
+	root.Debugf("root debug")
+	dcp.ResetLevel()
+	stream.Debugf("stream debug again")
+	root.SetLevel(LevelWarning)
+	stream.Log("hidden")
+	stream.Warnf("closing")
+
+	exp := []string{
+		"dcp.stream: opened vb=3",
+		"DEBU: dcp.stream: stream debug vb=3",
+		"WARN: dcp.stream: closing vb=3",
+	}
+	if got := strings.TrimSpace(b.String()); got != strings.Join(exp, "\n") {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+	if stream.Name() != "dcp.stream" || root.Name() != "" {
+		t.Errorf("Unexpected names %q, %q", stream.Name(), root.Name())
+	}
+}
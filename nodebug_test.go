@@ -0,0 +1,51 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+//go:build clog_nodebug
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNoDebug(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithLevel(LevelTrace), WithKeys("k"))
+	called := false
+	lazy := Lazy(func() interface{} { called = true; return nil })
+
+	l.Debugf("%v", lazy)
+	l.Trace(lazy)
+	l.DebugTo("k", "%v", lazy)
+	if DebugEnabled || b.Len() != 0 || called {
+		t.Errorf("Expected debug logging to be compiled out, got %q", b.String())
+	}
+	l.V(0).Logf("%v", lazy)
+	l.VTo("k", 0).Logf("%v", lazy)
+	l.Dumpf("k", "packet", []byte{1, 2})
+	l.LogFields(LevelDebug, "fields", F("lazy", lazy))
+	l.Tof(LevelTrace, "k", "%v", lazy)
+	l.Output(LevelDebug, "", 0, "output")
+	if b.Len() != 0 || called || l.LevelEnabled(LevelDebug) {
+		t.Errorf("Expected debug messages logged by level to be dropped, got %q", b.String())
+	}
+	if n := testing.AllocsPerRun(100, func() { l.Debugf("filtered %d", 1) }); n != 0 && !raceEnabled {
+		t.Errorf("Expected no allocations, got %v", n)
+	}
+}
+
+func BenchmarkDebugfCompiledOut(b *testing.B) {
+	l := New(WithOutput(io.Discard), WithLevel(LevelTrace))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debugf("vb %d at seqno %d", i, i)
+	}
+}
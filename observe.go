@@ -0,0 +1,68 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Registers f to be called on the default logger's configuration changes;
+// see Logger.OnConfigChange.
+func OnConfigChange(f func(Config)) {
+	std.OnConfigChange(f)
+}
+
+// Registers f to be called whenever the level, enabled keys, caller setting
+// or outputs of l, or of a Logger derived from it, are changed. It is passed
+// the settings after the change, as seen by the Logger which changed;
+// Outputs is left empty, as outputs added with AddOutput have no
+// serializable description. f is called synchronously, once per change (or
+// once per Configure), so it must not block or change the configuration.
+func (l *Logger) OnConfigChange(f func(Config)) {
+	for {
+		oop := atomic.LoadPointer(&l.observers)
+		var newo []func(Config)
+		if oop != nil {
+			newo = append(newo, *(*[]func(Config))(oop)...)
+		}
+		newo = append(newo, f)
+		if atomic.CompareAndSwapPointer(&l.observers, oop, unsafe.Pointer(&newo)) {
+			return
+		}
+	}
+}
+
+// Calls the OnConfigChange observers with the current settings.
+func (l *Logger) configChanged() {
+	op := atomic.LoadPointer(&l.observers)
+	if op == nil {
+		return
+	}
+	cfg := l.currentConfig()
+	for _, f := range *(*[]func(Config))(op) {
+		f(cfg)
+	}
+}
+
+// Returns a Config describing the logger's current settings, other than its
+// outputs.
+func (l *Logger) currentConfig() Config {
+	level := l.GetLevel()
+	color := colorEnabled()
+	includeCaller := l.IsIncludeCaller()
+	redaction := GetRedactionLevel()
+	return Config{
+		Level:         &level,
+		Keys:          l.EnabledKeys(),
+		Color:         &color,
+		IncludeCaller: &includeCaller,
+		Redaction:     &redaction,
+	}
+}
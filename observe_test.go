@@ -0,0 +1,51 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestOnConfigChange(t *testing.T) {
+	l := New(WithOutput(ioutil.Discard))
+	var seen []Config
+	l.OnConfigChange(func(cfg Config) { seen = append(seen, cfg) })
+
+	l.SetLevel(LevelDebug)
+	l.EnableKey("dcp")
+	l.Named("rest").SetLevel(LevelTrace)
+	l.AddOutput(ioutil.Discard, SinkOptions{})
+	l.SetIncludeCaller(false)
+	l.DisableKey("dcp")
+	level := LevelWarning
+	if err := l.Configure(Config{Level: &level, Keys: []string{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 7 {
+		t.Fatalf("Expected 7 notifications, got %d", len(seen))
+	}
+	if *seen[0].Level != LevelDebug || len(seen[0].Keys) != 0 {
+		t.Errorf("SetLevel: %+v", seen[0])
+	}
+	if !reflect.DeepEqual(seen[1].Keys, []string{"dcp"}) {
+		t.Errorf("EnableKey: keys %v", seen[1].Keys)
+	}
+	if *seen[2].Level != LevelTrace {
+		t.Errorf("Named SetLevel: level %v", *seen[2].Level)
+	}
+	if *seen[4].IncludeCaller {
+		t.Errorf("SetIncludeCaller: still including the caller")
+	}
+	if last := seen[6]; *last.Level != LevelWarning || !reflect.DeepEqual(last.Keys, []string{"a", "b"}) {
+		t.Errorf("Configure: level %v, keys %v", *last.Level, last.Keys)
+	}
+}
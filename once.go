@@ -0,0 +1,103 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// When each LogOnce id was last logged (*int64 Unix nanoseconds), shared by
+// all Loggers.
+var onceTimes sync.Map
+
+// Reports whether a message with the given id may be logged now, and if so
+// records it as logged. An interval of 0 allows it only once.
+func allowOnce(id string, interval time.Duration) bool {
+	v, ok := onceTimes.Load(id)
+	if !ok {
+		v, _ = onceTimes.LoadOrStore(id, new(int64))
+	}
+	p := v.(*int64)
+	now := time.Now().UnixNano()
+	for {
+		last := atomic.LoadInt64(p)
+		if last != 0 && (interval <= 0 || now-last < int64(interval)) {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(p, last, now) {
+			return true
+		}
+	}
+}
+
+// Logs a message the first time it is called with a given id in this
+// process, such as for a deprecation notice. Ids are shared by all Loggers.
+func LogOnce(id string, format string, args ...interface{}) {
+	if std.wants(LevelNormal) && allowOnce(id, 0) {
+		std.doPrintf(format, args...)
+	}
+}
+
+// Logs a warning the first time it is called with a given id in this
+// process.
+func WarnOnce(id string, format string, args ...interface{}) {
+	if std.wants(LevelWarning) && allowOnce(id, 0) {
+		std.doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
+
+// Logs a message if none with the given id has been logged in the last
+// interval, such as for a configuration problem found on every request.
+func LogOncePer(id string, interval time.Duration, format string, args ...interface{}) {
+	if std.wants(LevelNormal) && allowOnce(id, interval) {
+		std.doPrintf(format, args...)
+	}
+}
+
+// Logs a warning if none with the given id has been logged in the last
+// interval.
+func WarnOncePer(id string, interval time.Duration, format string, args ...interface{}) {
+	if std.wants(LevelWarning) && allowOnce(id, interval) {
+		std.doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
+
+// Logs a message the first time it is called with a given id in this
+// process; see the package-level LogOnce.
+func (l *Logger) LogOnce(id string, format string, args ...interface{}) {
+	if l.wants(LevelNormal) && allowOnce(id, 0) {
+		l.doPrintf(format, args...)
+	}
+}
+
+// Logs a warning the first time it is called with a given id in this
+// process.
+func (l *Logger) WarnOnce(id string, format string, args ...interface{}) {
+	if l.wants(LevelWarning) && allowOnce(id, 0) {
+		l.doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
+
+// Logs a message if none with the given id has been logged in the last
+// interval.
+func (l *Logger) LogOncePer(id string, interval time.Duration, format string, args ...interface{}) {
+	if l.wants(LevelNormal) && allowOnce(id, interval) {
+		l.doPrintf(format, args...)
+	}
+}
+
+// Logs a warning if none with the given id has been logged in the last
+// interval.
+func (l *Logger) WarnOncePer(id string, interval time.Duration, format string, args ...interface{}) {
+	if l.wants(LevelWarning) && allowOnce(id, interval) {
+		l.doLogf(LevelWarning, fgRed, "WARN", format, args...)
+	}
+}
@@ -0,0 +1,56 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogOnce(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+
+	for i := 0; i < 3; i++ {
+		l.WarnOnce("once-test.deprecated", "option %d is deprecated", i)
+		l.LogOnce("once-test.note", "note")
+	}
+	l.Named("other").WarnOnce("once-test.deprecated", "shared id")
+	if got := b.String(); got != "WARN: option 0 is deprecated\nnote\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
+
+func TestLogOncePer(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+
+	for i := 0; i < 3; i++ {
+		l.LogOncePer("once-test.per", 20*time.Millisecond, "bad config")
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.LogOncePer("once-test.per", 20*time.Millisecond, "bad config")
+	if n := strings.Count(b.String(), "bad config"); n != 2 {
+		t.Errorf("Expected 2 messages, got %d", n)
+	}
+}
+
+func TestLogOnceFiltered(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithLevel(LevelError))
+	l.WarnOnce("once-test.filtered", "hidden")
+	l.SetLevel(LevelNormal)
+	l.WarnOnce("once-test.filtered", "shown")
+	if !strings.Contains(b.String(), "shown") {
+		t.Errorf("Expected a filtered message not to use up its id, got %q", b.String())
+	}
+}
@@ -0,0 +1,321 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPOptions configure an OpenTelemetry log exporter.
+type OTLPOptions struct {
+	Endpoint      string            // OTLP/HTTP logs URL, e.g. "http://localhost:4318/v1/logs"
+	Headers       map[string]string // Added to each request, e.g. for authentication
+	ServiceName   string            // The service.name resource attribute
+	Level         LogLevel          // Minimum level exported
+	BatchSize     int               // Records per request (default 512)
+	FlushInterval time.Duration     // Maximum time a record is held (default 5s)
+	Client        *http.Client      // Default http.DefaultClient
+	Circuit       CircuitOptions
+}
+
+// An OTLPExporter sends log entries to an OpenTelemetry collector using
+// OTLP/HTTP with JSON encoding. Entries carrying "trace_id" and "span_id"
+// fields (for instance added with ContextWith, or by the clogotel package)
+// are correlated with that span.
+type OTLPExporter struct {
+	opts    OTLPOptions
+	circuit *circuit
+
+	mu      sync.Mutex
+	records []otlpRecord
+	err     error // Last export error
+
+	flush     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Exports the default logger's entries; see Logger.AddOTLPOutput.
+func AddOTLPOutput(opts OTLPOptions) *OTLPExporter {
+	return std.AddOTLPOutput(opts)
+}
+
+// Adds an output exporting entries to an OpenTelemetry collector. Entries are
+// sent in batches from a background goroutine; Close the exporter to send
+// any outstanding entries.
+func (l *Logger) AddOTLPOutput(opts OTLPOptions) *OTLPExporter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	x := &OTLPExporter{
+		opts:    opts,
+		circuit: newCircuit("otlp "+opts.Endpoint, opts.Circuit),
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	x.wg.Add(1)
+	go x.run()
+	l.addSink(&sink{level: opts.Level, ew: x})
+	return x
+}
+
+// Sends any outstanding entries, returning the error from doing so.
+func (x *OTLPExporter) Flush() error {
+	x.mu.Lock()
+	records := x.records
+	x.records = nil
+	x.mu.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+	err := ErrSinkDown
+	if x.circuit.allow() {
+		err = x.export(records)
+		x.circuit.record(err)
+	}
+	x.mu.Lock()
+	x.err = err
+	x.mu.Unlock()
+	return err
+}
+
+// Returns the error from the most recent export, if it failed.
+func (x *OTLPExporter) Err() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.err
+}
+
+// Stops the background goroutine and sends any outstanding entries. Entries
+// written afterwards are sent only by an explicit Flush.
+func (x *OTLPExporter) Close() error {
+	x.closeOnce.Do(func() { close(x.done) })
+	x.wg.Wait()
+	return x.Flush()
+}
+
+func (x *OTLPExporter) run() {
+	defer x.wg.Done()
+	ticker := time.NewTicker(x.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-x.flush:
+		case <-x.done:
+			return
+		}
+		x.Flush()
+	}
+}
+
+func (x *OTLPExporter) health() SinkState {
+	return x.circuit.health()
+}
+
+func (x *OTLPExporter) writeEntry(e *Entry) {
+	r := newOTLPRecord(e)
+	x.mu.Lock()
+	x.records = append(x.records, r)
+	full := len(x.records) >= x.opts.BatchSize
+	x.mu.Unlock()
+	if full {
+		select {
+		case x.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (x *OTLPExporter) export(records []otlpRecord) error {
+	var resource otlpResource
+	if x.opts.ServiceName != "" {
+		resource.Attributes = []otlpAttr{{"service.name", otlpValueOf(x.opts.ServiceName)}}
+	}
+	body, err := json.Marshal(otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource: resource,
+		ScopeLogs: []otlpScopeLogs{{
+			Scope:      otlpScope{Name: "github.com/couchbase/clog"},
+			LogRecords: records,
+		}},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", x.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range x.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := x.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("clog: OTLP export to %s: %s", x.opts.Endpoint, resp.Status)
+	}
+	return nil
+}
+
+// The subset of the OTLP/JSON logs request used by the exporter.
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope    `json:"scope"`
+	LogRecords []otlpRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpRecord struct {
+	TimeUnixNano   string     `json:"timeUnixNano"`
+	SeverityNumber int        `json:"severityNumber"`
+	SeverityText   string     `json:"severityText"`
+	Body           otlpValue  `json:"body"`
+	Attributes     []otlpAttr `json:"attributes,omitempty"`
+	TraceID        string     `json:"traceId,omitempty"`
+	SpanID         string     `json:"spanId,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"` // int64 as a string, per OTLP/JSON
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpValue `json:"values"`
+}
+
+// OpenTelemetry severity numbers, by level.
+var otlpSeverities = []int{
+	LevelTrace:    1,  // TRACE
+	LevelDebug:    5,  // DEBUG
+	LevelNormal:   9,  // INFO
+	LevelNotice:   10, // INFO2
+	LevelWarning:  13, // WARN
+	LevelError:    17, // ERROR
+	LevelCritical: 20, // ERROR4
+	LevelPanic:    21, // FATAL
+}
+
+func newOTLPRecord(e *Entry) otlpRecord {
+	severity := 9
+	if e.Level >= 0 && int(e.Level) < len(otlpSeverities) {
+		severity = otlpSeverities[e.Level]
+	}
+	r := otlpRecord{
+		TimeUnixNano:   strconv.FormatInt(e.Time.UnixNano(), 10),
+		SeverityNumber: severity,
+		SeverityText:   e.Level.String(),
+		Body:           otlpValueOf(e.Message),
+	}
+	if e.Key != "" {
+		r.Attributes = append(r.Attributes, otlpAttr{"clog.key", otlpValueOf(e.Key)})
+	}
+	if e.Caller != nil {
+		r.Attributes = append(r.Attributes,
+			otlpAttr{"code.function", otlpValueOf(e.Caller.Function)},
+			otlpAttr{"code.filepath", otlpValueOf(e.Caller.File)},
+			otlpAttr{"code.lineno", otlpValueOf(e.Caller.Line)})
+	}
+	redacted := redactionTags(e.Message)
+	for _, f := range e.Fields {
+		switch f.Key {
+		case "trace_id":
+			r.TraceID = fmt.Sprint(f.Value)
+		case "span_id":
+			r.SpanID = fmt.Sprint(f.Value)
+		default:
+			r.Attributes = append(r.Attributes, otlpAttr{f.Key, otlpValueOf(f.Value)})
+			redacted = append(redacted, redactionTags(fmt.Sprint(f.Value))...)
+		}
+	}
+	if len(redacted) > 0 {
+		r.Attributes = append(r.Attributes, otlpAttr{"clog.redaction", otlpValueOf(redacted)})
+	}
+	return r
+}
+
+// Returns the redaction tags ("ud", "md", "sd") used in s.
+func redactionTags(s string) []string {
+	var found []string
+	for _, tag := range tags {
+		if strings.Contains(s, "<"+tag+">") {
+			found = append(found, tag)
+		}
+	}
+	return found
+}
+
+func otlpValueOf(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+		s := fmt.Sprint(v)
+		return otlpValue{IntValue: &s}
+	case float32:
+		f := float64(v)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case []string:
+		values := make([]otlpValue, len(v))
+		for i, s := range v {
+			values[i] = otlpValueOf(s)
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{values}}
+	case error:
+		return otlpValueOf(v.Error())
+	}
+	return otlpValueOf(fmt.Sprint(v))
+}
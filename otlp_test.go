@@ -0,0 +1,75 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer x" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	l := New(WithOutput(io.Discard))
+	x := l.AddOTLPOutput(OTLPOptions{
+		Endpoint:      srv.URL,
+		Headers:       map[string]string{"Authorization": "Bearer x"},
+		ServiceName:   "indexer",
+		Level:         LevelWarning,
+		FlushInterval: time.Hour,
+	})
+	ctx := ContextWith(context.Background(),
+		"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736", "span_id", "00f067aa0ba902b7")
+	l.Log("not exported")
+	l.With("vb", 12).WarnfCtx(ctx, "slow key %s", Tag(UserData, "k1"))
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal([]byte(<-bodies), &req); err != nil {
+		t.Fatal(err)
+	}
+	rl := req.ResourceLogs[0]
+	if *rl.Resource.Attributes[0].Value.StringValue != "indexer" {
+		t.Errorf("Expected service.name indexer, got %+v", rl.Resource)
+	}
+	records := rl.ScopeLogs[0].LogRecords
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %+v", records)
+	}
+	r := records[0]
+	if r.SeverityNumber != 13 || *r.Body.StringValue != "slow key <ud>k1</ud>" ||
+		r.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || r.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	attrs := map[string]otlpValue{}
+	for _, a := range r.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	if *attrs["vb"].IntValue != "12" ||
+		!strings.HasSuffix(*attrs["code.function"].StringValue, "TestOTLPExporter") ||
+		*attrs["clog.redaction"].ArrayValue.Values[0].StringValue != "ud" {
+		t.Errorf("Unexpected attributes %+v", r.Attributes)
+	}
+}
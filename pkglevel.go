@@ -0,0 +1,124 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Levels set for code under package paths, by SetPackageLevel.
+type packageLevels struct {
+	paths map[string]LogLevel
+	min   LogLevel // The lowest of the levels
+}
+
+// Enables debug messages from code under a package path in the default
+// logger; see Logger.EnableDebugForPackage.
+func EnableDebugForPackage(path string) {
+	std.EnableDebugForPackage(path)
+}
+
+// Sets the default logger's level for code under a package path; see
+// Logger.SetPackageLevel.
+func SetPackageLevel(path string, level LogLevel) {
+	std.SetPackageLevel(path, level)
+}
+
+// Removes the default logger's package levels.
+func ClearPackageLevels() {
+	std.ClearPackageLevels()
+}
+
+// Logs debug messages from code under the package path, such as
+// "github.com/couchbase/indexing/secondary/projector", whatever the logger's
+// level. Shorthand for SetPackageLevel(path, LevelDebug).
+func (l *Logger) EnableDebugForPackage(path string) {
+	l.SetPackageLevel(path, LevelDebug)
+}
+
+// Thread-safe API for logging messages at level or above from code in the
+// package with the given import path, or below it, even if the logger's
+// level is higher, so that one module can be debugged without enabling its
+// keys. The package is that of the function which called the logging
+// function, and the most specific path applies. Package levels only lower
+// the logger's level; messages it enables are logged regardless. Messages
+// below the logger's level cost a caller lookup while any package level is
+// set below it.
+func (l *Logger) SetPackageLevel(path string, level LogLevel) {
+	path = strings.TrimSuffix(path, "/")
+	for {
+		opp := atomic.LoadPointer(&l.packageLevels)
+		newp := &packageLevels{paths: map[string]LogLevel{path: level}, min: level}
+		if opp != nil {
+			for p, lv := range (*packageLevels)(opp).paths {
+				if p != path {
+					newp.paths[p] = lv
+					newp.min = min(newp.min, lv)
+				}
+			}
+		}
+		if atomic.CompareAndSwapPointer(&l.packageLevels, opp, unsafe.Pointer(newp)) {
+			l.updateFloor()
+			return
+		}
+	}
+}
+
+// Removes the logger's package levels.
+func (l *Logger) ClearPackageLevels() {
+	atomic.StorePointer(&l.packageLevels, nil)
+	l.updateFloor()
+}
+
+// Reports whether a message at the given level may be logged because of a
+// package level.
+func (l *Logger) packageMayWant(level LogLevel) bool {
+	p := (*packageLevels)(atomic.LoadPointer(&l.packageLevels))
+	return p != nil && level >= p.min
+}
+
+// Reports whether the entry is logged because of the level of its caller's
+// package.
+func (l *Logger) packageWants(e *Entry) bool {
+	p := (*packageLevels)(atomic.LoadPointer(&l.packageLevels))
+	if p == nil || e.origin == nil || e.Level < p.min {
+		return false
+	}
+	pkg := packagePath(e.origin.Function)
+	for {
+		if level, ok := p.paths[pkg]; ok {
+			return e.Level >= level
+		}
+		i := strings.LastIndexByte(pkg, '/')
+		if i < 0 {
+			return false
+		}
+		pkg = pkg[:i]
+	}
+}
+
+// Sets the entry's Caller, if show is set, and its origin, if it is needed
+// to apply the package levels or by an output such as journald. depth is as
+// for callerAt, from the function calling setCaller.
+func (l *Logger) setCaller(e *Entry, depth int, show bool) {
+	origin := atomic.LoadInt32(&l.needOrigin) == 1 ||
+		e.Level < l.GetLevel() && l.packageMayWant(e.Level)
+	if !show && !origin {
+		return
+	}
+	c := callerAt(depth + 1)
+	if show {
+		e.Caller = c
+	}
+	if origin {
+		e.origin = c
+	}
+}
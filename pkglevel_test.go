@@ -0,0 +1,47 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPackageLevel(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	l.Debugf("before")
+	l.EnableDebugForPackage("github.com/couchbase")
+	l.Debugf("enabled for parent")
+	l.Tracef("trace")
+	l.SetPackageLevel("github.com/couchbase/clog", LevelWarning)
+	l.Debugf("more specific")
+	l.SetPackageLevel("github.com/couchbase/clog", LevelTrace)
+	l.Tracef("trace enabled")
+	l.SetPackageLevel("github.com/couchbase/cl", LevelDebug) // Not a parent
+	l.ClearPackageLevels()
+	l.Debugf("after")
+	l.Log("normal")
+
+	expect := "DEBU: enabled for parent\nTRAC: trace enabled\nnormal\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestPackageLevelOtherPackage(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.EnableDebugForPackage("github.com/couchbase/indexing")
+	l.Debugf("not from indexing")
+	if b.Len() != 0 {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
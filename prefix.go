@@ -0,0 +1,68 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Sets the prefix of the default logger's messages; see Logger.SetPrefix.
+func SetPrefix(prefix string) {
+	std.SetPrefix(prefix)
+}
+
+// Thread-safe API for setting a string, such as "indexer: ", prepended to
+// every message of the logger and the Loggers derived from it, even those
+// derived already, before the prefixes of WithPrefix. The Logger it was
+// derived from, and their other derived Loggers, are unaffected; a derived
+// Logger's prefix follows those of its parents. "" removes it.
+func (l *Logger) SetPrefix(prefix string) {
+	if prefix == "" {
+		atomic.StorePointer(&l.prefix, nil)
+		return
+	}
+	atomic.StoreInt32(&l.prefixed, 1)
+	atomic.StorePointer(&l.prefix, unsafe.Pointer(&prefix))
+}
+
+// Returns a Logger like l which prepends prefix to its messages, after l's
+// own prefix, in place of prefixes written into each format string:
+//
+//	orch := clog.Default().WithPrefix("orchestrator: ")
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	nl := l.withFields(l.fields)
+	nl.msgPrefix = l.msgPrefix + prefix
+	return nl
+}
+
+// Returns the prefix of the logger's messages.
+func (l *Logger) messagePrefix() string {
+	if atomic.LoadInt32(&l.prefixed) == 0 {
+		return l.msgPrefix
+	}
+	prefix := l.msgPrefix
+	for p := l; p != nil; p = p.parent {
+		if sp := atomic.LoadPointer(&p.prefix); sp != nil {
+			prefix = *(*string)(sp) + prefix
+		}
+	}
+	return prefix
+}
+
+// Prepends the logger's prefix to the entry's message, and to its callback
+// rendering.
+func (l *Logger) addPrefix(e *Entry) {
+	if p := l.messagePrefix(); p != "" {
+		e.Message = p + e.Message
+		if e.text != "" {
+			e.text = p + e.text
+		}
+	}
+}
@@ -0,0 +1,58 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrefix(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	orch := l.WithPrefix("orchestrator: ")
+	orch.Log("started")
+	l.SetPrefix("[n1] ")
+	orch.WithPrefix("plan: ").With("moves", 3).Warnf("computed")
+	l.Log("root")
+	l.SetPrefix("")
+	orch.Named("rebalance").Log("done")
+
+	exp := "orchestrator: started\n" +
+		"WARN: [n1] orchestrator: plan: computed moves=3\n" +
+		"[n1] root\n" +
+		"rebalance: orchestrator: done\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
+
+func TestPrefixDerived(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.SetPrefix("[n1] ")
+	child := l.With("bucket", "b1")
+	sibling := l.Named("dcp")
+	grandchild := child.WithPrefix("stream: ")
+	child.SetPrefix("<b1> ")
+	l.Log("root")
+	child.Log("child")
+	sibling.Log("sibling")
+	grandchild.Log("grandchild")
+
+	exp := "[n1] root\n" +
+		"[n1] <b1> child bucket=b1\n" +
+		"dcp: [n1] sibling\n" +
+		"[n1] <b1> stream: grandchild bucket=b1\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
@@ -0,0 +1,108 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column widths of FormatPretty.
+const (
+	prettyKeyWidth    = 12  // Keys are padded or truncated to this
+	prettyCallerWidth = 28  // Callers are truncated to this
+	prettyLineWidth   = 120 // Callers are right-aligned to this
+)
+
+// Writes an entry in FormatPretty. prefixLen is the length of the timestamp
+// written before it by the sink's log.Logger.
+func writePretty(b *bytes.Buffer, e *Entry, color bool, prefixLen int) {
+	color = color && colorEnabled()
+	c := func(code string) string {
+		if color {
+			return code
+		}
+		return ""
+	}
+	start := b.Len()
+	if e.textTime != "" {
+		b.WriteString(e.textTime)
+		b.WriteByte(' ')
+	}
+
+	label := e.prefix
+	if label == "" {
+		label = "INFO"
+	}
+	b.WriteString(c(levelColor(e)))
+	b.WriteString(label)
+	b.WriteString(c(reset))
+	b.WriteString(strings.Repeat(" ", 5-utf8.RuneCountInString(label)))
+
+	key := truncateRight(e.Key, prettyKeyWidth)
+	b.WriteString(c(currentKeyColor()))
+	b.WriteString(key)
+	b.WriteString(c(reset))
+	b.WriteString(strings.Repeat(" ", prettyKeyWidth+1-utf8.RuneCountInString(key)))
+
+	text := b.Len()
+	if e.text != "" {
+		writeMessage(b, e.text, e.escape)
+	} else {
+		writeMessage(b, e.Message, e.escape)
+	}
+	fields := e.Fields
+	if e.globals <= len(fields) {
+		fields = fields[e.globals:]
+	}
+	writeFields(b, fields)
+
+	if e.Caller != nil {
+		caller := truncateLeft(lastComponent(e.Caller.File)+":"+strconv.Itoa(e.Caller.Line), prettyCallerWidth)
+		col := prefixLen + 5 + prettyKeyWidth + 1 + utf8.RuneCount(b.Bytes()[text:])
+		if e.textTime != "" {
+			col += len(e.textTime) + 1
+		}
+		pad := prettyLineWidth - col - utf8.RuneCountInString(caller)
+		if pad < 1 || bytes.IndexByte(b.Bytes()[text:], '\n') >= 0 {
+			pad = 1
+		}
+		b.WriteString(strings.Repeat(" ", pad))
+		b.WriteString(c(dim))
+		b.WriteString(caller)
+		b.WriteString(c(reset))
+	}
+	if e.Stack != "" {
+		b.WriteByte('\n')
+		b.WriteString(c(dim))
+		b.WriteString(e.Stack)
+		b.WriteString(c(reset))
+	}
+	indentLines(b, start, e.continuation)
+}
+
+// Truncates s to n runes, ending it with an ellipsis if it was longer.
+func truncateRight(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// Truncates s to n runes, starting it with an ellipsis if it was longer.
+func truncateLeft(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return "…" + string(runes[len(runes)-n+1:])
+}
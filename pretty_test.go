@@ -0,0 +1,43 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestFormatPretty(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithKeys("*"), WithFlags(log.Ltime))
+	l.AddOutput(b, SinkOptions{Format: FormatPretty})
+	l.To("DCP", "stream opened")
+	l.Named("indexer.scheduler").Warnf("slow %d", 3)
+	l.Log("plain")
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Unexpected output %q", b.String())
+	}
+	for i, expect := range []string{
+		"INFO DCP          stream opened",
+		"WARN indexer.sch… slow 3",
+		"INFO              plain",
+	} {
+		if line := lines[i][9:]; !strings.HasPrefix(line, expect) {
+			t.Errorf("Line %d is %q, expected it to start %q", i, line, expect)
+		}
+	}
+	if line := lines[1]; len([]rune(line)) != prettyLineWidth || !strings.HasSuffix(line, " pretty_test.go:24") {
+		t.Errorf("Expected the caller right-aligned to column %d, got %q", prettyLineWidth, line)
+	}
+}
@@ -0,0 +1,156 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Limits the number of messages logged to a key in each period.
+type rateLimiter struct {
+	n   int
+	per time.Duration
+
+	mu         sync.Mutex
+	start      time.Time   // Start of the current period
+	count      int         // Messages in the current period
+	suppressed int         // Messages suppressed since the last one logged
+	last       *Entry      // Last message suppressed
+	timer      *time.Timer // Writes the count of those suppressed when the period ends
+}
+
+// Limits the messages logged to key by the default logger; see
+// Logger.SetKeyRateLimit.
+func SetKeyRateLimit(key string, n int, per time.Duration) {
+	std.SetKeyRateLimit(key, n, per)
+}
+
+// Limits the messages logged to key to n in each period of the given
+// duration. Further messages in the period are suppressed, and a count of
+// those suppressed is logged when the period ends, or before the next message
+// if that is sooner. n <= 0 removes the limit, logging any outstanding count.
+func (l *Logger) SetKeyRateLimit(key string, n int, per time.Duration) {
+	for {
+		opp := atomic.LoadPointer(&l.rateLimits)
+		newl := map[string]*rateLimiter{}
+		var old *rateLimiter
+		if opp != nil {
+			for k, r := range *(*map[string]*rateLimiter)(opp) {
+				newl[k] = r
+			}
+			old = newl[key]
+		}
+		if n > 0 {
+			newl[key] = &rateLimiter{n: n, per: per}
+		} else {
+			delete(newl, key)
+		}
+		if atomic.CompareAndSwapPointer(&l.rateLimits, opp, unsafe.Pointer(&newl)) {
+			if old != nil {
+				l.flushSuppressed(old, time.Now())
+			}
+			return
+		}
+	}
+}
+
+// Reports whether the entry may be logged, and how many were suppressed
+// since the last one that was. The first message suppressed in a period
+// starts a timer to log the count when the period ends.
+func (r *rateLimiter) allow(l *Logger, e *Entry) (bool, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.Time.Sub(r.start) >= r.per {
+		r.start, r.count = e.Time, 0
+	}
+	if r.count >= r.n {
+		r.suppressed++
+		r.last = e
+		if r.timer == nil {
+			var t *time.Timer
+			t = time.AfterFunc(time.Until(r.start.Add(r.per)), func() {
+				r.mu.Lock()
+				current := r.timer == t
+				r.mu.Unlock()
+				if current {
+					l.flushSuppressed(r, time.Now())
+				}
+			})
+			r.timer = t
+		}
+		return false, 0
+	}
+	r.count++
+	suppressed := r.take()
+	return true, suppressed
+}
+
+// Returns the number of messages suppressed and resets it, stopping the
+// timer and forgetting the last message. Must be called with mu held.
+func (r *rateLimiter) take() int {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	suppressed := r.suppressed
+	r.suppressed, r.last = 0, nil
+	return suppressed
+}
+
+// Writes the count of messages the limiter has suppressed, if any.
+func (l *Logger) flushSuppressed(r *rateLimiter, now time.Time) {
+	r.mu.Lock()
+	last := r.last
+	suppressed := r.take()
+	r.mu.Unlock()
+	if suppressed > 0 {
+		l.writeSuppressed(last, suppressed, now)
+	}
+}
+
+// Writes the count of all messages suppressed by rate limits.
+func (l *Logger) flushRateLimits() {
+	if lp := atomic.LoadPointer(&l.rateLimits); lp != nil {
+		now := time.Now()
+		for _, r := range *(*map[string]*rateLimiter)(lp) {
+			l.flushSuppressed(r, now)
+		}
+	}
+}
+
+// Writes a count of suppressed messages, like e, with the given time.
+func (l *Logger) writeSuppressed(e *Entry, suppressed int, now time.Time) {
+	summary := *e
+	summary.Time = now
+	summary.Message = fmt.Sprintf("… %d similar messages suppressed", suppressed)
+	summary.text, summary.Fields, summary.Caller = "", nil, nil
+	l.write(&summary)
+}
+
+// Applies the rate limit, if any, of the entry's key. Returns false if the
+// entry should be suppressed.
+func (l *Logger) rateLimit(e *Entry) bool {
+	lp := atomic.LoadPointer(&l.rateLimits)
+	if lp == nil {
+		return true
+	}
+	r := (*(*map[string]*rateLimiter)(lp))[e.Key]
+	if r == nil {
+		return true
+	}
+	ok, suppressed := r.allow(l, e)
+	if ok && suppressed > 0 {
+		l.writeSuppressed(e, suppressed, e.Time)
+	}
+	return ok
+}
@@ -0,0 +1,74 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyRateLimit(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("conn", "other"))
+	DisableColor()
+	l.SetKeyRateLimit("conn", 2, 50*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		l.To("conn", "reconnect %d", i)
+		l.To("other", "other %d", i)
+	}
+	time.Sleep(60 * time.Millisecond)
+	l.To("conn", "reconnect %d", 5)
+
+	exp := []string{
+		"conn: reconnect 0", "other: other 0",
+		"conn: reconnect 1", "other: other 1",
+		"other: other 2", "other: other 3", "other: other 4",
+		"conn: … 3 similar messages suppressed",
+		"conn: reconnect 5",
+	}
+	if got := strings.TrimSpace(b.String()); got != strings.Join(exp, "\n") {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+
+	b.Reset()
+	l.SetKeyRateLimit("conn", 0, 0)
+	for i := 0; i < 5; i++ {
+		l.To("conn", "reconnect %d", i)
+	}
+	if n := strings.Count(b.String(), "\n"); n != 5 {
+		t.Errorf("Expected the limit to be removed, got %d lines", n)
+	}
+}
+
+func TestKeyRateLimitQuiet(t *testing.T) {
+	b := &lockedBuffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("conn"))
+	DisableColor()
+	l.SetKeyRateLimit("conn", 1, 20*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		l.To("conn", "reconnect %d", i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	exp := "conn: reconnect 0\nconn: … 2 similar messages suppressed\n"
+	if got := b.String(); got != exp {
+		t.Errorf("Expected the count when the period ended, got:\n%s", got)
+	}
+
+	l.To("conn", "reconnect 3")
+	l.To("conn", "reconnect 4")
+	l.Flush()
+	exp += "conn: reconnect 3\nconn: … 1 similar messages suppressed\n"
+	if got := b.String(); got != exp {
+		t.Errorf("Expected the count on Flush, got:\n%s", got)
+	}
+}
@@ -0,0 +1,250 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// How tagged data is rendered by Tag.
+type RedactionLevel int32
+
+const (
+	RedactNone    = RedactionLevel(iota) // Data is logged as is, without tags
+	RedactPartial                        // Data is wrapped in tags such as <ud>
+	RedactFull                           // As RedactPartial, but UserData is hashed
+)
+
+var redactionLevel = int32(RedactPartial)
+
+// Salt prepended to UserData before hashing in RedactFull mode.
+var redactionSalt unsafe.Pointer
+
+func init() {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	SetRedactionSalt(hex.EncodeToString(salt))
+}
+
+// Thread-safe API for setting how Tag renders data. (default RedactPartial)
+func SetRedactionLevel(to RedactionLevel) {
+	atomic.StoreInt32(&redactionLevel, int32(to))
+}
+
+// Thread-safe API for fetching the redaction level.
+func GetRedactionLevel() RedactionLevel {
+	return RedactionLevel(atomic.LoadInt32(&redactionLevel))
+}
+
+// Sets the salt used when hashing UserData in RedactFull mode. By default a
+// random salt is chosen at startup, so hashes are consistent within one
+// process's logs but can't be matched against a dictionary of known values.
+func SetRedactionSalt(salt string) {
+	atomic.StorePointer(&redactionSalt, unsafe.Pointer(&salt))
+}
+
+// Returns the hex SHA1 hash of the salted string, as used for UserData in
+// RedactFull mode.
+func redactionHash(s string) string {
+	salt := *(*string)(atomic.LoadPointer(&redactionSalt))
+	sum := sha1.Sum([]byte(salt + s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Data tagged with its category by UD, MD or SD. It is only formatted, and
+// tagged according to the redaction level, when the message is logged.
+type Tagged struct {
+	category ContentCategory
+	value    interface{}
+}
+
+// Tags v as UserData, like Tag(UserData, v), but lazily.
+func UD(v interface{}) Tagged {
+	return Tagged{UserData, v}
+}
+
+// Tags v as MetaData, like Tag(MetaData, v), but lazily.
+func MD(v interface{}) Tagged {
+	return Tagged{MetaData, v}
+}
+
+// Tags v as SystemData, like Tag(SystemData, v), but lazily.
+func SD(v interface{}) Tagged {
+	return Tagged{SystemData, v}
+}
+
+func (t Tagged) String() string {
+	return tagString(t.category, fmt.Sprint(t.value))
+}
+
+// Formats the value with the verb and flags the Tagged was formatted with,
+// then tags it.
+func (t Tagged) Format(s fmt.State, verb rune) {
+	io.WriteString(s, tagString(t.category, fmt.Sprintf(fmt.FormatString(s, verb), t.value)))
+}
+
+func (t Tagged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// Sets whether the default logger's JSON outputs keep redaction tags; see
+// Logger.SetJSONRedactionTags.
+func SetJSONRedactionTags(keep bool) {
+	std.SetJSONRedactionTags(keep)
+}
+
+// Thread-safe API for setting whether JSON outputs keep the redaction tags in
+// messages, for RedactLogs to find. Otherwise the tags are removed from "msg"
+// and the tagged values are listed by category in "redact", for structured
+// redaction tools:
+//
+//	{"msg":"get doc1","redact":{"ud":["doc1"]}}
+//
+// Tagged fields are grouped by category either way. (default false)
+func (l *Logger) SetJSONRedactionTags(keep bool) {
+	atomic.StoreInt32(&l.jsonTags, btoi(keep))
+}
+
+// Removes the redaction tags from a message, returning it with the tagged
+// values by category. An unterminated tag extends to the end of the message,
+// and values tagged within another are only part of the outer one.
+func untagMessage(msg string) (string, [numTypes][]string) {
+	var values [numTypes][]string
+	var b strings.Builder
+	for {
+		start, category := -1, 0
+		for c, tag := range tags {
+			if i := strings.Index(msg, "<"+tag+">"); i >= 0 && (start < 0 || i < start) {
+				start, category = i, c
+			}
+		}
+		if start < 0 {
+			b.WriteString(msg)
+			return b.String(), values
+		}
+		b.WriteString(msg[:start])
+		open, close := "<"+tags[category]+">", "</"+tags[category]+">"
+		msg = msg[start+len(open):]
+		end, next := closingTag(msg, open, close), len(msg)
+		if end < 0 {
+			end = len(msg)
+		} else {
+			next = end + len(close)
+		}
+		value, _ := untagMessage(msg[:end])
+		values[category] = append(values[category], value)
+		b.WriteString(value)
+		msg = msg[next:]
+	}
+}
+
+// Returns the index of the close tag matching an open tag preceding s, or -1.
+func closingTag(s, open, close string) int {
+	depth := 1
+	for i := 0; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], open) {
+			depth++
+		} else if strings.HasPrefix(s[i:], close) {
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// What RedactLogs does with UserData in captured logs.
+type RedactAction int
+
+const (
+	RedactKeep   = RedactAction(iota) // UserData is left as is
+	RedactHash                        // UserData is hashed, as by RedactFull
+	RedactRemove                      // UserData is removed, leaving empty tags
+)
+
+const (
+	udOpen  = "<ud>"
+	udClose = "</ud>"
+)
+
+// Copies logs already written by clog from src to dst, rewriting the contents
+// of <ud> tags according to action, for instance to sanitize logs for a
+// support bundle. Tags may span lines and be nested; an unterminated tag
+// extends to the end of the logs. MetaData and SystemData are left as is.
+// JSON outputs only have tags to find if SetJSONRedactionTags is enabled.
+func RedactLogs(dst io.Writer, src io.Reader, action RedactAction) error {
+	if action == RedactKeep {
+		_, err := io.Copy(dst, src)
+		return err
+	}
+	r := bufio.NewReader(src)
+	w := bufio.NewWriter(dst)
+	var ud strings.Builder // Contents of the open tag
+	depth := 0
+	for {
+		line, err := r.ReadString('\n')
+		for line != "" {
+			open, close := strings.Index(line, udOpen), strings.Index(line, udClose)
+			switch {
+			case depth == 0 && open < 0:
+				w.WriteString(line)
+				line = ""
+			case depth == 0:
+				w.WriteString(line[:open+len(udOpen)])
+				line = line[open+len(udOpen):]
+				depth++
+			case open >= 0 && (close < 0 || open < close):
+				ud.WriteString(line[:open+len(udOpen)])
+				line = line[open+len(udOpen):]
+				depth++
+			case close < 0:
+				ud.WriteString(line)
+				line = ""
+			case depth > 1:
+				ud.WriteString(line[:close+len(udClose)])
+				line = line[close+len(udClose):]
+				depth--
+			default:
+				ud.WriteString(line[:close])
+				w.WriteString(redactUserData(ud.String(), action) + udClose)
+				ud.Reset()
+				line = line[close+len(udClose):]
+				depth--
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if depth > 0 {
+		w.WriteString(redactUserData(ud.String(), action))
+	}
+	return w.Flush()
+}
+
+func redactUserData(s string, action RedactAction) string {
+	switch action {
+	case RedactHash:
+		return redactionHash(s)
+	case RedactRemove:
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRedactionLevels(t *testing.T) {
+	defer SetRedactionLevel(RedactPartial)
+	SetRedactionSalt("salt")
+
+	tests := []struct {
+		level    RedactionLevel
+		category ContentCategory
+		exp      string
+	}{
+		{RedactNone, UserData, "k123"},
+		{RedactPartial, UserData, "<ud>k123</ud>"},
+		{RedactPartial, MetaData, "<md>k123</md>"},
+		// echo -n saltk123 | sha1sum
+		{RedactFull, UserData, "<ud>5b2fce2a789b57d18a61ae9cc84f59e28e477cd0</ud>"},
+		{RedactFull, SystemData, "<sd>k123</sd>"},
+	}
+
+	for _, test := range tests {
+		SetRedactionLevel(test.level)
+		if got := fmt.Sprintf("%s", Tag(test.category, []byte("k123"))); got != test.exp {
+			t.Errorf("Level %d: expected %q, got %q", test.level, test.exp, got)
+		}
+	}
+}
+
+func TestRedactLogs(t *testing.T) {
+	SetRedactionSalt("salt")
+	in := "WARN: get <ud>k123</ud> from <md>b1</md>\n" +
+		"value <ud>line1\nline2 <ud>nested</ud></ud> done\n" +
+		"unterminated <ud>secret"
+
+	tests := []struct {
+		action RedactAction
+		exp    string
+	}{
+		{RedactKeep, in},
+		{RedactRemove, "WARN: get <ud></ud> from <md>b1</md>\nvalue <ud></ud> done\nunterminated <ud>"},
+		// echo -n saltk123 | sha1sum
+		{RedactHash, "WARN: get <ud>5b2fce2a789b57d18a61ae9cc84f59e28e477cd0</ud> from <md>b1</md>\n" +
+			"value <ud>" + redactionHash("line1\nline2 <ud>nested</ud>") + "</ud> done\n" +
+			"unterminated <ud>" + redactionHash("secret")},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		if err := RedactLogs(&b, strings.NewReader(in), test.action); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.exp {
+			t.Errorf("Action %d: expected %q, got %q", test.action, test.exp, b.String())
+		}
+	}
+}
+
+func TestTagged(t *testing.T) {
+	defer SetRedactionLevel(RedactPartial)
+	SetRedactionSalt("salt")
+
+	doc := UD("k123")
+	if got := fmt.Sprintf("get %s from %v, %5.1f", doc, MD("b1"), SD(2.25)); got !=
+		"get <ud>k123</ud> from <md>b1</md>, <sd>  2.2</sd>" {
+		t.Errorf("Unexpected partial redaction %q", got)
+	}
+	SetRedactionLevel(RedactFull)
+	if got := doc.String(); got != "<ud>5b2fce2a789b57d18a61ae9cc84f59e28e477cd0</ud>" {
+		t.Errorf("Unexpected full redaction %q", got)
+	}
+	SetRedactionLevel(RedactNone)
+	if got := fmt.Sprint(doc); got != "k123" {
+		t.Errorf("Unexpected unredacted value %q", got)
+	}
+
+	calls := 0
+	lazy := UD(Lazy(func() interface{} { calls++; return "k" }))
+	l := New(WithOutput(io.Discard))
+	l.Debugf("hidden %s", lazy)
+	if calls != 0 {
+		t.Errorf("Expected the value not to be formatted")
+	}
+}
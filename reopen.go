@@ -0,0 +1,77 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Implemented by outputs which can reopen their files.
+type reopener interface {
+	Reopen() error
+}
+
+// Reopens the default logger's files; see Logger.Reopen.
+func Reopen() error {
+	return std.Reopen()
+}
+
+// Reopens the files the logger writes to, such as those set by
+// SetFileOutput or Configure, after they have been moved aside by an external
+// tool like logrotate. Returns the first error.
+func (l *Logger) Reopen() error {
+	var first error
+	for _, s := range l.loadSinks() {
+		var w interface{} = s.ew
+		if s.ew == nil {
+			w = s.logger.Writer()
+		}
+		if r, ok := w.(reopener); ok {
+			if err := r.Reopen(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Reopens the default logger's files on signals; see Logger.HandleSignals.
+func HandleSignals(sigs ...os.Signal) (stop func()) {
+	return std.HandleSignals(sigs...)
+}
+
+// Reopens the logger's files whenever the process receives one of the given
+// signals, SIGHUP by default, as sent by logrotate's postrotate scripts.
+// Call stop to stop handling the signals.
+func (l *Logger) HandleSignals(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := l.Reopen(); err != nil {
+					l.Errorf("clog: reopening log files: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
@@ -0,0 +1,58 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := New(WithFlags(0))
+	if err := l.Configure(Config{Outputs: []OutputConfig{{Path: path}}}); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Configure(Config{Outputs: []OutputConfig{{Path: "stderr"}}})
+	stop := l.HandleSignals()
+	defer stop()
+
+	l.Log("before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("Can't send SIGHUP: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); !fileExists(path); {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the file to be reopened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	l.Log("after")
+
+	for name, exp := range map[string]string{path + ".1": "before\n", path: "after\n"} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != exp {
+			t.Errorf("Expected %q in %s, got %q", exp, filepath.Base(name), b)
+		}
+	}
+	if err := l.Reopen(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,132 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// RecentOptions configure the buffer of recent entries kept by
+// EnableRecentEntries.
+type RecentOptions struct {
+	Size        int      // Number of entries kept (0 = disabled)
+	Level       LogLevel // Minimum level kept, even if below the log level
+	DumpOnError bool     // Write kept entries which were filtered from the output before an error
+}
+
+// A lock-free ring buffer of recent entries.
+type ring struct {
+	opts  RecentOptions
+	next  uint64           // Index of the next slot to fill, accessed atomically
+	slots []unsafe.Pointer // *ringEntry, accessed atomically
+}
+
+type ringEntry struct {
+	e       *Entry
+	written bool  // Whether the entry passed the log level
+	dumped  int32 // Set atomically once written by a dump
+}
+
+// Keeps recent entries of the default logger; see Logger.EnableRecentEntries.
+func EnableRecentEntries(opts RecentOptions) {
+	std.EnableRecentEntries(opts)
+}
+
+// Returns the default logger's recent entries; see Logger.RecentEntries.
+func RecentEntries() []string {
+	return std.RecentEntries()
+}
+
+// Keeps the most recent entries at or above opts.Level in memory, including
+// those filtered from the output by the log level, for RecentEntries. With
+// DumpOnError, messages at LevelError and above are preceded in the output by
+// the kept entries which were filtered from it. This replaces any entries
+// kept previously.
+func (l *Logger) EnableRecentEntries(opts RecentOptions) {
+	var r *ring
+	if opts.Size > 0 {
+		r = &ring{opts: opts, slots: make([]unsafe.Pointer, opts.Size)}
+	}
+	atomic.StorePointer(&l.recent, unsafe.Pointer(r))
+	l.updateFloor()
+}
+
+// Returns the kept entries as lines of text, oldest first.
+func (l *Logger) RecentEntries() []string {
+	r := l.loadRing()
+	if r == nil {
+		return nil
+	}
+	var lines []string
+	r.each(func(re *ringEntry) {
+		lines = append(lines, re.e.Time.Format("2006/01/02 15:04:05.000000 ")+
+			formatText(re.e, false))
+	})
+	return lines
+}
+
+func (l *Logger) loadRing() *ring {
+	return (*ring)(atomic.LoadPointer(&l.recent))
+}
+
+// Reports whether messages at the given level may be logged or kept. The
+// floor check is inlined into the logging functions, so that messages below
+// every level cost a single atomic load. Built with clog_nodebug, debug and
+// trace messages are never wanted, whichever function logs them.
+func (l *Logger) wants(level LogLevel) bool {
+	return (DebugEnabled || level > LevelDebug) &&
+		level >= LogLevel(l.floor.Load()) && l.mayWant(level)
+}
+
+func (l *Logger) mayWant(level LogLevel) bool {
+	if level >= l.GetLevel() {
+		return true
+	}
+	if r := l.loadRing(); r != nil && level >= r.opts.Level {
+		return true
+	}
+	return l.packageMayWant(level) || atomic.LoadInt32(&l.rules) > 0
+}
+
+func (r *ring) add(e *Entry, written bool) {
+	i := atomic.AddUint64(&r.next, 1) - 1
+	atomic.StorePointer(&r.slots[i%uint64(len(r.slots))],
+		unsafe.Pointer(&ringEntry{e: e, written: written}))
+}
+
+// Calls f with each kept entry, oldest first.
+func (r *ring) each(f func(*ringEntry)) {
+	next := atomic.LoadUint64(&r.next)
+	n := uint64(len(r.slots))
+	for i := next; i < next+n; i++ {
+		if re := (*ringEntry)(atomic.LoadPointer(&r.slots[i%n])); re != nil {
+			f(re)
+		}
+	}
+}
+
+// Keeps the entry if there is a ring. Before an error, writes the kept
+// entries which were filtered from the output, if configured to.
+func (l *Logger) keepRecent(e *Entry, written bool) {
+	r := l.loadRing()
+	if r == nil {
+		return
+	}
+	if written && r.opts.DumpOnError && e.Level >= LevelError && e.Level != levelTemp {
+		r.each(func(re *ringEntry) {
+			if !re.written && atomic.CompareAndSwapInt32(&re.dumped, 0, 1) {
+				l.write(re.e)
+			}
+		})
+	}
+	if e.Level >= r.opts.Level {
+		r.add(e, written)
+	}
+}
@@ -0,0 +1,62 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecentEntries(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.EnableRecentEntries(RecentOptions{Size: 3, Level: LevelDebug})
+
+	l.Tracef("trace")
+	for i := 1; i <= 4; i++ {
+		l.Debugf("debug %d", i)
+	}
+	l.Log("normal")
+
+	if b.String() != "normal\n" {
+		t.Errorf("Expected only the normal message in the output, got %q", b.String())
+	}
+	recent := l.RecentEntries()
+	if len(recent) != 3 || !strings.HasSuffix(recent[0], " DEBU: debug 3") ||
+		!strings.HasSuffix(recent[2], " normal") {
+		t.Errorf("Unexpected recent entries %q", recent)
+	}
+}
+
+func TestRecentEntriesDumpOnError(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.EnableRecentEntries(RecentOptions{Size: 10, Level: LevelTrace, DumpOnError: true})
+
+	l.Debugf("debug 1")
+	l.Log("normal")
+	l.Tracef("trace 1")
+	l.Errorf("failed")
+	l.Errorf("failed again")
+
+	exp := "normal\nDEBU: debug 1\nTRAC: trace 1\nERRO: failed\nERRO: failed again\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+
+	l.EnableRecentEntries(RecentOptions{})
+	if l.RecentEntries() != nil {
+		t.Errorf("Expected no entries once disabled")
+	}
+}
@@ -0,0 +1,370 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotationOptions control when a RotatingFile is rotated and which of the
+// rotated backups are kept.
+type RotationOptions struct {
+	MaxSizeMB  int           `json:"maxSizeMB,omitempty" yaml:"maxSizeMB,omitempty"`   // Rotate before the file exceeds this size (0 = never)
+	MaxAge     time.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`         // Remove backups older than this (0 = keep)
+	MaxBackups int           `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"` // Number of backups to keep (0 = all)
+	Compress   bool          `json:"compress,omitempty" yaml:"compress,omitempty"`     // Gzip backups after rotation
+
+	// Remove the oldest backups once the backups' total size exceeds this
+	// (0 = no limit)
+	MaxTotalSizeMB int `json:"maxTotalSizeMB,omitempty" yaml:"maxTotalSizeMB,omitempty"`
+
+	// Set if other processes write to the same file; Unix only. Rotation is
+	// then serialized by an advisory lock on path+".lock", and each
+	// write first checks whether another process has rotated the file.
+	// A write racing with another process's rotation may still be appended
+	// to the backup, but lines are never split.
+	Shared bool `json:"shared,omitempty" yaml:"shared,omitempty"`
+}
+
+// Layout of the timestamp appended to the names of rotated files. It sorts
+// lexically in time order.
+const backupTimeFormat = "20060102T150405.000"
+
+// How long writes go on appending to the file after a failed rotation,
+// before rotation is tried again.
+const rotateRetryInterval = time.Minute
+
+// Renames files; replaced by tests.
+var renameFile = os.Rename
+
+// A RotatingFile is an io.WriteCloser appending to a file, which is renamed
+// aside and replaced by a new file when it grows past the configured size.
+// It is safe for concurrent use. Each Write is a single append to the file,
+// so lines written by other processes appending to it aren't interleaved
+// with them.
+type RotatingFile struct {
+	path string
+	opts RotationOptions
+
+	mu      sync.Mutex // Protects the fields below
+	file    *os.File   // Nil if closed, or if reopening it failed
+	size    int64
+	closed  bool      // Set by Close
+	retryAt time.Time // Rotation isn't tried before this, after a failure
+
+	millMu sync.Mutex     // Serializes compression and removal of backups
+	millWg sync.WaitGroup // Outstanding mill goroutines
+}
+
+// OpenRotatingFile opens (creating if necessary) the file at path for
+// appending. Shared files are only supported on Unix.
+func OpenRotatingFile(path string, opts RotationOptions) (*RotatingFile, error) {
+	if opts.Shared && !lockSupported {
+		return nil, fmt.Errorf("clog: shared rotating files aren't supported on %s", runtime.GOOS)
+	}
+	f := &RotatingFile{path: path, opts: opts}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Sets the default logger's output to a RotatingFile at path.
+func SetFileOutput(path string, opts RotationOptions) error {
+	return std.SetFileOutput(path, opts)
+}
+
+// Sets the logger's output to a RotatingFile at path. A file previously set
+// by SetFileOutput is closed.
+func (l *Logger) SetFileOutput(path string, opts RotationOptions) error {
+	f, err := OpenRotatingFile(path, opts)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(f)
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = f
+	return nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p to the file, first rotating it if p would take it past
+// MaxSizeMB. If rotation fails, p is appended to the file regardless, and
+// rotation is retried after a minute.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return 0, err
+	}
+	if f.opts.Shared {
+		if err := f.follow(); err != nil {
+			return 0, err
+		}
+	}
+	max := int64(f.opts.MaxSizeMB) * 1024 * 1024
+	if max > 0 && f.size > 0 && f.size+int64(len(p)) > max && !time.Now().Before(f.retryAt) {
+		if err := f.rotateIfOver(max - int64(len(p))); err != nil {
+			f.retryAt = time.Now().Add(rotateRetryInterval)
+			if f.file == nil {
+				return 0, err
+			}
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate renames the current file aside and starts a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// Opens the file again if reopening it failed earlier.
+func (f *RotatingFile) ensureOpen() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+// Rotates the file if it is larger than size. If the file is shared, it is
+// locked and checked again first, in case another process rotated it.
+func (f *RotatingFile) rotateIfOver(size int64) error {
+	if !f.opts.Shared {
+		return f.rotate()
+	}
+	unlock, err := lockFile(f.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := f.follow(); err != nil || f.size <= size {
+		return err
+	}
+	return f.rotate()
+}
+
+// Reopens the path if another process has rotated the file, and updates the
+// size with other processes' writes.
+func (f *RotatingFile) follow() error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	if cur, err := os.Stat(f.path); err == nil && os.SameFile(cur, info) {
+		f.size = info.Size()
+		return nil
+	}
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	return f.open()
+}
+
+// Renames the file aside and opens a new one. If that fails, the file is
+// reopened for appending, so that writes go on.
+func (f *RotatingFile) rotate() error {
+	cerr := f.file.Close()
+	f.file = nil
+	backup := f.backupName(time.Now())
+	if err := renameFile(f.path, backup); err != nil && !os.IsNotExist(err) {
+		f.open()
+		return err
+	}
+	if err := f.open(); err != nil {
+		if renameFile(backup, f.path) == nil {
+			f.open()
+		}
+		return err
+	}
+	f.millWg.Add(1)
+	go f.mill()
+	return cerr
+}
+
+// Returns an unused backup name for the file, rotated at the given time.
+func (f *RotatingFile) backupName(t time.Time) string {
+	for {
+		name := f.path + "." + t.UTC().Format(backupTimeFormat)
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Reopen closes the file and opens the path again, without rotating it. Use
+// it when the file has been renamed by an external tool such as logrotate.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Sync commits the file's contents to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file, waiting for any compression of backups to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+	f.millWg.Wait()
+	return err
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// Lists the rotated backups of the file, newest first.
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []backupFile
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, f.path+"."), ".gz")
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue // Not one of ours
+		}
+		backups = append(backups, backupFile{m, t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// Compresses and removes backups according to the RotationOptions.
+func (f *RotatingFile) mill() {
+	defer f.millWg.Done()
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+	if f.opts.Shared {
+		unlock, err := lockFile(f.path + ".lock")
+		if err != nil {
+			return
+		}
+		defer unlock()
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+	maxTotal := int64(f.opts.MaxTotalSizeMB) * 1024 * 1024
+	var total int64
+	for i, b := range backups {
+		if (f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups) ||
+			(f.opts.MaxAge > 0 && time.Since(b.time) > f.opts.MaxAge) {
+			os.Remove(b.path)
+			continue
+		}
+		if f.opts.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if compressFile(b.path) == nil {
+				b.path += ".gz"
+			}
+		}
+		if maxTotal > 0 {
+			if info, err := os.Stat(b.path); err == nil {
+				if total += info.Size(); total > maxTotal {
+					os.Remove(b.path)
+				}
+			}
+		}
+	}
+}
+
+// Gzips src to src.gz, then removes src.
+func compressFile(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(src+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(src + ".gz")
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,195 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, err := OpenRotatingFile(path, RotationOptions{MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := bytes.Repeat([]byte("x"), 1023)
+	line = append(line, '\n')
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1024; i++ {
+				if _, err := f.Write(line); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("Expected 2 backups, got %v", backups)
+	}
+	for _, name := range append(backups, path) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 1024*1024 || len(data)%len(line) != 0 {
+			t.Errorf("Unexpected size %d of %s", len(data), name)
+		}
+	}
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	l := New(WithFlags(0))
+	if err := l.SetFileOutput(path, RotationOptions{Compress: true}); err != nil {
+		t.Fatal(err)
+	}
+	l.Log("before")
+	if err := l.file.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Log("after")
+	l.file.Close()
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Errorf("Expected one compressed backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "after\n" {
+		t.Errorf("Expected only the second message in %s, got %q", path, data)
+	}
+}
+
+func TestRotatingFileMaxTotalSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, err := OpenRotatingFile(path, RotationOptions{MaxTotalSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := bytes.Repeat([]byte("x"), 400*1024)
+	for i := 0; i < 4; i++ {
+		f.Write(chunk)
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("Expected the 2 newest backups to fit in 1MB, got %v", backups)
+	}
+}
+
+func TestRotatingFileShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	opts := RotationOptions{MaxSizeMB: 1, Shared: true}
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	var wg sync.WaitGroup
+	for p := 0; p < 2; p++ { // Each RotatingFile stands for a process
+		f, err := OpenRotatingFile(path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer f.Close()
+			for i := 0; i < 2048; i++ {
+				if _, err := f.Write(line); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	files, _ := filepath.Glob(path + "*")
+	lines := 0
+	for _, name := range files {
+		if strings.HasSuffix(name, ".lock") {
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 1024*1024+64*1024 { // Racing writes may follow rotation
+			t.Errorf("%s grew to %d bytes", name, len(data))
+		}
+		for _, l := range bytes.SplitAfter(data, []byte("\n")) {
+			if len(l) > 0 && !bytes.Equal(l, line) {
+				t.Fatalf("Partial line in %s", name)
+			}
+			if len(l) > 0 {
+				lines++
+			}
+		}
+	}
+	if lines != 4096 {
+		t.Errorf("Expected 4096 lines in %v, got %d", files, lines)
+	}
+}
+
+func TestRotatingFileRenameFails(t *testing.T) {
+	defer func(old func(string, string) error) { renameFile = old }(renameFile)
+	renameFile = func(string, string) error { return os.ErrPermission }
+
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, err := OpenRotatingFile(path, RotationOptions{MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for i := 0; i < 1100; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+	if err := f.Rotate(); err != os.ErrPermission {
+		t.Errorf("Expected the rename error from Rotate, got %v", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		t.Errorf("Write after a failed Rotate failed: %v", err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Size() != 1101*1024 {
+		t.Errorf("Expected every line to be appended, got %v, %v", info, err)
+	}
+	if backups, _ := filepath.Glob(path + ".*"); len(backups) != 0 {
+		t.Errorf("Expected no backups, got %v", backups)
+	}
+}
+
+func TestRotatingFileSharedUnsupported(t *testing.T) {
+	if lockSupported {
+		t.Skip("shared files are supported")
+	}
+	path := filepath.Join(t.TempDir(), "test.log")
+	if _, err := OpenRotatingFile(path, RotationOptions{Shared: true}); err == nil {
+		t.Error("Expected an error opening a shared file")
+	}
+}
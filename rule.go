@@ -0,0 +1,89 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+// A Matcher selects the entries to which a rule applies.
+type Matcher func(e *Entry) bool
+
+// An Action rewrites the entries selected by a rule.
+type Action func(e *Entry)
+
+// Matches entries whose messages match the regular expression. It panics if
+// expr doesn't compile, as regexp.MustCompile does.
+func MatchRegexp(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return func(e *Entry) bool { return re.MatchString(e.Message) }
+}
+
+// Matches entries with the given key, or logger name.
+func MatchKey(key string) Matcher {
+	return func(e *Entry) bool { return e.Key == key }
+}
+
+// Matches entries matched by all of the matchers.
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(e *Entry) bool {
+		for _, m := range matchers {
+			if !m(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Lowers the level of entries above the given level to it.
+func DemoteTo(level LogLevel) Action {
+	return func(e *Entry) {
+		if e.Level > level {
+			e.Level = level
+		}
+	}
+}
+
+// Raises the level of entries below the given level to it.
+func PromoteTo(level LogLevel) Action {
+	return func(e *Entry) {
+		if e.Level < level {
+			e.Level = level
+		}
+	}
+}
+
+// Adds a rule to the default logger; see Logger.AddRule.
+func AddRule(m Matcher, a Action) {
+	std.AddRule(m, a)
+}
+
+// Adds a rule applying the action to the entries selected by the matcher,
+// so that known noisy messages, such as those of third-party packages logged
+// through an adapter, can be reclassified without changing the code logging
+// them. For example:
+//
+//	AddRule(MatchRegexp("connection refused"), DemoteTo(LevelDebug))
+//
+// Rules are hooks (see AddHook), which run before the level is checked, so
+// PromoteTo can raise a message the level would filter out. While there are
+// rules, LevelEnabled reports true for every level and messages below the
+// level are formatted before being dropped. ClearHooks removes the rules.
+func (l *Logger) AddRule(m Matcher, a Action) {
+	l.AddHook(func(e Entry) (Entry, bool) {
+		if m(&e) {
+			a(&e)
+		}
+		return e, true
+	})
+	atomic.AddInt32(&l.rules, 1)
+	l.updateFloor()
+}
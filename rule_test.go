@@ -0,0 +1,64 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.AddRule(MatchRegexp("connection refused"), DemoteTo(LevelDebug))
+	l.AddRule(MatchAll(MatchKey("rebalance"), MatchRegexp("^failed")), PromoteTo(LevelError))
+
+	l.Warnf("dial 10.0.0.1:11210: connection refused")
+	l.Warnf("disk full")
+	l.Named("rebalance").Warnf("failed to move vb 12")
+	l.Named("rebalance").Log("progress 50%%")
+	l.SetLevel(LevelDebug)
+	l.Errorf("connection refused again")
+
+	expect := "WARN: disk full\n" +
+		"ERRO: rebalance: failed to move vb 12\n" +
+		"rebalance: progress 50%\n" +
+		"DEBU: connection refused again\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestPromoteFiltered(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.AddRule(MatchAll(MatchKey("rebalance"), MatchRegexp("^failed")), PromoteTo(LevelError))
+
+	l.Named("rebalance").Debugf("failed to stream vb 13")
+	l.Named("rebalance").Debugf("streaming vb 14")
+	l.Debugf("failed elsewhere")
+	if expect := "ERRO: rebalance: failed to stream vb 13\n"; b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestClearRules(t *testing.T) {
+	l := New(WithOutput(&bytes.Buffer{}), WithLevel(LevelError))
+	l.AddRule(MatchKey("rebalance"), PromoteTo(LevelError))
+	if !l.LevelEnabled(LevelNormal) {
+		t.Errorf("Expected messages below the level to reach the rules")
+	}
+	l.ClearHooks()
+	if l.LevelEnabled(LevelNormal) {
+		t.Errorf("Expected messages below the level to be filtered once the rules are cleared")
+	}
+}
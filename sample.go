@@ -0,0 +1,107 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Logs a sample of the messages sent to a key.
+type sampler struct {
+	everyN uint64  // Log every Nth message, or
+	p      float64 // log each message with this probability
+	count  uint64  // Messages seen, accessed atomically
+	rate   string  // Annotation added to logged messages
+}
+
+// For test fixture
+var sampleRand = rand.Float64
+
+// Samples the messages logged to key by the default logger; see
+// Logger.SetKeySampling.
+func SetKeySampling(key string, everyN int) {
+	std.SetKeySampling(key, everyN)
+}
+
+// Samples the messages logged to key by the default logger; see
+// Logger.SetKeySamplingRate.
+func SetKeySamplingRate(key string, p float64) {
+	std.SetKeySamplingRate(key, p)
+}
+
+// Logs only the first of every everyN messages sent to key. Logged messages
+// carry a "sampled" field giving the rate, e.g. sampled=1/100. everyN <= 1
+// removes the sampling.
+func (l *Logger) SetKeySampling(key string, everyN int) {
+	var s *sampler
+	if everyN > 1 {
+		s = &sampler{everyN: uint64(everyN), rate: "1/" + strconv.Itoa(everyN)}
+	}
+	l.setSampler(key, s)
+}
+
+// Logs each message sent to key with probability p. Logged messages carry a
+// "sampled" field giving the probability, e.g. sampled=0.01. p >= 1 removes
+// the sampling.
+func (l *Logger) SetKeySamplingRate(key string, p float64) {
+	var s *sampler
+	if p < 1 {
+		s = &sampler{p: p, rate: strconv.FormatFloat(p, 'g', -1, 64)}
+	}
+	l.setSampler(key, s)
+}
+
+func (l *Logger) setSampler(key string, s *sampler) {
+	for {
+		opp := atomic.LoadPointer(&l.samplers)
+		news := map[string]*sampler{}
+		if opp != nil {
+			for k, s := range *(*map[string]*sampler)(opp) {
+				news[k] = s
+			}
+		}
+		if s != nil {
+			news[key] = s
+		} else {
+			delete(news, key)
+		}
+		if atomic.CompareAndSwapPointer(&l.samplers, opp, unsafe.Pointer(&news)) {
+			return
+		}
+	}
+}
+
+// Reports whether the next message should be logged.
+func (s *sampler) sample() bool {
+	if s.everyN > 0 {
+		return (atomic.AddUint64(&s.count, 1)-1)%s.everyN == 0
+	}
+	return sampleRand() < s.p
+}
+
+// Applies the sampling, if any, of the entry's key, annotating the entry if
+// it is logged. Returns false if the entry should be dropped.
+func (l *Logger) sample(e *Entry) bool {
+	sp := atomic.LoadPointer(&l.samplers)
+	if sp == nil {
+		return true
+	}
+	s := (*(*map[string]*sampler)(sp))[e.Key]
+	if s == nil {
+		return true
+	}
+	if !s.sample() {
+		return false
+	}
+	e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], Field{Key: "sampled", Value: s.rate})
+	return true
+}
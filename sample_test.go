@@ -0,0 +1,68 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestKeySampling(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("mutation", "other"))
+	DisableColor()
+	l.SetKeySampling("mutation", 3)
+
+	for i := 0; i < 7; i++ {
+		l.To("mutation", "doc %d", i)
+	}
+	l.To("other", "not sampled")
+
+	exp := []string{
+		"mutation: doc 0 sampled=1/3",
+		"mutation: doc 3 sampled=1/3",
+		"mutation: doc 6 sampled=1/3",
+		"other: not sampled",
+	}
+	if got := strings.TrimSpace(b.String()); got != strings.Join(exp, "\n") {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+
+	b.Reset()
+	l.SetKeySampling("mutation", 0)
+	l.To("mutation", "doc %d", 7)
+	if got := strings.TrimSpace(b.String()); got != "mutation: doc 7" {
+		t.Errorf("Expected the sampling to be removed, got %q", got)
+	}
+}
+
+func TestKeySamplingRate(t *testing.T) {
+	defer func(f func() float64) { sampleRand = f }(sampleRand)
+	rolls := []float64{0.5, 0.005, 0.2, 0.009}
+	sampleRand = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	DisableColor()
+	dcp := l.Named("dcp")
+	l.SetKeySamplingRate("dcp", 0.01)
+	for i := 0; i < 4; i++ {
+		dcp.Log("mutation %d", i)
+	}
+
+	exp := "dcp: mutation 1 sampled=0.01\ndcp: mutation 3 sampled=0.01"
+	if got := strings.TrimSpace(b.String()); got != exp {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+}
@@ -0,0 +1,214 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SIEMOptions fill in the device fields of the headers written by the CEF
+// and LEEF formatters.
+type SIEMOptions struct {
+	Vendor  string // Default "Couchbase"
+	Product string
+	Version string
+}
+
+// CEF severities, from 0 (lowest) to 10, by level.
+var cefSeverities = []int{
+	LevelTrace:    0,
+	LevelDebug:    1,
+	LevelNormal:   3,
+	LevelNotice:   4,
+	LevelWarning:  6,
+	LevelError:    8,
+	LevelCritical: 9,
+	LevelPanic:    10,
+}
+
+// Returns a Formatter writing ArcSight Common Event Format records, for use
+// as SinkOptions.Formatter:
+//
+//	CEF:0|Couchbase|Server|7.6|audit|user login|3|rt=1714557600000 user=bob
+//
+// The signature ID is the entry's key, or its level if it has none. The
+// message is the event name, and the fields are extensions, after rt (the
+// time in milliseconds) and, if present, the caller as "fname" and "line".
+func NewCEFFormatter(opts SIEMOptions) Formatter {
+	header := siemHeader("CEF:0", opts)
+	return FormatterFunc(func(e Entry) []byte {
+		b := &bytes.Buffer{}
+		b.WriteString(header)
+		cefHeaderEscape(b, signatureID(&e))
+		b.WriteByte('|')
+		cefHeaderEscape(b, e.Message)
+		b.WriteByte('|')
+		b.WriteString(strconv.Itoa(levelSeverity(e.Level, cefSeverities, 3)))
+		b.WriteString("|rt=")
+		b.WriteString(strconv.FormatInt(e.Time.UnixMilli(), 10))
+		writeSIEMExtensions(b, &e, ' ', cefExtensionEscape)
+		return b.Bytes()
+	})
+}
+
+// LEEF severities, from 1 (lowest) to 10, by level.
+var leefSeverities = []int{
+	LevelTrace:    1,
+	LevelDebug:    2,
+	LevelNormal:   3,
+	LevelNotice:   4,
+	LevelWarning:  6,
+	LevelError:    8,
+	LevelCritical: 9,
+	LevelPanic:    10,
+}
+
+// Returns a Formatter writing IBM QRadar Log Event Extended Format 1.0
+// records, for use as SinkOptions.Formatter. Attributes are separated by
+// tabs:
+//
+//	LEEF:1.0|Couchbase|Server|7.6|audit|devTime=1714557600000	sev=3	msg=user login	user=bob
+//
+// The event ID is the entry's key, or its level if it has none. devTime is
+// the time in milliseconds, and the fields follow msg.
+func NewLEEFFormatter(opts SIEMOptions) Formatter {
+	header := siemHeader("LEEF:1.0", opts)
+	return FormatterFunc(func(e Entry) []byte {
+		b := &bytes.Buffer{}
+		b.WriteString(header)
+		cefHeaderEscape(b, signatureID(&e))
+		b.WriteString("|devTime=")
+		b.WriteString(strconv.FormatInt(e.Time.UnixMilli(), 10))
+		b.WriteString("\tsev=")
+		b.WriteString(strconv.Itoa(levelSeverity(e.Level, leefSeverities, 3)))
+		b.WriteString("\tmsg=")
+		leefEscape(b, e.Message)
+		writeSIEMExtensions(b, &e, '\t', leefEscape)
+		return b.Bytes()
+	})
+}
+
+// Returns the escaped header up to the event's ID, e.g. "CEF:0|V|P|1|".
+func siemHeader(prefix string, opts SIEMOptions) string {
+	if opts.Vendor == "" {
+		opts.Vendor = "Couchbase"
+	}
+	b := &bytes.Buffer{}
+	b.WriteString(prefix)
+	for _, s := range []string{opts.Vendor, opts.Product, opts.Version} {
+		b.WriteByte('|')
+		cefHeaderEscape(b, s)
+	}
+	b.WriteByte('|')
+	return b.String()
+}
+
+func signatureID(e *Entry) string {
+	if e.Key != "" {
+		return e.Key
+	}
+	return e.Level.String()
+}
+
+func levelSeverity(level LogLevel, severities []int, otherwise int) int {
+	if level >= 0 && int(level) < len(severities) {
+		return severities[level]
+	}
+	return otherwise
+}
+
+// Writes the caller, fields and stack of e, each preceded by sep.
+func writeSIEMExtensions(b *bytes.Buffer, e *Entry, sep byte, escape func(*bytes.Buffer, string)) {
+	if e.Caller != nil {
+		b.WriteByte(sep)
+		b.WriteString("fname=")
+		escape(b, e.Caller.File)
+		b.WriteByte(sep)
+		b.WriteString("line=")
+		b.WriteString(strconv.Itoa(e.Caller.Line))
+	}
+	for _, f := range e.Fields {
+		b.WriteByte(sep)
+		b.WriteString(siemKey(f.Key))
+		b.WriteByte('=')
+		escape(b, fmt.Sprint(f.Value))
+	}
+	if e.Stack != "" {
+		b.WriteByte(sep)
+		b.WriteString("stack=")
+		escape(b, e.Stack)
+	}
+}
+
+// Keys may only contain letters, digits and underscores; others become
+// underscores.
+func siemKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// Escapes backslashes and pipes in a CEF or LEEF header field. Line breaks,
+// which would end the record, become spaces.
+func cefHeaderEscape(b *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '|':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n', '\r':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// Escapes backslashes, equals signs and line breaks in a CEF extension
+// value.
+func cefExtensionEscape(b *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '=':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// Escapes backslashes, tabs (the attribute delimiter) and line breaks in a
+// LEEF attribute value.
+func leefEscape(b *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSIEMFormatters(t *testing.T) {
+	e := Entry{
+		Time:    time.UnixMilli(1714557600123),
+		Level:   LevelWarning,
+		Key:     "audit",
+		Message: "login|failed\nretry",
+		Fields:  []Field{{Key: "user", Value: `bob=admin\x`}, {Key: "src ip", Value: "10.0.0.1"}, {Key: "note", Value: "a\tb"}},
+	}
+	opts := SIEMOptions{Product: "Server", Version: "7.6"}
+
+	cef := string(NewCEFFormatter(opts).Format(e))
+	expect := `CEF:0|Couchbase|Server|7.6|audit|login\|failed retry|6|rt=1714557600123 ` +
+		`user=bob\=admin\\x src_ip=10.0.0.1 note=a` + "\tb"
+	if cef != expect {
+		t.Errorf("Unexpected CEF record\n%q, expected\n%q", cef, expect)
+	}
+
+	e.Key = ""
+	leef := string(NewLEEFFormatter(opts).Format(e))
+	expect = "LEEF:1.0|Couchbase|Server|7.6|warning|devTime=1714557600123\tsev=6\tmsg=login|failed\\nretry" +
+		"\tuser=bob=admin\\\\x\tsrc_ip=10.0.0.1\tnote=a\\tb"
+	if leef != expect {
+		t.Errorf("Unexpected LEEF record\n%q, expected\n%q", leef, expect)
+	}
+}
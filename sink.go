@@ -0,0 +1,604 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Format selects how an output renders log messages.
+type Format int
+
+const (
+	FormatText   = Format(iota) // Human readable lines, as written by SetOutput
+	FormatJSON                  // One JSON object per line
+	FormatLogfmt                // One line of key=value pairs per message
+	FormatPretty                // Text with aligned columns, for reading in a terminal
+)
+
+// Returns whether the format is read by people, and so has its timestamp
+// written by the output's log.Logger.
+func (f Format) human() bool {
+	return f == FormatText || f == FormatPretty
+}
+
+// SinkOptions configure an output added with AddOutput.
+type SinkOptions struct {
+	Level     LogLevel // Minimum level written to this output
+	Below     LogLevel // If nonzero, only levels below this are written
+	Color     bool     // Use ANSI color (text and pretty formats only)
+	Format    Format
+	Formatter Formatter // If set, renders entries in place of Format
+}
+
+// A Formatter renders entries for an output, for formats other than the
+// built-in ones. Each rendering is written on a line of its own.
+type Formatter interface {
+	Format(e Entry) []byte
+}
+
+// FormatterFunc adapts a function to a Formatter.
+type FormatterFunc func(e Entry) []byte
+
+func (f FormatterFunc) Format(e Entry) []byte {
+	return f(e)
+}
+
+// Formatters for the built-in structured formats, for use by or within
+// custom Formatters.
+var (
+	JSONFormatter   Formatter = FormatterFunc(func(e Entry) []byte { return formatJSON(&e) })
+	LogfmtFormatter Formatter = FormatterFunc(func(e Entry) []byte { return []byte(formatLogfmt(&e)) })
+)
+
+// An Entry is a single log message, as passed to hooks and outputs.
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Key     string // Set by To, or the logger's name
+	Message string
+	Fields  []Field // Attached using With or ContextWith
+	Caller  *Caller // Nil unless caller information is included; shared, so don't modify
+	Stack   string  // Stack trace, if one was requested
+
+	color  string
+	prefix string  // e.g. "WARN"; empty for Log, Print and To
+	text   string  // The logger callback's rendering, if there is one
+	origin *Caller // Set if needed to apply package levels, even if Caller isn't
+
+	globals       int    // Number of leading Fields set by SetGlobalFields
+	globalsInText bool   // Whether text outputs show them
+	escape        bool   // Whether text outputs escape control characters in the message
+	keepTags      bool   // Whether JSON outputs keep redaction tags in the message
+	continuation  string // Prefix of text outputs' continuation lines
+
+	callerFormat CallerFormat // How text and JSON outputs show the Caller
+	callerFirst  bool         // Whether text outputs show it at the start
+
+	// Set if the logger has a time format, rather than using the flags.
+	stamp    string // Timestamp for the structured formats
+	textTime string // Timestamp for text outputs, if the flags include one
+}
+
+// An output destination with its own level and format.
+type sink struct {
+	logger    *log.Logger
+	level     LogLevel
+	below     LogLevel // 0 if there is no upper limit
+	color     bool
+	format    Format
+	formatter Formatter   // If set, used in place of format
+	ew        entryWriter // If set, entries are passed to it rather than logger
+	failing   int32       // 1 once a write has failed, until one succeeds
+}
+
+// Implemented by outputs which take entries rather than formatted text.
+type entryWriter interface {
+	writeEntry(e *Entry)
+}
+
+// Adds an output to the default logger, alongside the existing ones.
+func AddOutput(w io.Writer, opts SinkOptions) {
+	std.AddOutput(w, opts)
+}
+
+// Adds an output to the logger, alongside the existing ones. Messages are
+// written to it if they pass both the logger's level and opts.Level.
+func (l *Logger) AddOutput(w io.Writer, opts SinkOptions) {
+	l.addSink(l.newSink(w, opts))
+}
+
+// Adds an entry output to the default logger; see Logger.AddEntryOutput.
+func AddEntryOutput(f func(e Entry), level LogLevel) {
+	std.AddEntryOutput(f, level)
+}
+
+// Adds an output which passes the entries at level or above to f, rather
+// than formatting them, for forwarding them to another logging API. f is
+// called synchronously, unless output is asynchronous, and must not modify
+// the entry's Fields or Caller.
+func (l *Logger) AddEntryOutput(f func(e Entry), level LogLevel) {
+	l.addSink(&sink{level: level, ew: entryFunc(f)})
+}
+
+// Replaces the default logger's outputs with an entry output; see
+// Logger.SetEntryOutput.
+func SetEntryOutput(f func(e Entry), level LogLevel) {
+	std.SetEntryOutput(f, level)
+}
+
+// Replaces the logger's outputs with an entry output, as AddEntryOutput
+// adds, in one step, so that no entry is written to both or neither.
+func (l *Logger) SetEntryOutput(f func(e Entry), level LogLevel) {
+	atomic.StorePointer(&l.sinks, unsafe.Pointer(&[]*sink{{level: level, ew: entryFunc(f)}}))
+	l.configChanged()
+}
+
+// Adapts a function to an entryWriter.
+type entryFunc func(e Entry)
+
+func (f entryFunc) writeEntry(e *Entry) {
+	f(*e)
+}
+
+// Removes the default logger's outputs which write to w.
+func RemoveOutput(w io.Writer) {
+	std.RemoveOutput(w)
+}
+
+// Removes the outputs which write to w, as added by AddOutput or SetOutput.
+func (l *Logger) RemoveOutput(w io.Writer) {
+	for {
+		opp := atomic.LoadPointer(&l.sinks)
+		var news []*sink
+		for _, s := range *(*[]*sink)(opp) {
+			if s.ew != nil || !sameWriter(s.logger.Writer(), w) {
+				news = append(news, s)
+			}
+		}
+		if atomic.CompareAndSwapPointer(&l.sinks, opp, unsafe.Pointer(&news)) {
+			l.configChanged()
+			return
+		}
+	}
+}
+
+// Compares writers, which may not be of comparable types.
+func sameWriter(a, b io.Writer) bool {
+	ta := reflect.TypeOf(a)
+	return ta == reflect.TypeOf(b) && ta != nil && ta.Comparable() && a == b
+}
+
+func (l *Logger) addSink(s *sink) {
+	for {
+		opp := atomic.LoadPointer(&l.sinks)
+		olds := *(*[]*sink)(opp)
+		news := make([]*sink, len(olds), len(olds)+1)
+		copy(news, olds)
+		news = append(news, s)
+		if atomic.CompareAndSwapPointer(&l.sinks, opp, unsafe.Pointer(&news)) {
+			l.configChanged()
+			return
+		}
+	}
+}
+
+func (l *Logger) newSink(w io.Writer, opts SinkOptions) *sink {
+	flags := 0
+	if opts.Format.human() && opts.Formatter == nil {
+		flags = l.sinkFlags(l.Flags())
+	}
+	return &sink{
+		logger:    log.New(w, "", flags),
+		level:     opts.Level,
+		below:     opts.Below,
+		color:     opts.Color,
+		format:    opts.Format,
+		formatter: opts.Formatter,
+	}
+}
+
+// Sets the default logger's outputs to stdout and stderr; see
+// Logger.SetSplitOutput.
+func SetSplitOutput() {
+	std.SetSplitOutput()
+}
+
+// Sets the logger's outputs to stdout for messages below LevelWarning, and
+// stderr for warnings and above, for log collectors which treat the two
+// streams differently. Any other outputs are replaced.
+func (l *Logger) SetSplitOutput() {
+	atomic.StorePointer(&l.sinks, unsafe.Pointer(&[]*sink{
+		l.newSink(os.Stdout, SinkOptions{Below: LevelWarning, Color: true}),
+		l.newSink(os.Stderr, SinkOptions{Level: LevelWarning, Color: true}),
+	}))
+	l.configChanged()
+}
+
+// Returns whether the output writes messages at the given level.
+func (s *sink) accepts(level LogLevel) bool {
+	return level >= s.level && (s.below == 0 || level < s.below)
+}
+
+func (l *Logger) loadSinks() []*sink {
+	return *(*[]*sink)(atomic.LoadPointer(&l.sinks))
+}
+
+// Returns the length of the timestamp the output's log.Logger writes.
+func (s *sink) prefixLen() int {
+	flags := s.logger.Flags()
+	if flags&timeFlags == 0 {
+		return 0
+	}
+	return len(defaultTextLayout(flags)) + 1
+}
+
+// Writes an entry to the output, returning any error from its writer.
+func (s *sink) write(e *Entry) error {
+	if s.ew != nil {
+		s.ew.writeEntry(e)
+		return nil
+	}
+	b := getBuffer()
+	switch {
+	case s.formatter != nil:
+		b.Write(s.formatter.Format(*e))
+	case s.format == FormatJSON:
+		writeJSON(b, e)
+	case s.format == FormatLogfmt:
+		writeLogfmt(b, e)
+	case s.format == FormatPretty:
+		writePretty(b, e, s.color, s.prefixLen())
+	default:
+		writeText(b, e, s.color)
+	}
+	err := s.logger.Output(1, bufferString(b))
+	putBuffer(b)
+	if err == nil && atomic.LoadInt32(&s.failing) != 0 {
+		atomic.StoreInt32(&s.failing, 0)
+	}
+	return err
+}
+
+func formatText(e *Entry, color bool) string {
+	var b bytes.Buffer
+	writeText(&b, e, color)
+	return b.String()
+}
+
+func writeText(b *bytes.Buffer, e *Entry, color bool) {
+	color = color && colorEnabled() && atomic.LoadInt32(&colorDepth) != int32(ColorDepthNone)
+	c := func(code string) string {
+		if color {
+			return code
+		}
+		return ""
+	}
+	start := b.Len()
+
+	if e.textTime != "" {
+		b.WriteString(e.textTime)
+		b.WriteByte(' ')
+	}
+	fields := e.Fields
+	if e.globals > 0 && e.globals <= len(fields) {
+		if e.globalsInText {
+			b.WriteByte('[')
+			for i, f := range fields[:e.globals] {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(f.Key)
+				b.WriteByte('=')
+				writeFieldValue(b, f.Value)
+			}
+			b.WriteString("] ")
+		}
+		fields = fields[e.globals:]
+	}
+	callerLast := e.Caller != nil && !e.callerFirst
+	if e.Caller != nil && e.callerFirst && (e.text != "" || e.prefix != "") {
+		b.WriteString(c(dim))
+		e.Caller.writeFormat(b, e.callerFormat)
+		b.WriteString(": ")
+		b.WriteString(c(reset))
+	}
+	switch {
+	case e.text != "":
+		writeMessage(b, e.text, e.escape)
+		writeFields(b, fields)
+		if callerLast {
+			b.WriteString(" -- ")
+			e.Caller.writeFormat(b, e.callerFormat)
+		}
+	case e.prefix != "":
+		b.WriteString(c(levelColor(e)))
+		b.WriteString(e.prefix)
+		b.WriteString(": ")
+		if e.Key != "" {
+			b.WriteString(e.Key)
+			b.WriteString(": ")
+		}
+		writeMessage(b, e.Message, e.escape)
+		writeFields(b, fields)
+		b.WriteString(c(reset))
+		b.WriteString(c(dim))
+		if callerLast {
+			b.WriteString(" -- ")
+			e.Caller.writeFormat(b, e.callerFormat)
+			b.WriteString(c(reset))
+		}
+	case e.Key != "":
+		b.WriteString(c(currentKeyColor()))
+		b.WriteString(e.Key)
+		b.WriteString(": ")
+		b.WriteString(c(reset))
+		writeMessage(b, e.Message, e.escape)
+		writeFields(b, fields)
+	default:
+		writeMessage(b, e.Message, e.escape)
+		writeFields(b, fields)
+	}
+	if e.Stack != "" {
+		b.WriteByte('\n')
+		b.WriteString(c(dim))
+		b.WriteString(e.Stack)
+		b.WriteString(c(reset))
+	}
+	indentLines(b, start, e.continuation)
+}
+
+func formatJSON(e *Entry) []byte {
+	var b bytes.Buffer
+	writeJSON(&b, e)
+	return b.Bytes()
+}
+
+func writeJSON(b *bytes.Buffer, e *Entry) {
+	b.WriteByte('{')
+	b.WriteString(`"time":"`)
+	b.Write(e.appendTimestamp(b.AvailableBuffer()))
+	b.WriteByte('"')
+	appendJSONString(b, "level", e.Level.String())
+	if e.Key != "" {
+		appendJSONString(b, "key", e.Key)
+	}
+	// The redaction tags are removed from the message, unless kept for
+	// RedactLogs, and the tagged values are listed by category in "redact".
+	msg := e.Message
+	var redacted [numTypes][]string
+	if strings.IndexByte(msg, '<') >= 0 {
+		var untagged string
+		if untagged, redacted = untagMessage(msg); !e.keepTags {
+			msg = untagged
+		}
+	}
+	appendJSONString(b, "msg", msg)
+	var r bytes.Buffer
+	for category, values := range redacted {
+		if len(values) > 0 {
+			if r.Len() == 0 {
+				r.WriteByte('{')
+			}
+			appendJSON(&r, tags[category], values)
+		}
+	}
+	if r.Len() > 0 {
+		r.WriteByte('}')
+		appendJSON(b, "redact", json.RawMessage(r.Bytes()))
+	}
+	if e.Caller != nil {
+		b.WriteString(`,"caller":"`)
+		cb := getBuffer()
+		e.Caller.writeFormat(cb, e.callerFormat)
+		writeJSONEscaped(b, bufferString(cb))
+		putBuffer(cb)
+		b.WriteByte('"')
+	}
+	if e.Stack != "" {
+		appendJSONString(b, "stack", e.Stack)
+	}
+	var tagged [numTypes]bytes.Buffer // Objects of tagged fields, by category
+	for _, f := range e.Fields {
+		if category, value, ok := taggedValue(f.Value); ok {
+			t := &tagged[category]
+			if t.Len() == 0 {
+				t.WriteByte('{')
+			}
+			appendJSON(t, f.Key, value)
+		} else {
+			appendJSON(b, f.Key, f.Value)
+		}
+	}
+	for category := range tagged {
+		if t := &tagged[category]; t.Len() > 0 {
+			t.WriteByte('}')
+			appendJSON(b, tags[category], json.RawMessage(t.Bytes()))
+		}
+	}
+	b.WriteByte('}')
+}
+
+// Returns the category and untagged rendering of a field value tagged by UD,
+// MD, SD or Tag, which JSON outputs group by category rather than embedding
+// the tags.
+func taggedValue(value interface{}) (ContentCategory, string, bool) {
+	switch v := value.(type) {
+	case Tagged:
+		if v.category >= numTypes || GetRedactionLevel() == RedactNone {
+			return 0, "", false
+		}
+		s := fmt.Sprint(v.value)
+		if v.category == UserData && GetRedactionLevel() == RedactFull {
+			s = redactionHash(s)
+		}
+		return v.category, s, true
+	case string:
+		for category, tag := range tags {
+			open, close := "<"+tag+">", "</"+tag+">"
+			if strings.HasPrefix(v, open) && strings.HasSuffix(v, close) &&
+				len(redactionTags(v)) == 1 && strings.Count(v, open) == 1 {
+				return ContentCategory(category), v[len(open) : len(v)-len(close)], true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+func formatLogfmt(e *Entry) string {
+	var b bytes.Buffer
+	writeLogfmt(&b, e)
+	return b.String()
+}
+
+func writeLogfmt(b *bytes.Buffer, e *Entry) {
+	b.WriteString("time=")
+	b.Write(e.appendTimestamp(b.AvailableBuffer()))
+	b.WriteString(" level=")
+	b.WriteString(e.Level.String())
+	if e.Key != "" {
+		b.WriteString(" key=")
+		writeQuotedIfNeeded(b, e.Key)
+	}
+	if e.Caller != nil {
+		b.WriteString(" caller=")
+		writeQuotedIfNeeded(b, fmt.Sprintf("%s:%d", lastComponent(e.Caller.File), e.Caller.Line))
+	}
+	b.WriteString(" msg=")
+	writeQuotedIfNeeded(b, e.Message)
+	writeFields(b, e.Fields)
+	if e.Stack != "" {
+		b.WriteString(" stack=")
+		writeQuotedIfNeeded(b, e.Stack)
+	}
+}
+
+// Appends a "key":value member to a JSON object being written to b.
+func appendJSON(b *bytes.Buffer, key string, value interface{}) {
+	appendJSONKey(b, key)
+	switch v := value.(type) {
+	case string:
+		b.WriteByte('"')
+		writeJSONEscaped(b, v)
+		b.WriteByte('"')
+		return
+	case error:
+		value = v.Error()
+	case int:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(v), 10))
+		return
+	case bool:
+		b.Write(strconv.AppendBool(b.AvailableBuffer(), v))
+		return
+	}
+	v, err := marshalJSON(value)
+	if err != nil {
+		v, _ = marshalJSON(fmt.Sprint(value))
+	}
+	b.Write(v)
+}
+
+// Appends a "key":"value" member, without boxing the value.
+func appendJSONString(b *bytes.Buffer, key string, value string) {
+	appendJSONKey(b, key)
+	b.WriteByte('"')
+	writeJSONEscaped(b, value)
+	b.WriteByte('"')
+}
+
+func appendJSONKey(b *bytes.Buffer, key string) {
+	if b.Len() > 0 && b.Bytes()[b.Len()-1] != '{' {
+		b.WriteByte(',')
+	}
+	b.WriteByte('"')
+	writeJSONEscaped(b, key)
+	b.WriteString(`":`)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// Writes s escaped as the contents of a JSON string, as json.Encoder does
+// with HTML escaping disabled.
+func writeJSONEscaped(b *bytes.Buffer, s string) {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\b':
+				b.WriteString(`\b`)
+			case '\f':
+				b.WriteString(`\f`)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+}
+
+// Like json.Marshal, but without escaping '<' and '>', which appear in
+// redaction tags.
+func marshalJSON(value interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte{'\n'}), nil
+}
@@ -0,0 +1,247 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAddOutput(t *testing.T) {
+	requireDebug(t)
+	text, plain, js := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(text), WithFlags(0), WithLevel(LevelDebug))
+	l.AddOutput(plain, SinkOptions{Level: LevelWarning})
+	l.AddOutput(js, SinkOptions{Format: FormatJSON})
+
+	l.Debugf("debug %d", 1)
+	l.With("bucket", "default").Warnf("warn <ud>%s</ud>", "doc1")
+
+	if n := strings.Count(text.String(), "\n"); n != 2 {
+		t.Errorf("Expected 2 lines of text output, got %q", text.String())
+	}
+	if !strings.HasPrefix(plain.String(), "WARN: warn <ud>doc1</ud> bucket=default -- ") ||
+		strings.Contains(plain.String(), "\x1b") {
+		t.Errorf("Expected only an uncolored warning, got %q", plain.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(js.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %q", js.String())
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["level"] != "warning" || m["msg"] != "warn doc1" ||
+		fmt.Sprint(m["redact"]) != "map[ud:[doc1]]" ||
+		m["bucket"] != "default" || m["time"] == nil ||
+		!strings.Contains(m["caller"].(string), "TestAddOutput()") {
+		t.Errorf("Unexpected JSON output %q", lines[1])
+	}
+}
+
+func TestJSONRedactionTags(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	l.AddOutput(b, SinkOptions{Format: FormatJSON})
+	l.Log("get <ud>doc1</ud> from <md>bucket <ud>b1</ud></md>")
+	l.SetJSONRedactionTags(true)
+	l.Log("get <ud>doc2</ud>")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 ||
+		!strings.Contains(lines[0], `"msg":"get doc1 from bucket b1","redact":{"ud":["doc1"],"md":["bucket b1"]}`) ||
+		!strings.Contains(lines[1], `"msg":"get <ud>doc2</ud>","redact":{"ud":["doc2"]}`) {
+		t.Errorf("Unexpected JSON output %q", b.String())
+	}
+}
+
+func TestSetEntryOutput(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b))
+	l.AddOutput(b, SinkOptions{Format: FormatJSON})
+	var msgs []string
+	l.SetEntryOutput(func(e Entry) { msgs = append(msgs, e.Message) }, LevelWarning)
+	l.Log("filtered")
+	l.Warnf("forwarded")
+	if b.Len() != 0 || len(msgs) != 1 || msgs[0] != "forwarded" {
+		t.Errorf("Expected only the entry output, got %q and %q", msgs, b.String())
+	}
+}
+
+func TestSetOutputReplacesOutputs(t *testing.T) {
+	b1, b2 := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(b1))
+	l.AddOutput(b1, SinkOptions{})
+	l.SetOutput(b2)
+	l.Log("msg")
+	if b1.Len() != 0 || b2.Len() == 0 {
+		t.Errorf("Expected output only to the new writer")
+	}
+}
+
+func TestSplitOutput(t *testing.T) {
+	requireDebug(t)
+	out, errs := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithFlags(0), WithIncludeCaller(false))
+	l.SetLevel(LevelDebug)
+	l.AddOutput(out, SinkOptions{Below: LevelWarning})
+	l.AddOutput(errs, SinkOptions{Level: LevelWarning})
+
+	l.Debugf("d")
+	l.Log("n")
+	l.Warnf("w")
+	l.Errorf("e")
+
+	if got := out.String(); got != "DEBU: d\nn\n" {
+		t.Errorf("Unexpected stdout output %q", got)
+	}
+	if got := errs.String(); got != "WARN: w\nERRO: e\n" {
+		t.Errorf("Unexpected stderr output %q", got)
+	}
+
+	l.SetSplitOutput()
+	sinks := l.loadSinks()
+	if len(sinks) != 2 || !sinks[0].accepts(LevelNormal) || sinks[0].accepts(LevelWarning) ||
+		sinks[1].accepts(LevelNormal) || !sinks[1].accepts(LevelPanic) {
+		t.Errorf("Unexpected split outputs")
+	}
+}
+
+func TestLogfmtOutput(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard))
+	l.AddOutput(b, SinkOptions{Format: FormatLogfmt})
+
+	l.Named("dcp").With("vb", 12, "reason", "rollback needed").Warnf(`stream "%s" closed`, "s1")
+	l.SetIncludeCaller(false)
+	l.Log("plain")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b.String())
+	}
+	re := regexp.MustCompile(`^time=\S+ level=warning key=dcp caller=sink_test.go:\d+ ` +
+		`msg="stream \\"s1\\" closed" vb=12 reason="rollback needed"$`)
+	if !re.MatchString(lines[0]) {
+		t.Errorf("Unexpected logfmt line %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], " level=normal msg=plain") {
+		t.Errorf("Unexpected logfmt line %q", lines[1])
+	}
+}
+
+func TestJSONRedaction(t *testing.T) {
+	defer SetRedactionLevel(RedactPartial)
+	SetRedactionSalt("salt")
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	l.AddOutput(b, SinkOptions{Format: FormatJSON})
+
+	l.With("docId", UD("k123"), "bucket", Tag(MetaData, "b1"), "vb", 7).Log("get")
+	SetRedactionLevel(RedactFull)
+	l.With("docId", UD("k123")).Log("get")
+	SetRedactionLevel(RedactNone)
+	l.With("docId", UD("k123")).Log("get")
+
+	// echo -n saltk123 | sha1sum
+	exp := []string{
+		`"msg":"get","vb":7,"ud":{"docId":"k123"},"md":{"bucket":"b1"}}`,
+		`"msg":"get","ud":{"docId":"5b2fce2a789b57d18a61ae9cc84f59e28e477cd0"}}`,
+		`"msg":"get","docId":"k123"}`,
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != len(exp) {
+		t.Fatalf("Expected %d lines, got %q", len(exp), b.String())
+	}
+	for i := range exp {
+		if !strings.HasSuffix(lines[i], exp[i]) {
+			t.Errorf("Expected suffix %s, got %s", exp[i], lines[i])
+		}
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard), WithIncludeCaller(false))
+	cef := FormatterFunc(func(e Entry) []byte {
+		return []byte(fmt.Sprintf("CEF:0|Couchbase|clog|1.0|%s|%s|%d|%d fields",
+			e.Key, e.Message, e.Level, len(e.Fields)))
+	})
+	l.AddOutput(b, SinkOptions{Formatter: cef})
+
+	l.Named("auth").With("user", "u1").Warnf("login failed")
+	exp := "CEF:0|Couchbase|clog|1.0|auth|login failed|4|1 fields\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+
+	b.Reset()
+	l = New(WithOutput(io.Discard), WithIncludeCaller(false))
+	l.AddOutput(b, SinkOptions{Formatter: JSONFormatter})
+	l.Log("json")
+	if !strings.HasSuffix(b.String(), `"level":"normal","msg":"json"}`+"\n") {
+		t.Errorf("Unexpected JSON output %q", b.String())
+	}
+}
+
+func TestWriteJSONEscaped(t *testing.T) {
+	for _, s := range []string{"plain", `q"b\s`, "nl\ncr\rtab\tbs\bff\f", "\x01\x1f", "<ud>x</ud>&",
+		"héllo", "bad\xffutf8", "ls\u2028ps\u2029"} {
+		var b bytes.Buffer
+		b.WriteByte('"')
+		writeJSONEscaped(&b, s)
+		b.WriteByte('"')
+		var got, want string
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Errorf("Escaped %q as invalid JSON %s: %v", s, b.String(), err)
+		}
+		enc, _ := marshalJSON(s)
+		json.Unmarshal(enc, &want)
+		if got != want {
+			t.Errorf("Escaped %q as %s, which decodes to %q rather than %q", s, b.String(), got, want)
+		}
+	}
+}
+
+func TestOutputAllocs(t *testing.T) {
+	requireNoRace(t)
+	l := New(WithOutput(io.Discard))
+	l.AddOutput(io.Discard, SinkOptions{Format: FormatJSON})
+	if n := testing.AllocsPerRun(100, func() { l.Debugf("filtered %s", "message") }); n != 0 {
+		t.Errorf("Filtered message made %v allocations", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { l.Warnf("emitted %s", "message") }); n > 2 {
+		t.Errorf("Emitted message made %v allocations", n)
+	}
+}
+
+// A writer of a type which can't be compared with ==.
+type sliceWriter []string
+
+func (sliceWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func TestRemoveOutput(t *testing.T) {
+	b1, b2 := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(sliceWriter{}), WithFlags(0))
+	l.AddOutput(b1, SinkOptions{})
+	l.AddOutput(b2, SinkOptions{})
+	l.RemoveOutput(b1)
+	l.RemoveOutput(sliceWriter{})
+	l.Log("hello")
+	if b1.Len() != 0 || b2.String() != "hello\n" || len(l.loadSinks()) != 2 {
+		t.Errorf("Expected only the first output to be removed")
+	}
+}
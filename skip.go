@@ -0,0 +1,40 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import "sync/atomic"
+
+// Sets the number of stack frames the default logger skips when finding
+// callers; see Logger.SetCallerSkip.
+func SetCallerSkip(n int) {
+	std.SetCallerSkip(n)
+}
+
+// Thread-safe API for setting the number of additional stack frames skipped
+// when finding a message's caller and stack trace, so that a package
+// wrapping clog in its own logging functions can report its callers rather
+// than itself. n is 1 for a wrapper which calls clog directly. It applies to
+// the Loggers derived from l too. (default 0)
+func (l *Logger) SetCallerSkip(n int) {
+	atomic.StoreInt32(&l.callerSkip, int32(n))
+}
+
+// Returns a Logger like l which skips n more stack frames when finding
+// callers, for a wrapper used by only some of l's users.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	nl := l.withFields(l.fields)
+	nl.skip = l.skip + n
+	return nl
+}
+
+// Returns the depth to pass to callerAt and stackTrace, from a function
+// called directly by the exported logging function, such as doLog.
+func (l *Logger) callerDepth() int {
+	return 2 + l.skip + int(atomic.LoadInt32(&l.callerSkip))
+}
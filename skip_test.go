@@ -0,0 +1,42 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+// A logging façade, as a service might wrap clog in.
+type facade struct{ l *Logger }
+
+func (f facade) warn(msg string) {
+	f.l.Warnf("%s", msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.SetCallerOptions(CallerOptions{Format: CallerFile})
+	f := facade{l}
+	f.warn("unskipped")
+	l.SetCallerSkip(1)
+	f.warn("skipped")
+	l.SetCallerSkip(0)
+	facade{l.WithCallerSkip(1).With("vb", 3)}.warn("derived")
+	f.warn("parent")
+
+	expect := "WARN: unskipped -- skip_test.go:20\n" +
+		"WARN: skipped -- skip_test.go:30\n" +
+		"WARN: derived vb=3 -- skip_test.go:32\n" +
+		"WARN: parent -- skip_test.go:20\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
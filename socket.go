@@ -0,0 +1,193 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Framing selects how records sent over a stream socket are delimited.
+type Framing int
+
+const (
+	FramingNewline      = Framing(iota) // Each record is followed by a newline
+	FramingOctetCounted                 // Each record is preceded by its length and a space (RFC 6587)
+)
+
+// SocketOptions configure an output sending entries to a socket, such as
+// the input of a local log forwarder like fluent-bit or vector.
+type SocketOptions struct {
+	Network   string    // "unix" or "tcp" (streams), or "unixgram" or "udp" (datagrams)
+	Address   string    // A socket path, or host:port
+	Level     LogLevel  // Minimum level sent
+	Formatter Formatter // Default JSONFormatter
+	Framing   Framing   // Streams only; datagrams hold one record each
+
+	// Minimum time between attempts to reconnect (default 1s). Batches
+	// which can't be sent while disconnected are dropped after retrying.
+	ReconnectInterval time.Duration
+
+	Timeout time.Duration // Limit on connecting and on each write (default 5s)
+	Batch   BatchOptions  // FlushInterval defaults to 100ms
+	Circuit CircuitOptions
+}
+
+// A SocketWriter sends log entries to a socket, reconnecting if the
+// connection fails.
+type SocketWriter struct {
+	opts  SocketOptions
+	batch *batcher
+
+	mu       sync.Mutex // Protects the members below
+	conn     net.Conn
+	lastDial time.Time // Time of the last connection attempt
+}
+
+var errReconnectWait = errors.New("clog: socket disconnected, waiting to reconnect")
+
+// Sends the default logger's entries to a socket; see
+// Logger.AddSocketOutput.
+func AddSocketOutput(opts SocketOptions) (*SocketWriter, error) {
+	return std.AddSocketOutput(opts)
+}
+
+// Adds an output sending entries to a socket. Entries are sent in batches
+// from a background goroutine, so that a stalled peer can't hold up logging,
+// and a batch which can't be sent is retried with backoff, reconnecting if
+// necessary, then dropped; Err returns the error. Returns an error if the
+// socket can't be connected to at first. Close the writer to send any
+// outstanding entries.
+func (l *Logger) AddSocketOutput(opts SocketOptions) (*SocketWriter, error) {
+	switch opts.Network {
+	case "unix", "tcp", "unixgram", "udp":
+	default:
+		return nil, fmt.Errorf("clog: unsupported socket network %q", opts.Network)
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = JSONFormatter
+	}
+	if opts.ReconnectInterval <= 0 {
+		opts.ReconnectInterval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Batch.FlushInterval <= 0 {
+		opts.Batch.FlushInterval = 100 * time.Millisecond
+	}
+	conn, err := net.DialTimeout(opts.Network, opts.Address, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	s := &SocketWriter{opts: opts, conn: conn, lastDial: time.Now()}
+	s.batch = newBatcher(opts.Batch, newCircuit("socket "+opts.Network+" "+opts.Address, opts.Circuit), s.send, nil)
+	l.addSink(&sink{level: opts.Level, ew: s})
+	return s, nil
+}
+
+// Sends any outstanding entries, returning the error from doing so.
+func (s *SocketWriter) Flush() error {
+	return s.batch.Flush()
+}
+
+// Returns the error from the most recent send, if it failed.
+func (s *SocketWriter) Err() error {
+	return s.batch.Err()
+}
+
+// Returns the number of entries dropped because too many were waiting to be
+// sent, or they were logged after Close.
+func (s *SocketWriter) Dropped() uint64 {
+	return s.batch.Dropped()
+}
+
+// Sends any outstanding entries, then closes the connection. Entries logged
+// afterwards are dropped.
+func (s *SocketWriter) Close() error {
+	err := s.batch.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		if cerr := s.conn.Close(); err == nil {
+			err = cerr
+		}
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *SocketWriter) health() SinkState {
+	return s.batch.circuit.health()
+}
+
+func (s *SocketWriter) writeEntry(e *Entry) {
+	s.batch.add(batchRecord{time: e.Time, value: s.frame(s.opts.Formatter.Format(*e))})
+}
+
+// Writes a batch to the socket, reconnecting first if the connection failed.
+// Datagrams are sent one record at a time, and stream records together.
+func (s *SocketWriter) send(batch []batchRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if time.Since(s.lastDial) < s.opts.ReconnectInterval {
+			return errReconnectWait
+		}
+		s.lastDial = time.Now()
+		conn, err := net.DialTimeout(s.opts.Network, s.opts.Address, s.opts.Timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	var err error
+	if s.opts.Network == "unixgram" || s.opts.Network == "udp" {
+		for _, r := range batch {
+			if err = s.write(r.value); err != nil {
+				break
+			}
+		}
+	} else {
+		b := &bytes.Buffer{}
+		for _, r := range batch {
+			b.Write(r.value)
+		}
+		err = s.write(b.Bytes())
+	}
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// Writes to the connection with a deadline. Must be called with mu held.
+func (s *SocketWriter) write(b []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(s.opts.Timeout))
+	_, err := s.conn.Write(b)
+	return err
+}
+
+func (s *SocketWriter) frame(record []byte) []byte {
+	switch {
+	case s.opts.Network == "unixgram" || s.opts.Network == "udp":
+		return record
+	case s.opts.Framing == FramingOctetCounted:
+		framed := strconv.AppendInt(nil, int64(len(record)), 10)
+		return append(append(framed, ' '), record...)
+	default:
+		return append(record, '\n')
+	}
+}
@@ -0,0 +1,150 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSocketOutputStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					lines <- line
+				}
+			}()
+		}
+	}()
+
+	l := New(WithOutput(io.Discard))
+	s, err := l.AddSocketOutput(SocketOptions{
+		Network:           "tcp",
+		Address:           ln.Addr().String(),
+		ReconnectInterval: time.Millisecond,
+		Batch:             BatchOptions{RetryBackoff: 5 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	l.Log("first")
+	if line := <-lines; !strings.HasPrefix(line, "{") || !strings.Contains(line, `"msg":"first"`) {
+		t.Errorf("Unexpected record %q", line)
+	}
+
+	// Break the connection; the batch which fails is resent after reconnecting.
+	s.mu.Lock()
+	s.conn.Close()
+	s.mu.Unlock()
+	l.Log("second")
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, `"msg":"second"`) {
+			t.Errorf("Unexpected record %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a reconnection")
+	}
+}
+
+func TestSocketOutputDatagram(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	l := New(WithOutput(io.Discard))
+	s, err := l.AddSocketOutput(SocketOptions{Network: "udp", Address: pc.LocalAddr().String(), Framing: FramingOctetCounted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	l.Log("hello")
+
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record := string(buf[:n]); !strings.HasPrefix(record, "{") || !strings.HasSuffix(record, "}") {
+		t.Errorf("Expected an unframed JSON record, got %q", record)
+	}
+}
+
+func TestSocketOutputStalled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			// Accept connections but never read from them.
+			if _, err := ln.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+
+	l := New(WithOutput(io.Discard))
+	s, err := l.AddSocketOutput(SocketOptions{
+		Network: "tcp",
+		Address: ln.Addr().String(),
+		Timeout: 50 * time.Millisecond,
+		Batch:   BatchOptions{FlushInterval: time.Hour, MaxRetries: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	big := strings.Repeat("x", 64<<10)
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		l.Log("%s", big)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Logging was held up by the stalled peer for %v", d)
+	}
+	if err := s.Flush(); err == nil {
+		t.Error("Expected the write to time out")
+	}
+}
+
+func TestSocketFraming(t *testing.T) {
+	s := &SocketWriter{opts: SocketOptions{Network: "unix", Framing: FramingOctetCounted}}
+	if framed := string(s.frame([]byte("{}"))); framed != "2 {}" {
+		t.Errorf("Unexpected framing %q", framed)
+	}
+}
@@ -0,0 +1,145 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Sets the minimum level at which the default logger appends stack traces;
+// see Logger.SetStackTraceLevel.
+func SetStackTraceLevel(level LogLevel) {
+	std.SetStackTraceLevel(level)
+}
+
+// Sets whether the default logger's stack traces include all goroutines.
+func SetStackTraceAll(all bool) {
+	std.SetStackTraceAll(all)
+}
+
+// Thread-safe API for setting the minimum level of messages to which the
+// calling goroutine's stack trace is appended, for instance LevelPanic for
+// Panic and Fatal messages only. Stack traces are disabled by default; a
+// level above LevelPanic disables them again.
+func (l *Logger) SetStackTraceLevel(level LogLevel) {
+	atomic.StoreInt32(&l.stackLevel, int32(level))
+}
+
+// Sets whether the default logger's Error prints the stacks carried by
+// errors; see Logger.SetVerboseErrors.
+func SetVerboseErrors(enabled bool) {
+	std.SetVerboseErrors(enabled)
+}
+
+// Thread-safe API for setting whether Error prints the stack trace carried
+// by the error, or by an error it wraps, below the message. Errors created by
+// github.com/pkg/errors carry stacks, as do those with a
+// Callers() []uintptr method. (default false)
+func (l *Logger) SetVerboseErrors(enabled bool) {
+	atomic.StoreInt32(&l.verboseErrors, btoi(enabled))
+}
+
+// Thread-safe API for setting whether stack traces include all goroutines,
+// rather than only the calling one. (default false)
+func (l *Logger) SetStackTraceAll(all bool) {
+	atomic.StoreInt32(&l.stackAll, btoi(all))
+}
+
+// Returns the stack trace to attach to a message at the given level, if any.
+// depth is as for getCallersName.
+func (l *Logger) stackTrace(level LogLevel, depth int) string {
+	if level < LogLevel(atomic.LoadInt32(&l.stackLevel)) || level > LevelPanic {
+		return ""
+	}
+	if atomic.LoadInt32(&l.stackAll) == 1 {
+		buf := make([]byte, 64*1024)
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				return strings.TrimSpace(string(buf[:n]))
+			}
+			buf = make([]byte, 2*len(buf))
+		}
+	}
+	return callerStack(depth + 1)
+}
+
+// Formats the calling goroutine's stack, like runtime.Stack, starting at the
+// caller identified by depth.
+func callerStack(depth int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(depth+2, pcs)
+	return formatFrames(pcs[:n])
+}
+
+// Formats a stack given as return program counters, as from runtime.Callers.
+func formatFrames(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s()\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// Returns the formatted stack carried by err or the errors it wraps, if any.
+// Where several have stacks, the innermost is used, being closest to where
+// the problem arose.
+func errorStack(err error) string {
+	var pcs []uintptr
+	for err != nil {
+		if p := errorPCs(err); len(p) > 0 {
+			pcs = p
+		}
+		if c, ok := err.(interface{ Cause() error }); ok {
+			err = c.Cause()
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+	if len(pcs) == 0 {
+		return ""
+	}
+	return formatFrames(pcs)
+}
+
+// Returns the program counters of the stack carried by err itself. The
+// StackTrace method of github.com/pkg/errors returns a slice of Frames,
+// which are uintptr program counters, so it is called by reflection to avoid
+// depending on that package.
+func errorPCs(err error) []uintptr {
+	if c, ok := err.(interface{ Callers() []uintptr }); ok {
+		return c.Callers()
+	}
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	st := m.Call(nil)[0]
+	if st.Kind() != reflect.Slice || st.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	pcs := make([]uintptr, st.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(st.Index(i).Uint())
+	}
+	return pcs
+}
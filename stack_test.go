@@ -0,0 +1,112 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStackTraces(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+
+	l.Errorf("no trace")
+	if strings.Contains(b.String(), "\n\t") {
+		t.Errorf("Expected no stack trace by default, got %q", b.String())
+	}
+
+	b.Reset()
+	l.SetStackTraceLevel(LevelError)
+	l.Warnf("below threshold")
+	l.Errorf("with trace")
+	lines := strings.Split(b.String(), "\n")
+	if !strings.Contains(lines[1], "with trace") ||
+		!strings.HasSuffix(lines[2], "clog.TestStackTraces()") ||
+		!strings.Contains(lines[3], "stack_test.go:") {
+		t.Errorf("Expected a stack trace starting at the caller, got %q", lines)
+	}
+
+	b.Reset()
+	l.SetStackTraceAll(true)
+	func() {
+		defer func() { recover() }()
+		l.Panic("all goroutines")
+	}()
+	if !strings.Contains(b.String(), "goroutine ") {
+		t.Errorf("Expected all goroutines, got %q", b.String())
+	}
+
+	b.Reset()
+	l.SetStackTraceLevel(LevelPanic + 1)
+	l.Errorf("disabled")
+	if strings.Count(b.String(), "\n") != 1 {
+		t.Errorf("Expected stack traces to be disabled, got %q", b.String())
+	}
+}
+
+// Mimics the errors of github.com/pkg/errors, whose StackTrace method
+// returns a slice of uintptr Frames.
+type frame uintptr
+
+type stackError struct {
+	msg   string
+	stack []frame
+}
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) StackTrace() []frame { return e.stack }
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	e := &stackError{msg: msg}
+	for _, pc := range pcs[:n] {
+		e.stack = append(e.stack, frame(pc))
+	}
+	return e
+}
+
+func failDeep() error {
+	return newStackError("disk full")
+}
+
+func TestVerboseErrors(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+
+	err := fmt.Errorf("writing snapshot: %w", failDeep())
+	l.Error(err)
+	if got := b.String(); got != "ERRO: writing snapshot: disk full\n" {
+		t.Errorf("Expected no stack without verbose errors, got %q", got)
+	}
+
+	b.Reset()
+	l.SetVerboseErrors(true)
+	l.Error(err)
+	lines := strings.Split(b.String(), "\n")
+	if lines[0] != "ERRO: writing snapshot: disk full" ||
+		lines[1] != "github.com/couchbase/clog.failDeep()" || !strings.HasPrefix(lines[2], "\t") ||
+		!regexp.MustCompile(`/stack_test.go:\d+$`).MatchString(lines[2]) {
+		t.Errorf("Unexpected verbose error output %q", b.String())
+	}
+
+	b.Reset()
+	l.Error(errors.New("no stack"))
+	if got := b.String(); got != "ERRO: no stack\n" {
+		t.Errorf("Unexpected output for an error without a stack %q", got)
+	}
+}
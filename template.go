@@ -0,0 +1,108 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// The data a template formatter's template is executed with.
+type templateEntry struct {
+	e *Entry
+}
+
+// Returns the timestamp, as written by the structured formats.
+func (t templateEntry) Time() string {
+	return string(t.e.appendTimestamp(nil))
+}
+
+// Returns the level's label, such as "WARN", or "INFO" for unlabeled entries.
+func (t templateEntry) Level() string {
+	if t.e.prefix == "" {
+		return "INFO"
+	}
+	return t.e.prefix
+}
+
+func (t templateEntry) Key() string {
+	return t.e.Key
+}
+
+func (t templateEntry) Message() string {
+	var b bytes.Buffer
+	writeMessage(&b, t.e.Message, t.e.escape)
+	return b.String()
+}
+
+// Returns the caller in the logger's caller format, or "" if there is none.
+func (t templateEntry) Caller() string {
+	if t.e.Caller == nil {
+		return ""
+	}
+	var b bytes.Buffer
+	t.e.Caller.writeFormat(&b, t.e.callerFormat)
+	return b.String()
+}
+
+// Returns the fields as space-separated key=value pairs.
+func (t templateEntry) Fields() string {
+	var b bytes.Buffer
+	writeFields(&b, t.e.Fields)
+	return strings.TrimPrefix(b.String(), " ")
+}
+
+// Returns the value of the last field with the given key, or nil.
+func (t templateEntry) Field(key string) interface{} {
+	for i := len(t.e.Fields) - 1; i >= 0; i-- {
+		if t.e.Fields[i].Key == key {
+			return t.e.Fields[i].Value
+		}
+	}
+	return nil
+}
+
+func (t templateEntry) Stack() string {
+	return t.e.Stack
+}
+
+// Formatter executing a text/template for each entry.
+type templateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter returns a Formatter rendering each entry with a
+// text/template, such as
+//
+//	{{.Time}} [{{.Level}}] ({{.Key}}) {{.Message}} <{{.Caller}}>
+//
+// The template may use .Time, .Level (the label, such as "WARN"), .Key,
+// .Message, .Caller, .Fields (as key=value pairs), .Stack, and .Field "name"
+// for a single field's value. Trailing whitespace is removed from each
+// rendering. If the template fails for an entry, it is written in
+// FormatText instead, followed by the error.
+func NewTemplateFormatter(text string) (Formatter, error) {
+	tmpl, err := template.New("clog").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("clog: invalid template: %w", err)
+	}
+	return &templateFormatter{tmpl}, nil
+}
+
+func (f *templateFormatter) Format(e Entry) []byte {
+	var b bytes.Buffer
+	if err := f.tmpl.Execute(&b, templateEntry{&e}); err != nil {
+		b.Reset()
+		writeText(&b, &e, false)
+		fmt.Fprintf(&b, " (clog: template failed: %v)", err)
+	}
+	return bytes.TrimRight(b.Bytes(), " \t\n")
+}
@@ -0,0 +1,53 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	f, err := NewTemplateFormatter(`[{{.Level}}] ({{.Key}}) {{.Message}} {{.Fields}} <{{.Caller}}> vb={{.Field "vb"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	l := New(WithOutput(io.Discard))
+	l.AddOutput(b, SinkOptions{Formatter: f})
+	l.With("vb", 12, "bucket", "default").Warnf("rollback to %d", 100)
+	l.SetIncludeCaller(false)
+	l.EnableKey("DCP")
+	l.To("DCP", "stream ended")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b.String())
+	}
+	if want := "[WARN] () rollback to 100 vb=12 bucket=default <clog.TestTemplateFormatter() at template_test.go:26> vb=12"; lines[0] != want {
+		t.Errorf("Expected %q, got %q", want, lines[0])
+	}
+	if want := "[INFO] (DCP) stream ended  <> vb=<no value>"; lines[1] != want {
+		t.Errorf("Expected %q, got %q", want, lines[1])
+	}
+
+	if _, err := NewTemplateFormatter("{{.Message"); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
+
+func TestConfigureTemplate(t *testing.T) {
+	l := New()
+	err := l.Configure(Config{Outputs: []OutputConfig{{Path: "stderr", Template: "{{.Nope"}}})
+	if err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Errorf("Expected an invalid template error, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+// Routes the default logger's output to a test; see Logger.SetTestOutput.
+func SetTestOutput(t testing.TB) {
+	std.SetTestOutput(t)
+}
+
+// Replaces the logger's outputs with t.Logf, so that messages are shown
+// with the test which logged them, and only if it fails or is verbose. The
+// previous outputs are restored when the test and its subtests complete.
+func (l *Logger) SetTestOutput(t testing.TB) {
+	old := atomic.LoadPointer(&l.sinks)
+	atomic.StorePointer(&l.sinks,
+		unsafe.Pointer(&[]*sink{l.newSink(testWriter{t}, SinkOptions{})}))
+	l.configChanged()
+	t.Cleanup(func() {
+		atomic.StorePointer(&l.sinks, old)
+		l.configChanged()
+	})
+}
+
+// Saves the default logger's settings; see Logger.Save.
+func Save() (restore func()) {
+	return std.Save()
+}
+
+// Saves the logger's level, enabled keys, outputs, flags and caller setting,
+// returning a function which restores them.
+func (l *Logger) Save() (restore func()) {
+	var level int32
+	if l.levels != nil {
+		level = atomic.LoadInt32(&l.levels.level)
+	} else {
+		level = l.level.Load()
+	}
+	keys := l.EnabledKeys()
+	sinks := atomic.LoadPointer(&l.sinks)
+	flags := l.Flags()
+	includeCaller := l.IsIncludeCaller()
+	return func() {
+		l.setLevel(LogLevel(level))
+		l.keys.replace(keys)
+		atomic.StorePointer(&l.sinks, sinks)
+		l.SetFlags(flags)
+		l.setIncludeCaller(includeCaller)
+		l.configChanged()
+	}
+}
+
+// Applies options to the default logger for a test; see Logger.Scoped.
+func Scoped(t testing.TB, opts ...Option) {
+	std.Scoped(t, opts...)
+}
+
+// Applies options, such as WithLevel, WithKeys and WithOutput, to the logger
+// until the test and its subtests complete, when its settings are restored
+// (see Save), even if the test panics. Tests which call Scoped on the same
+// logger run their scoped sections one at a time, so that parallel tests
+// don't see each other's settings. Calling it again within the test or one
+// of its subtests would wait forever, so fails the test instead.
+func (l *Logger) Scoped(t testing.TB, opts ...Option) {
+	t.Helper()
+	name := t.Name()
+	if owner := (*string)(atomic.LoadPointer(&l.scopeOwner)); owner != nil &&
+		(*owner == name || strings.HasPrefix(name, *owner+"/")) {
+		t.Fatalf("clog: Scoped called again within %s", *owner)
+	}
+	l.scopeMu.Lock()
+	atomic.StorePointer(&l.scopeOwner, unsafe.Pointer(&name))
+	restore := l.Save()
+	t.Cleanup(func() {
+		restore()
+		atomic.StorePointer(&l.scopeOwner, nil)
+		l.scopeMu.Unlock()
+	})
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.configChanged()
+}
+
+// Writes each message to a test's log.
+type testWriter struct {
+	t testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Logf("%s", bytes.TrimSuffix(p, []byte{'\n'}))
+	return len(p), nil
+}
@@ -0,0 +1,146 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// A testing.TB which records what is logged to it.
+type recordingTB struct {
+	testing.TB
+	logs     []string
+	cleanups []func()
+	fatal    string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recordingTB) Fatalf(format string, args ...interface{}) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// Runs f in a goroutine, as Fatalf exits it, and waits for it.
+func (r *recordingTB) run(f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	<-done
+}
+
+func TestSetTestOutput(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	r := &recordingTB{TB: t}
+
+	l.SetTestOutput(r)
+	l.Log("in test")
+	l.Warnf("careful")
+	if len(r.logs) != 2 || r.logs[0] != "in test" || r.logs[1] != "WARN: careful" {
+		t.Errorf("Unexpected test logs %q", r.logs)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Expected nothing written to the previous output, got %q", b.String())
+	}
+
+	for _, f := range r.cleanups {
+		f()
+	}
+	l.Log("after test")
+	if len(r.logs) != 2 || b.String() != "after test\n" {
+		t.Errorf("Expected the previous output to be restored, got %q", b.String())
+	}
+}
+
+func TestSetTestOutputLogs(t *testing.T) {
+	l := New()
+	l.SetTestOutput(t)
+	l.Log("shown with -v")
+}
+
+func TestScoped(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("dcp"))
+
+	t.Run("group", func(t *testing.T) {
+		for _, level := range []LogLevel{LevelDebug, LevelWarning, LevelError} {
+			level := level
+			t.Run(level.String(), func(t *testing.T) {
+				t.Parallel()
+				sb := &bytes.Buffer{}
+				l.Scoped(t, WithLevel(level), WithOutput(sb), WithKeys("rest"))
+				l.Debugf("debug")
+				if l.GetLevel() != level || !l.KeyEnabled("rest") {
+					t.Errorf("Expected level %v and key rest, got %v and %v", level, l.GetLevel(), l.EnabledKeys())
+				}
+				if (sb.Len() != 0) != (level == LevelDebug) {
+					t.Errorf("Unexpected output %q at level %v", sb.String(), level)
+				}
+			})
+		}
+	})
+
+	if l.GetLevel() != LevelNormal || l.KeyEnabled("rest") || !l.KeyEnabled("dcp") {
+		t.Errorf("Expected settings to be restored, got %v and %v", l.GetLevel(), l.EnabledKeys())
+	}
+	l.Log("restored")
+	if b.String() != "restored\n" {
+		t.Errorf("Expected the output to be restored, got %q", b.String())
+	}
+}
+
+func TestScopedNested(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	outer := &recordingTB{TB: t}
+	l.Scoped(outer, WithLevel(LevelWarning))
+
+	again := &recordingTB{TB: t}
+	again.run(func() { l.Scoped(again) })
+	t.Run("sub", func(t *testing.T) {
+		nested := &recordingTB{TB: t}
+		nested.run(func() { l.Scoped(nested) })
+		if nested.fatal == "" {
+			t.Errorf("Expected Scoped in a subtest to fail")
+		}
+	})
+	if again.fatal == "" {
+		t.Errorf("Expected a second Scoped in the test to fail")
+	}
+
+	for _, f := range outer.cleanups {
+		f()
+	}
+	if l.GetLevel() != LevelNormal {
+		t.Errorf("Expected the level to be restored, got %v", l.GetLevel())
+	}
+	next := &recordingTB{TB: t}
+	next.run(func() { l.Scoped(next) })
+	if next.fatal != "" {
+		t.Errorf("Expected Scoped to work after the scope ended, got %q", next.fatal)
+	}
+	for _, f := range next.cleanups {
+		f()
+	}
+}
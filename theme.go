@@ -0,0 +1,111 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// A Color is an ANSI escape sequence setting the color of text output.
+type Color string
+
+const (
+	ColorNone          Color = ""
+	ColorRed           Color = "\x1b[31m"
+	ColorGreen         Color = "\x1b[32m"
+	ColorYellow        Color = "\x1b[33m"
+	ColorBlue          Color = "\x1b[34m"
+	ColorMagenta       Color = "\x1b[35m"
+	ColorCyan          Color = "\x1b[36m"
+	ColorGray          Color = "\x1b[90m"
+	ColorBrightRed     Color = "\x1b[1;31m"
+	ColorBrightMagenta Color = "\x1b[1;35m"
+)
+
+// A ColorTheme gives the colors of the messages at each level. Levels
+// missing from it are colored red.
+type ColorTheme map[LogLevel]Color
+
+// The current ColorTheme, or nil to color every level red.
+var colorTheme unsafe.Pointer
+
+// Returns a theme distinguishing the levels on dark terminal backgrounds.
+func DarkTheme() ColorTheme {
+	return ColorTheme{
+		LevelTrace:    ColorGray,
+		LevelDebug:    ColorCyan,
+		LevelNotice:   ColorGreen,
+		LevelWarning:  ColorYellow,
+		LevelError:    ColorRed,
+		LevelCritical: ColorBrightRed,
+		LevelPanic:    ColorBrightRed,
+		levelTemp:     ColorMagenta,
+	}
+}
+
+// Returns a theme distinguishing the levels on light terminal backgrounds,
+// avoiding yellow and gray, which are hard to read on them.
+func LightTheme() ColorTheme {
+	return ColorTheme{
+		LevelTrace:    ColorCyan,
+		LevelDebug:    ColorBlue,
+		LevelNotice:   ColorGreen,
+		LevelWarning:  ColorMagenta,
+		LevelError:    ColorRed,
+		LevelCritical: ColorBrightRed,
+		LevelPanic:    ColorBrightRed,
+		levelTemp:     ColorBrightMagenta,
+	}
+}
+
+// Sets the colors of the levels in text output, for all loggers, replacing
+// those set before. Pass nil to restore the default of coloring every level
+// red.
+func SetColorTheme(theme ColorTheme) {
+	var p unsafe.Pointer
+	if theme != nil {
+		copied := make(ColorTheme, len(theme))
+		for level, color := range theme {
+			copied[level] = color
+		}
+		p = unsafe.Pointer(&copied)
+	}
+	atomic.StorePointer(&colorTheme, p)
+}
+
+// Sets the color of one level in text output, for all loggers.
+func SetLevelColor(level LogLevel, color Color) {
+	for {
+		otp := atomic.LoadPointer(&colorTheme)
+		theme := ColorTheme{}
+		if otp != nil {
+			for l, c := range *(*ColorTheme)(otp) {
+				theme[l] = c
+			}
+		}
+		theme[level] = color
+		if atomic.CompareAndSwapPointer(&colorTheme, otp, unsafe.Pointer(&theme)) {
+			return
+		}
+	}
+}
+
+// Returns the color of an entry's prefix and message in text output.
+func levelColor(e *Entry) string {
+	if !colorEnabled() {
+		return "" // Color is disabled
+	}
+	if tp := atomic.LoadPointer(&colorTheme); tp != nil {
+		if color, ok := (*(*ColorTheme)(tp))[e.Level]; ok {
+			return reduceColor(color)
+		}
+	}
+	return reduceColor(Color(e.color))
+}
@@ -0,0 +1,56 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColorTheme(t *testing.T) {
+	requireDebug(t)
+	defer SetColorTheme(nil)
+	if !colorEnabled() {
+		defer DisableColor()
+	} else {
+		defer EnableColor()
+	}
+	EnableColor()
+	defer SetColorDepth(ColorDepth(colorDepth))
+	SetColorDepth(ColorDepth16)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false), WithLevel(LevelDebug))
+
+	l.Warnf("w")
+	SetColorTheme(DarkTheme())
+	l.Warnf("w")
+	l.Debugf("d")
+	SetLevelColor(LevelDebug, ColorGreen)
+	l.Debugf("d")
+	DisableColor()
+	l.Debugf("d")
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	expect := []string{
+		"\x1b[31mWARN: w\x1b[0m\x1b[2m",
+		"\x1b[33mWARN: w\x1b[0m\x1b[2m",
+		"\x1b[36mDEBU: d\x1b[0m\x1b[2m",
+		"\x1b[32mDEBU: d\x1b[0m\x1b[2m",
+		"DEBU: d",
+	}
+	if len(lines) != len(expect) {
+		t.Fatalf("Unexpected output %q", b.String())
+	}
+	for i := range expect {
+		if lines[i] != expect[i] {
+			t.Errorf("Line %d is %q, expected %q", i, lines[i], expect[i])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// The date and time output flags.
+const timeFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC
+
+// How the logger renders timestamps, if not by the output flags.
+type timeFormat struct {
+	layout string         // Empty for the default layout
+	loc    *time.Location // Nil for local time
+	mode   TimeMode       // What text outputs' timestamps show
+}
+
+// Sets the layout of the default logger's timestamps; see
+// Logger.SetTimeFormat.
+func SetTimeFormat(layout string) {
+	std.SetTimeFormat(layout)
+}
+
+// Sets the time zone of the default logger's timestamps; see
+// Logger.SetTimeLocation.
+func SetTimeLocation(loc *time.Location) {
+	std.SetTimeLocation(loc)
+}
+
+// Sets the layout, as for time.Format, of the timestamps in all of the
+// logger's outputs, such as time.RFC3339Nano. Text outputs still show the
+// timestamp only if the output flags include the date or time. "" restores
+// the default layouts.
+func (l *Logger) SetTimeFormat(layout string) {
+	l.updateTimeFormat(func(tf *timeFormat) { tf.layout = layout })
+}
+
+// Sets the time zone of the timestamps in all of the logger's outputs, such
+// as time.UTC. nil restores local time.
+func (l *Logger) SetTimeLocation(loc *time.Location) {
+	l.updateTimeFormat(func(tf *timeFormat) { tf.loc = loc })
+}
+
+func (l *Logger) updateTimeFormat(update func(*timeFormat)) {
+	for {
+		otp := atomic.LoadPointer(&l.timeFormat)
+		var newt timeFormat
+		if otp != nil {
+			newt = *(*timeFormat)(otp)
+		}
+		update(&newt)
+		ntp := unsafe.Pointer(&newt)
+		if newt == (timeFormat{}) {
+			ntp = nil
+		}
+		if atomic.CompareAndSwapPointer(&l.timeFormat, otp, ntp) {
+			break
+		}
+	}
+	l.SetFlags(l.Flags()) // Updates the text outputs' flags
+}
+
+// Returns the flags for the log.Loggers of text outputs, which leave the
+// timestamp to the logger if it has a time format.
+func (l *Logger) sinkFlags(flags int) int {
+	if atomic.LoadPointer(&l.timeFormat) != nil {
+		return flags &^ timeFlags
+	}
+	return flags
+}
+
+// Sets the entry's timestamps, if the logger has a time format.
+func (l *Logger) stamp(e *Entry) {
+	tp := atomic.LoadPointer(&l.timeFormat)
+	if tp == nil {
+		return
+	}
+	tf := (*timeFormat)(tp)
+	t := e.Time
+	if tf.loc != nil {
+		t = t.In(tf.loc)
+	}
+	flags := l.Flags()
+	if flags&timeFlags != 0 && tf.mode != TimeWallClock {
+		e.textTime = l.elapsedTime(tf.mode, e.Time)
+	} else if flags&timeFlags != 0 {
+		layout := tf.layout
+		if layout == "" {
+			layout = defaultTextLayout(flags)
+		}
+		e.textTime = t.Format(layout)
+	}
+	if tf.layout != "" {
+		e.stamp = t.Format(tf.layout)
+	} else {
+		e.stamp = t.Format(time.RFC3339Nano)
+	}
+}
+
+// Returns the layout of the timestamps written by the log package with the
+// given flags.
+func defaultTextLayout(flags int) string {
+	var layout string
+	if flags&log.Ldate != 0 {
+		layout = "2006/01/02"
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		if layout != "" {
+			layout += " "
+		}
+		layout += "15:04:05"
+		if flags&log.Lmicroseconds != 0 {
+			layout += ".000000"
+		}
+	}
+	return layout
+}
+
+// Returns the entry's timestamp, for the structured formats.
+func (e *Entry) timestamp() string {
+	if e.stamp != "" {
+		return e.stamp
+	}
+	return e.Time.Format(time.RFC3339Nano)
+}
+
+// Appends the entry's timestamp to dst, as timestamp does.
+func (e *Entry) appendTimestamp(dst []byte) []byte {
+	if e.stamp != "" {
+		return append(dst, e.stamp...)
+	}
+	return e.Time.AppendFormat(dst, time.RFC3339Nano)
+}
@@ -0,0 +1,57 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeFormat(t *testing.T) {
+	text, js := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(text), WithIncludeCaller(false))
+	l.AddOutput(js, SinkOptions{Format: FormatJSON})
+	DisableColor()
+
+	l.SetTimeLocation(time.UTC)
+	l.Log("default layout")
+	l.SetTimeFormat(time.RFC3339Nano)
+	l.Warnf("custom layout")
+	l.DisableTime()
+	l.Log("no time")
+	l.SetFlags(log.LstdFlags)
+	l.SetTimeFormat("")
+	l.SetTimeLocation(nil)
+	l.Log("stdlib")
+
+	exp := []*regexp.Regexp{
+		regexp.MustCompile(`^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d default layout$`),
+		regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+)?Z WARN: custom layout$`),
+		regexp.MustCompile(`^no time$`),
+		regexp.MustCompile(`^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d stdlib$`),
+	}
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if len(lines) != len(exp) {
+		t.Fatalf("Expected %d lines, got %q", len(exp), text.String())
+	}
+	for i, re := range exp {
+		if !re.MatchString(lines[i]) {
+			t.Errorf("Unexpected line %q", lines[i])
+		}
+	}
+
+	lines = strings.Split(strings.TrimSpace(js.String()), "\n")
+	if !regexp.MustCompile(`^\{"time":"[^"]+Z",`).MatchString(lines[1]) {
+		t.Errorf("Expected a UTC time, got %q", lines[1])
+	}
+}
@@ -0,0 +1,72 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"strconv"
+	"time"
+)
+
+// TimeMode selects what the timestamps of text outputs show.
+type TimeMode int
+
+const (
+	TimeWallClock     = TimeMode(iota) // The time of day, as set by the flags or SetTimeFormat
+	TimeSinceStart                     // The time since the process started, e.g. "1.52s"
+	TimeSincePrevious                  // The time since the previous entry was written, e.g. "+12.3ms"
+)
+
+// Approximately when the process started: when the package was initialized.
+var processStart = time.Now()
+
+// Sets the default logger's time mode; see Logger.SetTimeMode.
+func SetTimeMode(mode TimeMode) {
+	std.SetTimeMode(mode)
+}
+
+// Sets what the timestamps of the logger's text outputs show, for profiling
+// startup sequences or rebalance phases, where elapsed times are more useful
+// than the time of day. Text outputs still show the timestamp only if the
+// output flags include the date or time. Structured outputs keep wall-clock
+// timestamps.
+func (l *Logger) SetTimeMode(mode TimeMode) {
+	l.updateTimeFormat(func(tf *timeFormat) { tf.mode = mode })
+}
+
+// Returns the timestamp of a text output in the given mode, updating the
+// time of the previous entry.
+func (l *Logger) elapsedTime(mode TimeMode, t time.Time) string {
+	prev := l.lastWritten.Swap(t.UnixNano())
+	switch {
+	case mode == TimeSinceStart:
+		return formatElapsed(t.Sub(processStart))
+	case prev == 0:
+		return "+" + formatElapsed(t.Sub(processStart))
+	default:
+		return "+" + formatElapsed(t.Sub(time.Unix(0, prev)))
+	}
+}
+
+// Formats d with one decimal place in the largest unit in which it is at
+// least 1, e.g. "12.3ms".
+func formatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	units := []struct {
+		unit time.Duration
+		name string
+	}{{time.Second, "s"}, {time.Millisecond, "ms"}, {time.Microsecond, "µs"}}
+	for _, u := range units {
+		if d >= u.unit {
+			return strconv.FormatFloat(float64(d)/float64(u.unit), 'f', 1, 64) + u.name
+		}
+	}
+	return strconv.Itoa(int(d)) + "ns"
+}
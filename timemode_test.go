@@ -0,0 +1,67 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTimeMode(t *testing.T) {
+	b, js := &bytes.Buffer{}, &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(log.Ltime), WithIncludeCaller(false))
+	l.AddOutput(js, SinkOptions{Format: FormatJSON})
+	var now time.Time
+	l.AddHook(func(e Entry) (Entry, bool) {
+		e.Time = now
+		return e, true
+	})
+
+	now = processStart.Add(1520 * time.Millisecond)
+	l.SetTimeMode(TimeSinceStart)
+	l.Log("warmup")
+	l.SetTimeMode(TimeSincePrevious)
+	now = now.Add(12345 * time.Microsecond)
+	l.Log("bucket loaded")
+	now = now.Add(800 * time.Nanosecond)
+	l.Log("vbuckets opened")
+	now = now.Add(2 * time.Minute)
+	l.Log("rebalance done")
+	l.SetTimeMode(TimeWallClock)
+	l.Log("wall clock")
+
+	expect := regexp.MustCompile(`^1\.5s warmup\n` +
+		`\+12\.3ms bucket loaded\n` +
+		`\+800ns vbuckets opened\n` +
+		`\+120\.0s rebalance done\n` +
+		`\d\d:\d\d:\d\d wall clock\n$`)
+	if !expect.MatchString(b.String()) {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+	if !bytes.Contains(js.Bytes(), []byte(`"time":"`+now.Format(time.RFC3339Nano)+`"`)) {
+		t.Errorf("Expected wall-clock times in JSON, got %q", js.String())
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	for d, expect := range map[time.Duration]string{
+		0:                        "0ns",
+		999 * time.Nanosecond:    "999ns",
+		1500 * time.Nanosecond:   "1.5µs",
+		12345 * time.Microsecond: "12.3ms",
+		90 * time.Second:         "90.0s",
+	} {
+		if s := formatElapsed(d); s != expect {
+			t.Errorf("formatElapsed(%v) = %q, expected %q", d, s, expect)
+		}
+	}
+}
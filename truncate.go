@@ -0,0 +1,106 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Sets the default logger's maximum message length; see
+// Logger.SetMaxMessageLength.
+func SetMaxMessageLength(n int) {
+	std.SetMaxMessageLength(n)
+}
+
+// Thread-safe API for limiting messages to n bytes, so that an accidentally
+// logged document body can't produce a multi-megabyte line. Longer messages
+// are cut at a rune boundary and marked "…(truncated 12480 bytes)"; redaction
+// tags left open by the cut are closed first. The limit applies before hooks
+// are run. (default 0, no limit)
+func (l *Logger) SetMaxMessageLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&l.maxMessageLen, int32(n))
+}
+
+// Returns the logger's maximum message length, or 0 if there is none.
+func (l *Logger) GetMaxMessageLength() int {
+	return int(atomic.LoadInt32(&l.maxMessageLen))
+}
+
+// Truncates the entry's message, and its callback rendering, to the logger's
+// maximum message length.
+func (l *Logger) truncate(e *Entry) {
+	if max := l.GetMaxMessageLength(); max > 0 {
+		e.Message = truncateMessage(e.Message, max)
+		e.text = truncateMessage(e.text, max)
+	}
+}
+
+// Cuts s to at most max bytes, without splitting a rune or a redaction tag,
+// and appends a marker saying how many bytes were removed. Tags left open by
+// the cut are closed before the marker, so that RedactLogs doesn't take the
+// rest of the log for user data.
+func truncateMessage(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	i := max
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	for j := i - 1; j >= 0 && j > i-len("</ud>"); j-- {
+		if s[j] == '<' && tagAt(s[j:]) > i-j {
+			i = j
+			break
+		}
+	}
+	return s[:i] + closeTags(s[:i]) + "…(truncated " + strconv.Itoa(len(s)-i) + " bytes)"
+}
+
+// Returns the length of the redaction tag, such as <ud> or </ud>, which s
+// starts with, or 0 if there is none.
+func tagAt(s string) int {
+	for _, tag := range tags {
+		if strings.HasPrefix(s, "<"+tag+">") {
+			return len(tag) + 2
+		} else if strings.HasPrefix(s, "</"+tag+">") {
+			return len(tag) + 3
+		}
+	}
+	return 0
+}
+
+// Returns the closing tags for the redaction tags left open in s, innermost
+// first.
+func closeTags(s string) string {
+	var open []string
+	for i := strings.IndexByte(s, '<'); i >= 0; i = strings.IndexByte(s, '<') {
+		s = s[i:]
+		n := tagAt(s)
+		switch {
+		case n == 0:
+			n = 1
+		case s[1] != '/':
+			open = append(open, s[1:n-1])
+		case len(open) > 0 && open[len(open)-1] == s[2:n-1]:
+			open = open[:len(open)-1]
+		}
+		s = s[n:]
+	}
+	var b strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
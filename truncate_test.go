@@ -0,0 +1,61 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMaxMessageLength(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.SetMaxMessageLength(10)
+	l.Log("short")
+	l.Log("doc: %s", strings.Repeat("x", 100))
+	l.Log("ünïcödé text") // The limit falls within "é"
+	l.SetMaxMessageLength(0)
+	l.Log("%s", strings.Repeat("y", 20))
+
+	expect := "short\n" +
+		"doc: xxxxx…(truncated 95 bytes)\n" +
+		"ünïcöd…(truncated 7 bytes)\n" +
+		strings.Repeat("y", 20) + "\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+}
+
+func TestMaxMessageLengthTagged(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	l.SetMaxMessageLength(16)
+	l.Log("doc %v", UD(strings.Repeat("x", 20)))
+	l.Log("key %v", UD("k123")) // The limit falls within "</ud>"
+	l.Log("next %v", SD("n1"))
+
+	expect := "doc <ud>xxxxxxxx</ud>…(truncated 17 bytes)\n" +
+		"key <ud>k123</ud>…(truncated 5 bytes)\n" +
+		"next <sd>n1</sd>\n"
+	if b.String() != expect {
+		t.Errorf("Unexpected output %q, expected %q", b.String(), expect)
+	}
+
+	var redacted bytes.Buffer
+	if err := RedactLogs(&redacted, b, RedactRemove); err != nil {
+		t.Fatal(err)
+	}
+	expect = "doc <ud></ud>…(truncated 17 bytes)\n" +
+		"key <ud></ud>…(truncated 5 bytes)\n" +
+		"next <sd>n1</sd>\n"
+	if redacted.String() != expect {
+		t.Errorf("Unexpected redacted output %q, expected %q", redacted.String(), expect)
+	}
+}
@@ -0,0 +1,121 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"math"
+	"time"
+)
+
+// How a Field created by F holds its value, if not in Value.
+type fieldKind uint8
+
+const (
+	kindAny fieldKind = iota // In Value
+	kindInt
+	kindInt64
+	kindUint64
+	kindFloat64
+	kindBool
+	kindDuration
+	kindString
+)
+
+// F returns a field with the given key and value. Values of type int, int64,
+// uint64, float64, bool, time.Duration and string are held without boxing
+// them in an interface, so passing them to LogFields doesn't allocate if the
+// message is filtered out; the field's Value is set when it is logged.
+// Values of other types are held in Value.
+func F[T any](key string, v T) Field {
+	// Switching on a pointer keeps v from escaping for the common types.
+	switch p := any(&v).(type) {
+	case *int:
+		return Field{Key: key, kind: kindInt, num: uint64(*p)}
+	case *int64:
+		return Field{Key: key, kind: kindInt64, num: uint64(*p)}
+	case *uint64:
+		return Field{Key: key, kind: kindUint64, num: *p}
+	case *float64:
+		return Field{Key: key, kind: kindFloat64, num: math.Float64bits(*p)}
+	case *bool:
+		return Field{Key: key, kind: kindBool, num: uint64(btoi(*p))}
+	case *time.Duration:
+		return Field{Key: key, kind: kindDuration, num: uint64(*p)}
+	case *string:
+		return Field{Key: key, kind: kindString, str: *p}
+	}
+	return Field{Key: key, Value: v}
+}
+
+// Returns the field with its value in Value.
+func (f Field) resolve() Field {
+	switch f.kind {
+	case kindAny:
+		return f
+	case kindInt:
+		f.Value = int(f.num)
+	case kindInt64:
+		f.Value = int64(f.num)
+	case kindUint64:
+		f.Value = f.num
+	case kindFloat64:
+		f.Value = math.Float64frombits(f.num)
+	case kindBool:
+		f.Value = f.num != 0
+	case kindDuration:
+		f.Value = time.Duration(f.num)
+	case kindString:
+		f.Value = f.str
+	}
+	return Field{Key: f.Key, Value: f.Value}
+}
+
+// Logs a message with the given fields at the given level, to the default
+// logger; see Logger.LogFields.
+func LogFields(level LogLevel, msg string, fields ...Field) {
+	if std.wants(level) {
+		std.doFields(level, msg, fields)
+	}
+}
+
+// Logs a message with the given fields, which are appended to the logger's
+// own, at the given level. Create the fields with F to avoid allocating if
+// the level is filtered out. It doesn't panic or exit at LevelPanic.
+func (l *Logger) LogFields(level LogLevel, msg string, fields ...Field) {
+	if l.wants(level) {
+		l.doFields(level, msg, fields)
+	}
+}
+
+// Like doLogf, for LogFields; it must also be called directly from the
+// exported logging function.
+func (l *Logger) doFields(level LogLevel, msg string, fields []Field) {
+	prefix := levelPrefix(level)
+	e := l.newEntry(level, fgRed, prefix, msg)
+	if len(fields) > 0 {
+		all := make([]Field, len(e.Fields), len(e.Fields)+len(fields))
+		copy(all, e.Fields)
+		for _, f := range fields {
+			all = append(all, f.resolve())
+		}
+		e.Fields = all
+	}
+	if l.callback != nil {
+		label := prefix
+		if label == "" {
+			label = "INFO"
+		}
+		if e.text = l.callbackText(label, "%s", []interface{}{msg}); e.text == "" {
+			return
+		}
+	}
+	l.setCaller(e, l.callerDepth(), l.wantsCaller(level))
+	e.Stack = l.stackTrace(level, l.callerDepth())
+	l.output(e)
+}
@@ -0,0 +1,66 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLogFields(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+
+	l.With(F("bucket", "default")).LogFields(LevelWarning, "slow flush",
+		F("vb", 12), F("seqno", int64(-1)), F("bytes", uint64(4096)), F("ratio", 0.5),
+		F("full", true), F("took", 1500*time.Millisecond), F("err", errors.New("disk full")))
+	l.LogFields(LevelDebug, "hidden", F("vb", 1))
+
+	exp := `WARN: slow flush bucket=default vb=12 seqno=-1 bytes=4096 ratio=0.5 full=true took=1.5s err="disk full"` + "\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
+
+func TestLogFieldsHookValues(t *testing.T) {
+	l := New(WithOutput(io.Discard))
+	var got []Field
+	l.AddHook(func(e Entry) (Entry, bool) {
+		got = e.Fields
+		return e, true
+	})
+	l.LogFields(LevelNormal, "msg", F("vb", 12), F("name", "n1"))
+	if len(got) != 2 || got[0] != (Field{Key: "vb", Value: 12}) || got[1] != (Field{Key: "name", Value: "n1"}) {
+		t.Errorf("Unexpected fields %#v", got)
+	}
+}
+
+func TestLogFieldsFilteredAllocs(t *testing.T) {
+	requireNoRace(t)
+	l := New(WithOutput(io.Discard))
+	name, took := "default", 3*time.Second
+	if n := testing.AllocsPerRun(100, func() {
+		l.LogFields(LevelDebug, "filtered", F("bucket", name), F("vb", 1024), F("took", took))
+	}); n != 0 {
+		t.Errorf("Expected no allocations for a filtered message, got %v", n)
+	}
+}
+
+func BenchmarkLogFieldsFiltered(b *testing.B) {
+	l := New(WithOutput(io.Discard))
+	name := "default"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.LogFields(LevelDebug, "filtered", F("bucket", name), F("vb", i))
+	}
+}
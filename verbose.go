@@ -0,0 +1,104 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import "sync/atomic"
+
+// Verbose logs debug messages at a verbosity, if it is enabled. It is
+// returned by V and VTo.
+type Verbose struct {
+	l   *Logger // Nil if disabled
+	key string
+}
+
+// Sets the default logger's verbosity; see Logger.SetVerbosity.
+func SetVerbosity(v int) {
+	std.SetVerbosity(v)
+}
+
+// Sets the default logger's verbosity for a key; see
+// Logger.SetKeyVerbosity.
+func SetKeyVerbosity(key string, v int) {
+	std.SetKeyVerbosity(key, v)
+}
+
+// Returns a Verbose for the default logger; see Logger.V.
+func V(v int) Verbose {
+	return std.V(v)
+}
+
+// Returns a Verbose for a key of the default logger; see Logger.VTo.
+func VTo(key string, v int) Verbose {
+	return std.VTo(key, v)
+}
+
+// Thread-safe API for setting the verbosity of debug messages logged with
+// V, so that chatty traces can be layered within LevelDebug, for instance
+// 1 for lifecycle events, 2 per request and 4 per item. (default 0)
+func (l *Logger) SetVerbosity(v int) {
+	atomic.StoreInt32(&l.verbosity, int32(v))
+}
+
+// Thread-safe API for setting the verbosity of debug messages logged with
+// VTo to the key, or with V by a logger Named key, in place of the logger's
+// verbosity. A negative v removes the key's verbosity.
+func (l *Logger) SetKeyVerbosity(key string, v int) {
+	if v < 0 {
+		l.keyVerbosity.Delete(key)
+	} else {
+		l.keyVerbosity.Store(key, int32(v))
+	}
+}
+
+// Returns the verbosity of messages to the key, or the logger's.
+func (l *Logger) verbosityOf(key string) int {
+	if key != "" {
+		if v, ok := l.keyVerbosity.Load(key); ok {
+			return int(v.(int32))
+		}
+	}
+	return int(atomic.LoadInt32(&l.verbosity))
+}
+
+// Returns a Verbose which logs debug messages if debug messages are
+// enabled and v is at most the logger's verbosity, or its name's:
+//
+//	l.V(2).Logf("request %d: %s", id, req)
+func (l *Logger) V(v int) Verbose {
+	if l.wants(LevelDebug) && v <= l.verbosityOf(l.name) {
+		return Verbose{l: l}
+	}
+	return Verbose{}
+}
+
+// Like V, for debug messages to a key, which must be enabled. Its
+// verbosity applies if it has one.
+func (l *Logger) VTo(key string, v int) Verbose {
+	if l.wantsKey(LevelDebug, key) && v <= l.verbosityOf(key) {
+		return Verbose{l: l, key: key}
+	}
+	return Verbose{}
+}
+
+// Reports whether messages are logged, to guard costly arguments.
+func (v Verbose) Enabled() bool {
+	return v.l != nil
+}
+
+// Logs a formatted debug message, if the verbosity is enabled.
+func (v Verbose) Logf(format string, args ...interface{}) {
+	if v.l == nil {
+		return
+	}
+	if v.key != "" {
+		v.l.doTof(LevelDebug, v.key, format, args...)
+	} else {
+		v.l.doLogf(LevelDebug, fgRed, "DEBU", format, args...)
+	}
+}
@@ -0,0 +1,46 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVerbosity(t *testing.T) {
+	requireDebug(t)
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithIncludeCaller(false))
+	DisableColor()
+	l.V(0).Logf("debug disabled")
+	l.SetLevel(LevelDebug)
+	l.V(0).Logf("v0")
+	l.V(1).Logf("v1 disabled")
+	l.SetVerbosity(2)
+	l.V(1).Logf("v1")
+	l.V(2).Logf("v2")
+	if l.V(3).Enabled() {
+		t.Errorf("V(3) enabled at verbosity 2")
+	}
+
+	l.VTo("DCP", 1).Logf("key disabled")
+	l.EnableKey("DCP")
+	l.VTo("DCP", 2).Logf("DCP v2")
+	l.SetKeyVerbosity("DCP", 4)
+	l.VTo("DCP", 4).Logf("DCP v4")
+	l.SetKeyVerbosity("planner", 0)
+	l.Named("planner").V(1).Logf("named v1 disabled")
+	l.SetKeyVerbosity("planner", -1)
+	l.Named("planner").V(1).Logf("named v1")
+
+	exp := "DEBU: v0\nDEBU: v1\nDEBU: v2\nDEBU: DCP: DCP v2\nDEBU: DCP: DCP v4\nDEBU: planner: named v1\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
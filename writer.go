@@ -0,0 +1,87 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+// Returns an io.Writer logging to the default logger; see Logger.Writer.
+func Writer(level LogLevel, key string) io.Writer {
+	return std.Writer(level, key)
+}
+
+// Returns a *log.Logger logging to the default logger; see Logger.StdLogger.
+func StdLogger(level LogLevel, key string) *log.Logger {
+	return std.StdLogger(level, key)
+}
+
+// Returns an io.Writer which logs each line written to it as a message at
+// the given level, sent to key (if not empty) as with To. It lets libraries
+// which only accept an io.Writer log through clog. A line starting with a
+// level, as "[WARN] ", "warning: " or "ERRO: ", is logged at that level
+// instead, without it.
+func (l *Logger) Writer(level LogLevel, key string) io.Writer {
+	return &logWriter{l, level, key}
+}
+
+// Returns a *log.Logger which logs through l, for APIs such as
+// http.Server.ErrorLog. The messages are logged as by Writer.
+func (l *Logger) StdLogger(level LogLevel, key string) *log.Logger {
+	return log.New(l.Writer(level, key), "", 0)
+}
+
+type logWriter struct {
+	l     *Logger
+	level LogLevel
+	key   string
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\r\n"), "\n") {
+		level, msg := w.level, strings.TrimSuffix(line, "\r")
+		if lv, rest, ok := cutLevel(msg); ok {
+			level, msg = lv, rest
+		}
+		w.l.Output(level, w.key, 0, msg)
+	}
+	return len(p), nil
+}
+
+// Splits a leading level, such as "[WARN] " or "error: ", from a line.
+func cutLevel(line string) (LogLevel, string, bool) {
+	var token, rest string
+	if strings.HasPrefix(line, "[") {
+		i := strings.Index(line, "] ")
+		if i < 0 {
+			return 0, "", false
+		}
+		token, rest = line[1:i], line[i+2:]
+	} else {
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			return 0, "", false
+		}
+		token, rest = line[:i], line[i+2:]
+	}
+	if len(token) < 4 || len(token) > 8 {
+		return 0, "", false
+	}
+	for level, prefix := range levelPrefixes {
+		if prefix != "" && strings.EqualFold(token, prefix) {
+			return LogLevel(level), rest, true
+		}
+	}
+	if level, err := ParseLevel(token); err == nil {
+		return level, rest, true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,49 @@
+//  Copyright 2012-Present Couchbase, Inc.
+//
+//  Use of this software is governed by the Business Source License included
+//  in the file licenses/BSL-Couchbase.txt.  As of the Change Date specified
+//  in that file, in accordance with the Business Source License, use of this
+//  software will be governed by the Apache License, Version 2.0, included in
+//  the file licenses/APL2.txt.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0), WithKeys("http"))
+	DisableColor()
+
+	fmt.Fprint(l.Writer(LevelWarning, ""), "first\r\nsecond\n")
+	l.StdLogger(LevelError, "http").Printf("http: TLS handshake error")
+	l.StdLogger(LevelError, "sql").Printf("dropped")
+	l.Writer(LevelDebug, "").Write([]byte("hidden\n"))
+
+	exp := "WARN: first\nWARN: second\nERRO: http: http: TLS handshake error\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}
+
+func TestStdLoggerLevels(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := New(WithOutput(b), WithFlags(0))
+	DisableColor()
+
+	std := l.StdLogger(LevelWarning, "")
+	std.Printf("[DEBUG] ignored")
+	std.Printf("[ERROR] broker down")
+	std.Println("info: connected")
+	std.Printf("retrying: 3 attempts")
+	fmt.Fprint(l.Writer(LevelWarning, ""), "CRIT: disk failed\n")
+
+	exp := "ERRO: broker down\nconnected\nWARN: retrying: 3 attempts\nCRIT: disk failed\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, b.String())
+	}
+}